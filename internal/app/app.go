@@ -13,12 +13,18 @@ import (
 	"eventbooker/internal/auth"
 	"eventbooker/internal/broker/rabbit"
 	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/expiry"
 	"eventbooker/internal/notification"
+	"eventbooker/internal/outbox"
 	"eventbooker/internal/repository/postgres"
 	"eventbooker/internal/service"
 	httpTransport "eventbooker/internal/transport/http"
 	"eventbooker/internal/transport/http/handler"
+	"eventbooker/internal/transport/telegram"
+	"eventbooker/internal/webhook"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	wbgin "github.com/wb-go/wbf/ginext"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
@@ -28,7 +34,14 @@ type App struct {
 	cfg      *config.AppConfig
 	server   *http.Server
 	postgres *postgres.Repository
-	broker   *rabbit.Broker
+	workers  []worker
+	users    *service.UserService
+}
+
+// worker is a background process started with the app and stopped on shutdown.
+type worker interface {
+	Start(ctx context.Context) error
+	Close() error
 }
 
 // New initializes all dependencies and creates the App.
@@ -40,31 +53,75 @@ func New(cfg *config.AppConfig) (*App, error) {
 	}
 
 	emailSender := notification.NewEmailSender(cfg)
-	telegramSender := notification.NewTelegramSender(cfg)
-
-	broker, err := rabbit.NewBroker(cfg, pg, emailSender, telegramSender)
+	templates := notification.NewTemplates(&cfg.Notification)
+	tgBot, err := tgbotapi.NewBotAPI(cfg.Telegram.BotToken)
 	if err != nil {
-		return nil, fmt.Errorf("rabbitmq: %w", err)
+		wbzlog.Logger.Error().Err(err).Msg("failed to create Telegram bot, Telegram notifications and commands are disabled")
+	} else if cfg.Telegram.BotUsername == "" {
+		cfg.Telegram.BotUsername = tgBot.Self.UserName
+	}
+	telegramSender := notification.NewTelegramSender(tgBot)
+	// Unless the policy is off, emails only go to verified addresses
+	dispatcher := notification.NewDispatcher(templates, emailSender, telegramSender, pg, cfg.EmailVerification.BlocksNotifications())
+	webhooks := webhook.NewPublisher(pg)
+	notifier := notifiers{dispatcher, webhooks}
+
+	// Expiry: the relay delivers expiry messages queued in the outbox to the broker.
+	// Without the broker they are discarded and the sweeper finds due bookings itself.
+	relay := outbox.NewRelay(pg, &cfg.Outbox)
+	relay.Handle(outbox.TopicBookingExpiry, outbox.Discard)
+	workers := []worker{relay}
+
+	if cfg.Expiry.UseBroker() {
+		broker, err := rabbit.NewBroker(cfg, pg, notifier)
+		if err != nil {
+			return nil, fmt.Errorf("rabbitmq: %w", err)
+		}
+		relay.Handle(outbox.TopicBookingExpiry, broker.PublishOutbox)
+		workers = append(workers, expiry.Expirer(broker))
+	}
+	if cfg.Expiry.UseSweeper() {
+		workers = append(workers, expiry.Expirer(expiry.NewSweeper(pg, notifier, &cfg.Expiry)))
 	}
 
+	// Expiry warnings and event reminders, retries of failed deliveries
+	workers = append(workers,
+		notification.NewScheduler(pg, dispatcher, &cfg.Notification),
+		notification.NewRetrier(pg, dispatcher, &cfg.Notification),
+		webhook.NewWorker(pg, &cfg.Webhook),
+	)
+
 	// Auth
 	jwtService := auth.NewService(&cfg.JWT)
 
 	// Services
-	bookingSvc := service.NewBookingService(pg, broker)
-	eventSvc := service.NewEventService(pg, &cfg.Event)
-	userSvc := service.NewUserService(pg, jwtService, cfg)
+	bookingSvc := service.NewBookingService(pg, notifier, &cfg.Booking, &cfg.EmailVerification)
+	eventSvc := service.NewEventService(pg, notifier, webhooks, &cfg.Event)
+	userSvc := service.NewUserService(pg, jwtService, notification.NewAccountMailer(templates, emailSender, &cfg.PasswordReset, &cfg.EmailVerification), notifier, cfg)
+	notificationSvc := service.NewNotificationService(pg)
+	webhookSvc := service.NewWebhookService(pg)
+
+	if err = userSvc.PromoteAdmins(context.Background(), cfg.Admin.UserIDs); err != nil {
+		return nil, err
+	}
 
 	// Handlers
 	eventHandler := handler.NewEventHandler(eventSvc, bookingSvc)
 	userHandler := handler.NewUserHandler(userSvc)
+	notificationHandler := handler.NewNotificationHandler(notificationSvc)
+	webhookHandler := handler.NewWebhookHandler(webhookSvc)
+
+	// Telegram commands and booking buttons
+	if tgBot != nil {
+		workers = append(workers, telegram.NewBot(tgBot, userSvc, bookingSvc, eventSvc, templates))
+	}
 
 	// Router
 	router := wbgin.New(cfg.Gin.Mode)
 	router.Use(wbgin.Logger(), wbgin.Recovery())
 	router.Use(corsMiddleware())
 
-	httpTransport.RegisterRoutes(router, userHandler, eventHandler, userSvc)
+	httpTransport.RegisterRoutes(router, userHandler, eventHandler, notificationHandler, webhookHandler, userSvc, pg, &cfg.Idempotency)
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	server := &http.Server{
@@ -76,12 +133,20 @@ func New(cfg *config.AppConfig) (*App, error) {
 		cfg:      cfg,
 		server:   server,
 		postgres: pg,
-		broker:   broker,
+		workers:  workers,
+		users:    userSvc,
 	}, nil
 }
 
 // Run starts the HTTP server and blocks until a shutdown signal is received.
 func (a *App) Run() {
+	// Start background workers
+	for _, w := range a.workers {
+		if err := w.Start(context.Background()); err != nil {
+			wbzlog.Logger.Fatal().Err(err).Msgf("failed to start %T", w)
+		}
+	}
+
 	// Start server
 	go func() {
 		wbzlog.Logger.Info().Msgf("server started on %s", a.server.Addr)
@@ -110,24 +175,40 @@ func (a *App) Run() {
 }
 
 func (a *App) stop() {
+	for _, w := range a.workers {
+		if err := w.Close(); err != nil {
+			log.Printf("failed to close %T: %v", w, err)
+		} else {
+			log.Printf("%T closed successfully", w)
+		}
+	}
+
+	// Emails still being sent in the background need the database.
+	if err := a.users.Close(); err != nil {
+		log.Printf("failed to close %T: %v", a.users, err)
+	}
+
 	if err := a.postgres.Close(); err != nil {
 		log.Printf("failed to close Postgres: %v", err)
 	} else {
 		log.Println("Postgres closed successfully")
 	}
+}
 
-	if err := a.broker.Close(); err != nil {
-		log.Printf("failed to close RabbitMQ: %v", err)
-	} else {
-		log.Println("RabbitMQ closed successfully")
+// notifiers passes booking notifications both to their owners and to webhook subscribers.
+type notifiers []service.Notifier
+
+func (n notifiers) Notify(kind notification.Kind, b *booking.Booking) {
+	for _, x := range n {
+		x.Notify(kind, b)
 	}
 }
 
 func corsMiddleware() wbgin.HandlerFunc {
 	return func(c *wbgin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
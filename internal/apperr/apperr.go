@@ -0,0 +1,89 @@
+// Package apperr defines the application error kinds shared by the domain,
+// service and repository layers and understood by the transport layer.
+package apperr
+
+import "errors"
+
+// Error kinds. Every *Error belongs to exactly one of them, so callers can
+// branch with errors.Is(err, apperr.ErrNotFound) regardless of the concrete error.
+var (
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrSoldOut      = errors.New("sold out")
+)
+
+// CodeInternal is reported for errors that carry no application code.
+const CodeInternal = "internal"
+
+// Error is an application error with a stable machine-readable code.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+// Error returns the human-readable message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the underlying cause to errors.Is/As.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// New creates an error of the given kind.
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Wrap creates an error of the given kind that keeps err as its cause.
+func Wrap(kind error, code, message string, err error) *Error {
+	return &Error{Kind: kind, Code: code, Message: message, Err: err}
+}
+
+// Validation creates an ErrValidation error.
+func Validation(code, message string) *Error {
+	return New(ErrValidation, code, message)
+}
+
+// Unauthorized creates an ErrUnauthorized error.
+func Unauthorized(code, message string) *Error {
+	return New(ErrUnauthorized, code, message)
+}
+
+// Forbidden creates an ErrForbidden error.
+func Forbidden(code, message string) *Error {
+	return New(ErrForbidden, code, message)
+}
+
+// NotFound creates an ErrNotFound error.
+func NotFound(code, message string) *Error {
+	return New(ErrNotFound, code, message)
+}
+
+// Conflict creates an ErrConflict error.
+func Conflict(code, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+// SoldOut creates an ErrSoldOut error.
+func SoldOut(code, message string) *Error {
+	return New(ErrSoldOut, code, message)
+}
+
+// Code returns the code of the first *Error in err's chain, or CodeInternal.
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,50 @@
+package apperr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"eventbooker/internal/apperr"
+)
+
+func TestError_Is(t *testing.T) {
+	err := apperr.NotFound("event_not_found", "event not found")
+
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatal("expected error to match ErrNotFound")
+	}
+	if errors.Is(err, apperr.ErrConflict) {
+		t.Fatal("did not expect error to match ErrConflict")
+	}
+	if err.Error() != "event not found" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestWrap_KeepsCause(t *testing.T) {
+	cause := errors.New("bad uuid")
+	err := apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid id", cause)
+
+	if !errors.Is(err, cause) {
+		t.Fatal("expected error to match its cause")
+	}
+	if !errors.Is(err, apperr.ErrValidation) {
+		t.Fatal("expected error to match ErrValidation")
+	}
+}
+
+func TestCode(t *testing.T) {
+	sentinel := apperr.SoldOut("sold_out", "no seats")
+	wrapped := fmt.Errorf("create booking: %w", sentinel)
+
+	if got := apperr.Code(wrapped); got != "sold_out" {
+		t.Fatalf("expected sold_out, got %q", got)
+	}
+	if !errors.Is(wrapped, sentinel) {
+		t.Fatal("expected wrapped error to match sentinel")
+	}
+	if got := apperr.Code(errors.New("db down")); got != apperr.CodeInternal {
+		t.Fatalf("expected %q, got %q", apperr.CodeInternal, got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"eventbooker/internal/apperr"
 	"eventbooker/internal/config"
 	"eventbooker/internal/domain/user"
 
@@ -11,6 +12,11 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidToken = apperr.Unauthorized("invalid_token", "invalid token")
+	ErrTokenExpired = apperr.Unauthorized("token_expired", "token has expired")
+)
+
 // Response holds a pair of JWT tokens.
 type Response struct {
 	AccessToken      string
@@ -20,37 +26,38 @@ type Response struct {
 	TokenType        string
 }
 
-// Payload holds the validated token claims.
+// Payload holds the validated token claims. TokenID is the jti of a refresh token.
 type Payload struct {
-	UserID string
+	UserID  string
+	Role    user.Role
+	TokenID string
 }
 
 // Service handles JWT token operations.
 type Service struct {
-	accessSecret    string
-	refreshSecret   string
-	expAccessToken  int // minutes
-	expRefreshToken int // hours
+	accessSecret   string
+	refreshSecret  string
+	expAccessToken int // minutes
 }
 
 // NewService creates a new JWT Service.
 func NewService(cfg *config.JWTConfig) *Service {
 	return &Service{
-		accessSecret:    cfg.AccessSecret,
-		refreshSecret:   cfg.RefreshSecret,
-		expAccessToken:  cfg.ExpAccessToken,
-		expRefreshToken: cfg.ExpRefreshToken,
+		accessSecret:   cfg.AccessSecret,
+		refreshSecret:  cfg.RefreshSecret,
+		expAccessToken: cfg.ExpAccessToken,
 	}
 }
 
-// GenerateTokens creates a new access/refresh token pair.
-func (s *Service) GenerateTokens(u *user.User) (*Response, error) {
+// GenerateTokens creates a new access/refresh token pair. The refresh token carries
+// the id and expiry of rt, which the caller stores.
+func (s *Service) GenerateTokens(u *user.User, rt *user.RefreshToken) (*Response, error) {
 	access, err := s.generateAccessToken(u)
 	if err != nil {
 		return nil, err
 	}
 
-	refresh, err := s.generateRefreshToken(u)
+	refresh, err := s.generateRefreshToken(rt)
 	if err != nil {
 		return nil, err
 	}
@@ -70,14 +77,23 @@ func (s *Service) ValidateToken(tokenStr string) (*Payload, error) {
 
 	uuidStr, ok := claims["uuid"].(string)
 	if !ok {
-		return nil, errors.New("invalid token payload")
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens issued before roles existed carry none.
+	role, _ := claims["role"].(string)
+	if role == "" {
+		role = string(user.RoleAttendee)
 	}
 
-	return &Payload{UserID: uuidStr}, nil
+	return &Payload{UserID: uuidStr, Role: user.Role(role)}, nil
 }
 
-// RefreshTokens generates a new token pair from a valid refresh token.
-func (s *Service) RefreshTokens(refreshToken string) (*Response, error) {
+// ValidateRefreshToken validates the signature and expiry of a refresh token and
+// returns its payload. Whether the token was used or revoked is up to the caller,
+// which also reloads the user before issuing new tokens, since the role is not part
+// of refresh tokens. Tokens without a jti predate rotation and are rejected.
+func (s *Service) ValidateRefreshToken(refreshToken string) (*Payload, error) {
 	claims, err := s.validateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
@@ -85,26 +101,35 @@ func (s *Service) RefreshTokens(refreshToken string) (*Response, error) {
 
 	uuidStr, ok := claims["uuid"].(string)
 	if !ok {
-		return nil, errors.New("invalid refresh token payload")
+		return nil, ErrInvalidToken
+	}
+	if _, err = uuid.Parse(uuidStr); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	jti, _ := claims["jti"].(string)
+	if _, err = uuid.Parse(jti); err != nil {
+		return nil, ErrInvalidToken
 	}
 
-	u := &user.User{ID: uuid.MustParse(uuidStr)}
-	return s.GenerateTokens(u)
+	return &Payload{UserID: uuidStr, TokenID: jti}, nil
 }
 
 func (s *Service) generateAccessToken(u *user.User) (string, error) {
 	claims := jwt.MapClaims{
 		"uuid": u.ID.String(),
+		"role": string(u.Role),
 		"exp":  time.Now().Add(time.Minute * time.Duration(s.expAccessToken)).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.accessSecret))
 }
 
-func (s *Service) generateRefreshToken(u *user.User) (string, error) {
+func (s *Service) generateRefreshToken(rt *user.RefreshToken) (string, error) {
 	claims := jwt.MapClaims{
-		"uuid": u.ID.String(),
-		"exp":  time.Now().Add(time.Hour * time.Duration(s.expRefreshToken)).Unix(),
+		"uuid": rt.UserID.String(),
+		"jti":  rt.ID.String(),
+		"exp":  rt.ExpiresAt.Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.refreshSecret))
@@ -113,18 +138,19 @@ func (s *Service) generateRefreshToken(u *user.User) (string, error) {
 func (s *Service) validateAccessToken(tokenStr string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.accessSecret), nil
-	})
+	}, jwt.WithExpirationRequired())
+	// The signature is checked before the expiry, so only genuine tokens are
+	// reported as expired.
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
 	if err != nil || !token.Valid {
-		return nil, errors.New("invalid access token")
+		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, errors.New("invalid claims")
-	}
-
-	if exp, ok := claims["exp"].(float64); !ok || time.Unix(int64(exp), 0).Before(time.Now()) {
-		return nil, errors.New("token has expired")
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
@@ -133,18 +159,17 @@ func (s *Service) validateAccessToken(tokenStr string) (jwt.MapClaims, error) {
 func (s *Service) validateRefreshToken(tokenStr string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.refreshSecret), nil
-	})
+	}, jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
 	if err != nil || !token.Valid {
-		return nil, errors.New("invalid refresh token")
+		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, errors.New("invalid claims")
-	}
-
-	if exp, ok := claims["exp"].(float64); !ok || time.Unix(int64(exp), 0).Before(time.Now()) {
-		return nil, errors.New("refresh token has expired")
+		return nil, ErrInvalidToken
 	}
 
 	return claims, nil
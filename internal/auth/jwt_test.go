@@ -1,6 +1,7 @@
 package auth_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -14,10 +15,9 @@ import (
 
 func newTestJWT() *auth.Service {
 	return auth.NewService(&config.JWTConfig{
-		AccessSecret:    "access-secret",
-		RefreshSecret:   "refresh-secret",
-		ExpAccessToken:  1,
-		ExpRefreshToken: 1,
+		AccessSecret:   "access-secret",
+		RefreshSecret:  "refresh-secret",
+		ExpAccessToken: 1,
 	})
 }
 
@@ -25,11 +25,15 @@ func newTestUser() *user.User {
 	return &user.User{ID: uuid.New()}
 }
 
+func generateTokens(s *auth.Service, u *user.User) (*auth.Response, error) {
+	return s.GenerateTokens(u, user.NewRefreshToken(u.ID, time.Hour))
+}
+
 func TestGenerateTokens_Success(t *testing.T) {
 	s := newTestJWT()
 	u := newTestUser()
 
-	resp, err := s.GenerateTokens(u)
+	resp, err := generateTokens(s, u)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -41,7 +45,7 @@ func TestGenerateTokens_Success(t *testing.T) {
 func TestValidateToken_Success(t *testing.T) {
 	s := newTestJWT()
 	u := newTestUser()
-	tokens, _ := s.GenerateTokens(u)
+	tokens, _ := generateTokens(s, u)
 
 	payload, err := s.ValidateToken(tokens.AccessToken)
 	if err != nil {
@@ -72,23 +76,59 @@ func TestValidateToken_NoUUIDField(t *testing.T) {
 	}
 }
 
+func TestValidateToken_Role(t *testing.T) {
+	s := newTestJWT()
+	u := newTestUser()
+	u.Role = user.RoleOrganizer
+	tokens, _ := generateTokens(s, u)
+
+	payload, err := s.ValidateToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if payload.Role != user.RoleOrganizer {
+		t.Fatalf("expected organizer role, got %q", payload.Role)
+	}
+}
+
+func TestValidateToken_NoRoleIsAttendee(t *testing.T) {
+	s := newTestJWT()
+	claims := jwt.MapClaims{"uuid": uuid.New().String(), "exp": time.Now().Add(time.Minute).Unix()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("access-secret"))
+
+	payload, err := s.ValidateToken(tokenStr)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if payload.Role != user.RoleAttendee {
+		t.Fatalf("expected attendee role, got %q", payload.Role)
+	}
+}
+
 func TestRefreshTokens_Success(t *testing.T) {
 	s := newTestJWT()
 	u := newTestUser()
-	tokens, _ := s.GenerateTokens(u)
+	rt := user.NewRefreshToken(u.ID, time.Hour)
+	tokens, _ := s.GenerateTokens(u, rt)
 
-	newTokens, err := s.RefreshTokens(tokens.RefreshToken)
+	payload, err := s.ValidateRefreshToken(tokens.RefreshToken)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if newTokens.AccessToken == "" || newTokens.RefreshToken == "" {
-		t.Fatal("new tokens must not be empty")
+	if payload.UserID != u.ID.String() {
+		t.Fatal("invalid payload user ID")
+	}
+	if payload.TokenID != rt.ID.String() {
+		t.Fatalf("expected jti %s, got %q", rt.ID, payload.TokenID)
+	}
+	if _, err = s.ValidateToken(tokens.RefreshToken); err == nil {
+		t.Fatal("expected a refresh token not to be accepted as an access token")
 	}
 }
 
 func TestRefreshTokens_Invalid(t *testing.T) {
 	s := newTestJWT()
-	_, err := s.RefreshTokens("invalid_refresh_token")
+	_, err := s.ValidateRefreshToken("invalid_refresh_token")
 	if err == nil {
 		t.Fatal("expected error for invalid refresh token")
 	}
@@ -100,12 +140,23 @@ func TestRefreshTokens_NoUUIDField(t *testing.T) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, _ := token.SignedString([]byte("refresh-secret"))
 
-	_, err := s.RefreshTokens(tokenStr)
+	_, err := s.ValidateRefreshToken(tokenStr)
 	if err == nil {
 		t.Fatal("expected error because uuid missing")
 	}
 }
 
+func TestRefreshTokens_NoJTI(t *testing.T) {
+	s := newTestJWT()
+	claims := jwt.MapClaims{"uuid": uuid.New().String(), "exp": time.Now().Add(time.Hour).Unix()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("refresh-secret"))
+
+	_, err := s.ValidateRefreshToken(tokenStr)
+	if err == nil {
+		t.Fatal("expected error because jti missing")
+	}
+}
+
 func TestValidateAccessToken_Expired(t *testing.T) {
 	cfg := &config.JWTConfig{
 		AccessSecret:   "access-secret",
@@ -115,27 +166,41 @@ func TestValidateAccessToken_Expired(t *testing.T) {
 
 	s := auth.NewService(cfg)
 	u := newTestUser()
-	expiredToken, _ := s.GenerateTokens(u)
+	expiredToken, _ := generateTokens(s, u)
 
 	_, err := s.ValidateToken(expiredToken.AccessToken)
-	if err == nil {
-		t.Fatal("expected error: access token expired")
+	if !errors.Is(err, auth.ErrTokenExpired) {
+		t.Fatalf("expected an expired access token, got %v", err)
 	}
 }
 
 func TestValidateRefreshToken_Expired(t *testing.T) {
-	cfg := &config.JWTConfig{
-		AccessSecret:    "access-secret",
-		RefreshSecret:   "refresh-secret",
-		ExpRefreshToken: -1,
+	s := newTestJWT()
+	u := newTestUser()
+	tokens, _ := s.GenerateTokens(u, user.NewRefreshToken(u.ID, -time.Hour))
+
+	_, err := s.ValidateRefreshToken(tokens.RefreshToken)
+	if !errors.Is(err, auth.ErrTokenExpired) {
+		t.Fatalf("expected an expired refresh token, got %v", err)
 	}
+}
 
-	s := auth.NewService(cfg)
-	u := newTestUser()
-	tokens, _ := s.GenerateTokens(u)
+func TestValidateToken_ExpiredWithWrongSecret(t *testing.T) {
+	claims := jwt.MapClaims{"uuid": uuid.New().String(), "exp": time.Now().Add(-time.Hour).Unix()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("other-secret"))
 
-	_, err := s.RefreshTokens(tokens.RefreshToken)
-	if err == nil {
-		t.Fatal("expected error: refresh token expired")
+	_, err := newTestJWT().ValidateToken(tokenStr)
+	if !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("expected a forged token to be invalid rather than expired, got %v", err)
+	}
+}
+
+func TestValidateToken_NoExpiry(t *testing.T) {
+	claims := jwt.MapClaims{"uuid": uuid.New().String()}
+	tokenStr, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("access-secret"))
+
+	_, err := newTestJWT().ValidateToken(tokenStr)
+	if !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("expected a token without exp to be invalid, got %v", err)
 	}
 }
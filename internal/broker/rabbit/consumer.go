@@ -3,16 +3,24 @@ package rabbit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/expiry"
 
 	"github.com/rabbitmq/amqp091-go"
 	wbrabbit "github.com/wb-go/wbf/rabbitmq"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
-func bookingExpiredHandler(repo StorageProvider, email EmailProvider, tg TelegramProvider) wbrabbit.MessageHandler {
+// delayPublisher re-publishes a booking message that arrived before the booking is due.
+type delayPublisher interface {
+	PublishMsg(ctx context.Context, bk *booking.Booking) error
+}
+
+func bookingExpiredHandler(repo StorageProvider, publisher delayPublisher, notifier expiry.Notifier) wbrabbit.MessageHandler {
 	return func(ctx context.Context, msg amqp091.Delivery) error {
 		wbzlog.Logger.Info().Msgf("received booking expired message: %s", string(msg.Body))
 
@@ -32,21 +40,20 @@ func bookingExpiredHandler(repo StorageProvider, email EmailProvider, tg Telegra
 			return nil
 		}
 
-		if err := repo.CancelBooking(ctx, payload.ID.String(), payload.EventID.String()); err != nil {
-			return err
+		if time.Now().Before(payload.ExpiredAt) {
+			return publisher.PublishMsg(ctx, &payload)
 		}
 
-		if payload.EmailNotification {
-			if err := email.Send(payload.EmailRecepient, payload.EventName, payload.Count); err != nil {
-				wbzlog.Logger.Error().Err(err).Msg("cannot send email notification")
+		promoted, err := repo.ExpireBooking(ctx, payload.ID.String(), payload.EventID.String())
+		if err != nil {
+			if errors.Is(err, booking.ErrAlreadyConfirmed) || errors.Is(err, booking.ErrAlreadyCancelled) {
+				wbzlog.Logger.Info().Msgf("booking %s processed concurrently, skipping", payload.ID.String())
+				return nil
 			}
+			return err
 		}
 
-		if payload.TelegramNotification {
-			if err := tg.Send(payload.TelegramRecepient, payload.EventName, payload.Count); err != nil {
-				wbzlog.Logger.Error().Err(err).Msg("cannot send telegram notification")
-			}
-		}
+		expiry.Finish(notifier, &payload, promoted)
 
 		return nil
 	}
@@ -0,0 +1,91 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type fakeStorage struct {
+	status   booking.Status
+	promoted []*booking.Booking
+	expired  []string
+}
+
+func (s *fakeStorage) ExpireBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error) {
+	s.expired = append(s.expired, bookingID)
+	return s.promoted, nil
+}
+
+func (s *fakeStorage) GetBookingStatus(ctx context.Context, id string) (booking.Status, error) {
+	return s.status, nil
+}
+
+type fakePublisher struct{ published []*booking.Booking }
+
+func (p *fakePublisher) PublishMsg(ctx context.Context, bk *booking.Booking) error {
+	p.published = append(p.published, bk)
+	return nil
+}
+
+type fakeNotifier struct{ kinds []notification.Kind }
+
+func (n *fakeNotifier) Notify(kind notification.Kind, b *booking.Booking) {
+	n.kinds = append(n.kinds, kind)
+}
+
+func delivery(t *testing.T, b *booking.Booking) amqp091.Delivery {
+	t.Helper()
+	body, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amqp091.Delivery{Body: body}
+}
+
+func TestBookingExpiredHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        booking.Status
+		expiresIn     time.Duration
+		wantPublished int
+		wantExpired   int
+		wantNotified  int
+	}{
+		{"not due yet is published again", booking.StatusCreated, time.Hour, 1, 0, 0},
+		{"due is expired and its owner and the promoted are notified", booking.StatusCreated, -time.Second, 0, 1, 2},
+		{"confirmed is skipped", booking.StatusConfirmed, -time.Second, 0, 0, 0},
+		{"cancelled is skipped even before it is due", booking.StatusCancelled, time.Hour, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		storage := &fakeStorage{status: tt.status, promoted: []*booking.Booking{{ID: uuid.New()}}}
+		publisher := &fakePublisher{}
+		notifier := &fakeNotifier{}
+		b := &booking.Booking{ID: uuid.New(), EventID: uuid.New(), ExpiredAt: time.Now().Add(tt.expiresIn)}
+
+		if err := bookingExpiredHandler(storage, publisher, notifier)(context.Background(), delivery(t, b)); err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+		if len(publisher.published) != tt.wantPublished || len(storage.expired) != tt.wantExpired || len(notifier.kinds) != tt.wantNotified {
+			t.Errorf("%s: expected %d published, %d expired, %d notified; got %d, %d, %d", tt.name,
+				tt.wantPublished, tt.wantExpired, tt.wantNotified, len(publisher.published), len(storage.expired), len(notifier.kinds))
+		}
+		if tt.wantPublished > 0 && !publisher.published[0].ExpiredAt.Equal(b.ExpiredAt) {
+			t.Errorf("%s: expected the expiry to be kept, got %v", tt.name, publisher.published[0].ExpiredAt)
+		}
+	}
+}
+
+func TestBookingExpiredHandler_InvalidPayload(t *testing.T) {
+	handler := bookingExpiredHandler(&fakeStorage{}, &fakePublisher{}, &fakeNotifier{})
+	if err := handler(context.Background(), amqp091.Delivery{Body: []byte("{")}); err == nil {
+		t.Fatal("expected an error for a malformed message")
+	}
+}
@@ -3,15 +3,21 @@ package rabbit
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"strconv"
 	"time"
 
 	"eventbooker/internal/domain/booking"
 
+	"github.com/rabbitmq/amqp091-go"
+	wbrabbit "github.com/wb-go/wbf/rabbitmq"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
-// PublishMsg publishes a booking message to the delay exchange.
+// PublishMsg schedules expiry of a booking by publishing it to the delay queue.
+//
+// RabbitMQ only expires messages at the head of a queue, so a long hold would delay
+// shorter ones queued behind it. To keep any TTL accurate with a single queue, a
+// message waits at most delayStep; the consumer re-publishes it until the booking is due.
 func (b *Broker) PublishMsg(ctx context.Context, bk *booking.Booking) error {
 	msg, err := json.Marshal(bk)
 	if err != nil {
@@ -19,24 +25,43 @@ func (b *Broker) PublishMsg(ctx context.Context, bk *booking.Booking) error {
 		return err
 	}
 
-	ttlMinutes := 1
-	if !bk.CreatedAt.IsZero() && !bk.ExpiredAt.IsZero() {
-		diff := bk.ExpiredAt.Sub(bk.CreatedAt)
-		if diff > 0 {
-			ttlMinutes = int(diff.Round(time.Minute).Minutes())
-			if ttlMinutes <= 0 {
-				ttlMinutes = 1
-			}
-		}
-	}
-
-	routingKey := fmt.Sprintf("delay_%d", ttlMinutes)
+	delay := nextDelay(bk.ExpiredAt, time.Now(), b.delayStep)
 
-	if err = b.publisher.Publish(ctx, msg, routingKey); err != nil {
+	if err = b.publisher.Publish(ctx, msg, delayRoutingKey, withExpiration(delay)); err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to publish booking message")
 		return err
 	}
 
-	wbzlog.Logger.Info().Msgf("published booking message (routing=%s): %s", routingKey, string(msg))
+	wbzlog.Logger.Info().Msgf("published booking message (delay=%s): %s", delay, string(msg))
 	return nil
 }
+
+// nextDelay returns how long a booking message should wait before the next check:
+// the time left until expiry, capped by step and never negative.
+func nextDelay(expiresAt, now time.Time, step time.Duration) time.Duration {
+	delay := expiresAt.Sub(now)
+	if delay < 0 {
+		return 0
+	}
+	if delay > step {
+		return step
+	}
+	return delay
+}
+
+// withExpiration sets the per-message TTL. RabbitMQ expects whole milliseconds,
+// and "0" dead-letters the message at once.
+func withExpiration(d time.Duration) wbrabbit.PublishOption {
+	return func(p *amqp091.Publishing) {
+		p.Expiration = strconv.FormatInt(d.Milliseconds(), 10)
+	}
+}
+
+// PublishOutbox is the outbox handler of booking expiry messages.
+func (b *Broker) PublishOutbox(ctx context.Context, payload []byte) error {
+	var bk booking.Booking
+	if err := json.Unmarshal(payload, &bk); err != nil {
+		return err
+	}
+	return b.PublishMsg(ctx, &bk)
+}
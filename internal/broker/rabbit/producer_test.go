@@ -0,0 +1,49 @@
+package rabbit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestNextDelay(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := time.Minute
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      time.Duration
+	}{
+		{"due within the step", now.Add(10 * time.Second), 10 * time.Second},
+		{"sub-second precision", now.Add(1500 * time.Millisecond), 1500 * time.Millisecond},
+		{"exactly one step", now.Add(time.Minute), time.Minute},
+		{"capped by the step", now.Add(90 * time.Minute), time.Minute},
+		{"due now", now, 0},
+		{"overdue", now.Add(-time.Hour), 0},
+	}
+	for _, tt := range tests {
+		if got := nextDelay(tt.expiresAt, now, step); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWithExpiration(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  string
+	}{
+		{90 * time.Second, "90000"},
+		{1500 * time.Microsecond, "1"},
+		{0, "0"},
+	}
+	for _, tt := range tests {
+		var p amqp091.Publishing
+		withExpiration(tt.delay)(&p)
+		if p.Expiration != tt.want {
+			t.Errorf("%s: expected expiration %q, got %q", tt.delay, tt.want, p.Expiration)
+		}
+	}
+}
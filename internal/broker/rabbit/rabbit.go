@@ -8,6 +8,7 @@ import (
 
 	"eventbooker/internal/config"
 	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/expiry"
 
 	"github.com/rabbitmq/amqp091-go"
 	wbrabbit "github.com/wb-go/wbf/rabbitmq"
@@ -15,31 +16,29 @@ import (
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
+const (
+	delayQueue      = "booking.delay.queue"
+	delayRoutingKey = "delay"
+
+	defaultDelayStep = time.Minute
+)
+
 // Broker wraps a RabbitMQ client with publisher and consumer.
 type Broker struct {
 	client    *wbrabbit.RabbitClient
 	publisher *wbrabbit.Publisher
 	consumer  *wbrabbit.Consumer
+	delayStep time.Duration
 }
 
 // StorageProvider defines the repository methods needed by the consumer.
 type StorageProvider interface {
-	CancelBooking(ctx context.Context, bookingID, eventID string) error
+	ExpireBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error)
 	GetBookingStatus(ctx context.Context, id string) (booking.Status, error)
 }
 
-// TelegramProvider defines the interface for sending Telegram notifications.
-type TelegramProvider interface {
-	Send(tg, eventName string, persons int) error
-}
-
-// EmailProvider defines the interface for sending email notifications.
-type EmailProvider interface {
-	Send(email, eventName string, persons int) error
-}
-
 // NewBroker creates a new RabbitMQ Broker.
-func NewBroker(cfg *config.AppConfig, repo StorageProvider, email EmailProvider, tg TelegramProvider) (*Broker, error) {
+func NewBroker(cfg *config.AppConfig, repo StorageProvider, notifier expiry.Notifier) (*Broker, error) {
 	rabbitDSN := fmt.Sprintf(
 		"amqp://%s:%s@%s:%d/",
 		cfg.RabbitMQ.User,
@@ -71,32 +70,40 @@ func NewBroker(cfg *config.AppConfig, repo StorageProvider, email EmailProvider,
 		return nil, err
 	}
 
-	if err = declareInfrastructure(client, cfg); err != nil {
+	if err = declareInfrastructure(client); err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("bad rabbitmq declareInfrastructure")
 		return nil, err
 	}
 
-	publisher := wbrabbit.NewPublisher(client, "booking.delay.exchange", "application/json")
-	consumer := wbrabbit.NewConsumer(client, wbrabbit.ConsumerConfig{
+	b := &Broker{
+		client:    client,
+		publisher: wbrabbit.NewPublisher(client, "booking.delay.exchange", "application/json"),
+		delayStep: cfg.RabbitMQ.DelayStep,
+	}
+	if b.delayStep <= 0 {
+		b.delayStep = defaultDelayStep
+	}
+	b.consumer = wbrabbit.NewConsumer(client, wbrabbit.ConsumerConfig{
 		Queue:         "expired.queue",
 		ConsumerTag:   "booking-expired-worker",
 		AutoAck:       false,
 		PrefetchCount: 10,
 		Workers:       5,
-	}, bookingExpiredHandler(repo, email, tg))
+	}, bookingExpiredHandler(repo, b, notifier))
 
+	return b, nil
+}
+
+// Start consumes expired booking messages in the background.
+func (b *Broker) Start(ctx context.Context) error {
 	go func() {
-		if err := consumer.Start(context.Background()); err != nil {
+		if err := b.consumer.Start(ctx); err != nil {
 			wbzlog.Logger.Error().Err(err).Msg("failed to start RabbitMQ consumer")
 			os.Exit(1)
 		}
 	}()
 
-	return &Broker{
-		client:    client,
-		publisher: publisher,
-		consumer:  consumer,
-	}, nil
+	return nil
 }
 
 // Close closes the RabbitMQ connection.
@@ -104,7 +111,9 @@ func (b *Broker) Close() error {
 	return b.client.Close()
 }
 
-func declareInfrastructure(client *wbrabbit.RabbitClient, cfg *config.AppConfig) error {
+// declareInfrastructure declares a single delay queue without a queue-level TTL.
+// Every message carries its own expiration and is dead-lettered into expired.queue.
+func declareInfrastructure(client *wbrabbit.RabbitClient) error {
 	if err := client.DeclareExchange("booking.dlx.exchange", "direct", true, false, false, nil); err != nil {
 		return err
 	}
@@ -117,22 +126,10 @@ func declareInfrastructure(client *wbrabbit.RabbitClient, cfg *config.AppConfig)
 		return err
 	}
 
-	for ttlMinutes := range cfg.Event.SupportedTTLs {
-		ttlMs := ttlMinutes * 60 * 1000
-
-		queueName := fmt.Sprintf("delay_%d.queue", ttlMinutes)
-		routingKey := fmt.Sprintf("delay_%d", ttlMinutes)
-
-		args := amqp091.Table{
-			"x-message-ttl":             ttlMs,
-			"x-dead-letter-exchange":    "booking.dlx.exchange",
-			"x-dead-letter-routing-key": "booking.expired",
-		}
-
-		if err := client.DeclareQueue(queueName, "booking.delay.exchange", routingKey, true, false, true, args); err != nil {
-			return err
-		}
+	args := amqp091.Table{
+		"x-dead-letter-exchange":    "booking.dlx.exchange",
+		"x-dead-letter-routing-key": "booking.expired",
 	}
 
-	return nil
+	return client.DeclareQueue(delayQueue, "booking.delay.exchange", delayRoutingKey, true, false, true, args)
 }
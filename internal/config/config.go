@@ -10,18 +10,27 @@ import (
 
 // AppConfig is the root configuration for the application.
 type AppConfig struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Logger   LoggerConfig   `mapstructure:"logger"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
-	DB       DBConfig       `mapstructure:"db_config"`
-	Telegram TelegramConfig `mapstructure:"telegram"`
-	Mail     MailConfig     `mapstructure:"mail"`
-	Retry    RetryConfig    `mapstructure:"retry_strategy"`
-	Gin      GinConfig      `mapstructure:"gin"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	User     UserConfig     `mapstructure:"username_config"`
-	Password PasswordConfig `mapstructure:"password_config"`
-	Event    EventConfig    `mapstructure:"event_config"`
+	Server            ServerConfig            `mapstructure:"server"`
+	Logger            LoggerConfig            `mapstructure:"logger"`
+	RabbitMQ          RabbitMQConfig          `mapstructure:"rabbitmq"`
+	DB                DBConfig                `mapstructure:"db_config"`
+	Telegram          TelegramConfig          `mapstructure:"telegram"`
+	Mail              MailConfig              `mapstructure:"mail"`
+	Retry             RetryConfig             `mapstructure:"retry_strategy"`
+	Gin               GinConfig               `mapstructure:"gin"`
+	JWT               JWTConfig               `mapstructure:"jwt"`
+	PasswordReset     PasswordResetConfig     `mapstructure:"password_reset"`
+	EmailVerification EmailVerificationConfig `mapstructure:"email_verification"`
+	User              UserConfig              `mapstructure:"username_config"`
+	Password          PasswordConfig          `mapstructure:"password_config"`
+	Event             EventConfig             `mapstructure:"event_config"`
+	Booking           BookingConfig           `mapstructure:"booking_config"`
+	Expiry            ExpiryConfig            `mapstructure:"expiry"`
+	Outbox            OutboxConfig            `mapstructure:"outbox"`
+	Notification      NotificationConfig      `mapstructure:"notification"`
+	Webhook           WebhookConfig           `mapstructure:"webhook"`
+	Idempotency       IdempotencyConfig       `mapstructure:"idempotency"`
+	Admin             AdminConfig             `mapstructure:"admin"`
 }
 
 type RetryConfig struct {
@@ -48,11 +57,12 @@ type RabbitMQConfig struct {
 	Port              int    `mapstructure:"port" default:"5672"`
 	User              string
 	Password          string
-	ConnectionTimeout int    `mapstructure:"connection_timeout"`
-	Heartbeat         int    `mapstructure:"heartbeat"`
-	ConnectionName    string `mapstructure:"connection_name"`
-	Exchange          string `mapstructure:"exchange"`
-	QueueName         string `mapstructure:"queue_name"`
+	ConnectionTimeout int           `mapstructure:"connection_timeout"`
+	Heartbeat         int           `mapstructure:"heartbeat"`
+	ConnectionName    string        `mapstructure:"connection_name"`
+	Exchange          string        `mapstructure:"exchange"`
+	QueueName         string        `mapstructure:"queue_name"`
+	DelayStep         time.Duration `mapstructure:"delay_step" default:"1m"`
 }
 
 type PostgresConfig struct {
@@ -75,6 +85,9 @@ type DBConfig struct {
 
 type TelegramConfig struct {
 	BotToken string
+	// BotUsername is used in account linking deep links, taken from the bot when empty.
+	BotUsername string        `mapstructure:"bot_username"`
+	LinkTTL     time.Duration `mapstructure:"link_ttl"`
 }
 
 type MailConfig struct {
@@ -82,6 +95,8 @@ type MailConfig struct {
 	SMTPPort     int    `mapstructure:"smtp_port" default:"587"`
 	SMTPEmail    string `mapstructure:"smtp_user" default:""`
 	SMTPPassword string `mapstructure:"smtp_password" default:""`
+	From         string `mapstructure:"from" default:""`
+	FromName     string `mapstructure:"from_name" default:"EventBooker"`
 }
 
 type JWTConfig struct {
@@ -91,6 +106,48 @@ type JWTConfig struct {
 	RefreshSecret   string
 }
 
+type PasswordResetConfig struct {
+	TTL time.Duration `mapstructure:"ttl" default:"30m"`
+	// Cooldown is how long after an email another request for the user is ignored.
+	Cooldown time.Duration `mapstructure:"cooldown" default:"5m"`
+	// URL is the page that completes a reset, the token is appended as ?token=.
+	URL string `mapstructure:"url" default:""`
+}
+
+// Email verification policies select what an unverified email address blocks.
+const (
+	EmailPolicyOff           = "off"
+	EmailPolicyNotifications = "notifications"
+	EmailPolicyBookings      = "bookings"
+)
+
+type EmailVerificationConfig struct {
+	TTL time.Duration `mapstructure:"ttl" default:"24h"`
+	// URL is the link emailed to the user, the token is appended as ?token=.
+	URL    string `mapstructure:"url" default:"http://localhost:8080/api/auth/verify"`
+	Policy string `mapstructure:"policy" default:"notifications"`
+}
+
+// Validate rejects an unknown policy, so a typo does not silently turn verification off.
+func (c EmailVerificationConfig) Validate() error {
+	switch c.Policy {
+	case EmailPolicyOff, EmailPolicyNotifications, EmailPolicyBookings:
+		return nil
+	}
+	return fmt.Errorf("email_verification.policy must be %q, %q or %q, got %q",
+		EmailPolicyOff, EmailPolicyNotifications, EmailPolicyBookings, c.Policy)
+}
+
+// BlocksNotifications reports whether email notifications skip unverified addresses.
+func (c EmailVerificationConfig) BlocksNotifications() bool {
+	return c.Policy == EmailPolicyNotifications || c.Policy == EmailPolicyBookings
+}
+
+// BlocksBookings reports whether users must verify their email before booking.
+func (c EmailVerificationConfig) BlocksBookings() bool {
+	return c.Policy == EmailPolicyBookings
+}
+
 type UserConfig struct {
 	MinLength         int    `mapstructure:"min_length"`
 	MaxLength         int    `mapstructure:"max_length"`
@@ -107,12 +164,86 @@ type PasswordConfig struct {
 }
 
 type EventConfig struct {
-	NameMinLength        int          `mapstructure:"name_min_length"`
-	NameMaxLength        int          `mapstructure:"name_max_length"`
-	DescriptionMaxLength int          `mapstructure:"desctiption_max_length"`
-	DescriptionRequired  bool         `mapstructure:"description_require"`
-	TTL                  []int        `mapstructure:"booking_ttl"`
-	SupportedTTLs        map[int]bool `mapstructure:"-"`
+	NameMinLength        int  `mapstructure:"name_min_length"`
+	NameMaxLength        int  `mapstructure:"name_max_length"`
+	DescriptionMaxLength int  `mapstructure:"desctiption_max_length"`
+	DescriptionRequired  bool `mapstructure:"description_require"`
+	ListDefaultLimit     int  `mapstructure:"list_default_limit" default:"20"`
+	ListMaxLimit         int  `mapstructure:"list_max_limit" default:"100"`
+}
+
+type BookingConfig struct {
+	CancelCutoff time.Duration `mapstructure:"cancel_cutoff" default:"1h"`
+}
+
+// Expiry modes select which expirers release unconfirmed bookings.
+const (
+	ExpiryModeBroker  = "broker"
+	ExpiryModeSweeper = "sweeper"
+	ExpiryModeBoth    = "both"
+)
+
+type ExpiryConfig struct {
+	Mode          string        `mapstructure:"mode" default:"broker"`
+	SweepInterval time.Duration `mapstructure:"sweep_interval" default:"30s"`
+	SweepBatch    int           `mapstructure:"sweep_batch" default:"100"`
+}
+
+// UseBroker reports whether bookings are expired through RabbitMQ.
+func (c ExpiryConfig) UseBroker() bool {
+	return c.Mode != ExpiryModeSweeper
+}
+
+// UseSweeper reports whether the Postgres sweeper runs.
+func (c ExpiryConfig) UseSweeper() bool {
+	return c.Mode == ExpiryModeSweeper || c.Mode == ExpiryModeBoth
+}
+
+type OutboxConfig struct {
+	Interval      time.Duration `mapstructure:"interval" default:"1s"`
+	Batch         int           `mapstructure:"batch" default:"100"`
+	Lease         time.Duration `mapstructure:"lease" default:"30s"`
+	RetryDelay    time.Duration `mapstructure:"retry_delay" default:"1s"`
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay" default:"5m"`
+	// Retention is how long sent messages are kept before they are deleted.
+	Retention time.Duration `mapstructure:"retention" default:"168h"`
+}
+
+type NotificationConfig struct {
+	Interval      time.Duration `mapstructure:"interval" default:"1m"`
+	Batch         int           `mapstructure:"batch" default:"100"`
+	ExpiryWarning time.Duration `mapstructure:"expiry_warning" default:"10m"`
+	EventReminder time.Duration `mapstructure:"event_reminder" default:"24h"`
+	TemplatesDir  string        `mapstructure:"templates_dir"`
+	DefaultLocale string        `mapstructure:"default_locale" default:"en"`
+	RetryInterval time.Duration `mapstructure:"retry_interval" default:"30s"`
+	RetryDelay    time.Duration `mapstructure:"retry_delay" default:"1m"`
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay" default:"1h"`
+	MaxAttempts   int           `mapstructure:"max_attempts" default:"8"`
+	SendTimeout   time.Duration `mapstructure:"send_timeout" default:"10s"`
+	// Retention is how long sent and abandoned deliveries are kept before they are deleted.
+	Retention time.Duration `mapstructure:"retention" default:"168h"`
+}
+
+type WebhookConfig struct {
+	Interval      time.Duration `mapstructure:"interval" default:"5s"`
+	Batch         int           `mapstructure:"batch" default:"50"`
+	Timeout       time.Duration `mapstructure:"timeout" default:"10s"`
+	RetryDelay    time.Duration `mapstructure:"retry_delay" default:"30s"`
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay" default:"6h"`
+	MaxAttempts   int           `mapstructure:"max_attempts" default:"10"`
+	AllowPrivate  bool          `mapstructure:"allow_private" default:"false"`
+	// Retention is how long delivered and abandoned deliveries are kept before they are deleted.
+	Retention time.Duration `mapstructure:"retention" default:"168h"`
+}
+
+type IdempotencyConfig struct {
+	TTL   time.Duration `mapstructure:"ttl" default:"24h"`
+	Lease time.Duration `mapstructure:"lease" default:"1m"`
+}
+
+type AdminConfig struct {
+	UserIDs []string `mapstructure:"user_ids"`
 }
 
 // MustLoad loads configuration from files and environment variables.
@@ -133,6 +264,9 @@ func MustLoad() *AppConfig {
 	if err := cfg.Unmarshal(&appCfg); err != nil {
 		panic(fmt.Sprintf("failed to unmarshal config: %v", err))
 	}
+	if err := appCfg.EmailVerification.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid config: %v", err))
+	}
 
 	appCfg.DB.Master.DBName = os.Getenv("POSTGRES_DB")
 	appCfg.DB.Master.User = os.Getenv("POSTGRES_USER")
@@ -149,15 +283,5 @@ func MustLoad() *AppConfig {
 	appCfg.JWT.AccessSecret = os.Getenv("JWT_ACCESS_SECRET")
 	appCfg.JWT.RefreshSecret = os.Getenv("JWT_REFRESH_SECRET")
 
-	appCfg.Event.SupportedTTLs = buildSupportedTTLs(appCfg.Event.TTL)
-
 	return &appCfg
 }
-
-func buildSupportedTTLs(formats []int) map[int]bool {
-	m := make(map[int]bool, len(formats))
-	for _, f := range formats {
-		m[f] = true
-	}
-	return m
-}
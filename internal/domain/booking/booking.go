@@ -1,12 +1,23 @@
 package booking
 
 import (
-	"errors"
 	"time"
 
+	"eventbooker/internal/apperr"
+
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNotFound           = apperr.NotFound("booking_not_found", "booking not found")
+	ErrNotOwner           = apperr.Forbidden("booking_forbidden", "booking belongs to another user")
+	ErrAlreadyCancelled   = apperr.Conflict("booking_already_cancelled", "booking already cancelled")
+	ErrAlreadyConfirmed   = apperr.Conflict("booking_already_confirmed", "booking already confirmed")
+	ErrExpired            = apperr.Conflict("booking_expired", "booking has expired")
+	ErrCancellationClosed = apperr.Conflict("booking_cancellation_closed", "booking can no longer be cancelled")
+	ErrInvalidCount       = apperr.Validation("invalid_count", "count should be bigger than 0")
+)
+
 // Status represents the state of a booking.
 type Status string
 
@@ -16,12 +27,24 @@ const (
 	StatusConfirmed Status = "confirmed"
 )
 
+// Valid reports whether s is a known booking status.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusCreated, StatusCancelled, StatusConfirmed:
+		return true
+	}
+	return false
+}
+
 // Booking is the domain model for a reservation.
 type Booking struct {
 	ID                   uuid.UUID `json:"id"`
 	EventID              uuid.UUID `json:"event_id"`
 	UserID               uuid.UUID `json:"user_id"`
 	EventName            string    `json:"event_name"`
+	EventDate            time.Time `json:"event_date"`
+	TicketTypeID         uuid.UUID `json:"ticket_type_id"`
+	TicketTypeName       string    `json:"ticket_type_name"`
 	Count                int       `json:"count"`
 	Price                float64   `json:"price"`
 	Status               Status    `json:"status"`
@@ -31,22 +54,25 @@ type Booking struct {
 	TelegramRecepient    string    `json:"telegram_recepient"`
 	EmailNotification    bool      `json:"email_notification"`
 	EmailRecepient       string    `json:"email_recepient"`
+	Locale               string    `json:"locale"`
+	// EmailVerified is set when EmailRecepient is the verified address of the owner.
+	EmailVerified bool `json:"email_verified"`
 }
 
-// New creates a new Booking with validation.
-func New(eventID, userID, telegramRecepient, emailRecepient, eventName string, telegramNotification, emailNotification bool, count int, expiredAtMinutes int, price float64) (*Booking, error) {
+// New creates a new Booking with validation. The booking expires ttl after now.
+func New(eventID, userID, telegramRecepient, emailRecepient, eventName string, telegramNotification, emailNotification bool, count int, ttl time.Duration, price float64) (*Booking, error) {
 	eID, err := uuid.Parse(eventID)
 	if err != nil {
-		return nil, err
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid event id", err)
 	}
 
 	uID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, err
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid user id", err)
 	}
 
 	if count <= 0 {
-		return nil, errors.New("count should be bigger than 0")
+		return nil, ErrInvalidCount
 	}
 
 	return &Booking{
@@ -56,7 +82,7 @@ func New(eventID, userID, telegramRecepient, emailRecepient, eventName string, t
 		Count:                count,
 		Price:                price * float64(count),
 		CreatedAt:            time.Now(),
-		ExpiredAt:            time.Now().Add(time.Minute * time.Duration(expiredAtMinutes)),
+		ExpiredAt:            time.Now().Add(ttl),
 		Status:               StatusCreated,
 		TelegramNotification: telegramNotification,
 		EmailNotification:    emailNotification,
@@ -70,3 +96,36 @@ func New(eventID, userID, telegramRecepient, emailRecepient, eventName string, t
 func (b *Booking) Confirm() {
 	b.Status = StatusConfirmed
 }
+
+// ConfirmBy confirms a pending, unexpired booking on behalf of its owner.
+func (b *Booking) ConfirmBy(userID uuid.UUID) error {
+	if b.UserID != userID {
+		return ErrNotOwner
+	}
+
+	switch b.Status {
+	case StatusCancelled:
+		return ErrAlreadyCancelled
+	case StatusConfirmed:
+		return ErrAlreadyConfirmed
+	}
+
+	if !b.ExpiredAt.IsZero() && time.Now().After(b.ExpiredAt) {
+		return ErrExpired
+	}
+
+	b.Confirm()
+	return nil
+}
+
+// Cancel sets the booking status to cancelled if the event is far enough away.
+func (b *Booking) Cancel(cutoff time.Duration) error {
+	if b.Status == StatusCancelled {
+		return ErrAlreadyCancelled
+	}
+	if !b.EventDate.IsZero() && time.Now().Add(cutoff).After(b.EventDate) {
+		return ErrCancellationClosed
+	}
+	b.Status = StatusCancelled
+	return nil
+}
@@ -17,7 +17,7 @@ func TestNew_Success(t *testing.T) {
 		"12345", "mail@mail.com",
 		"Test Event",
 		true, true,
-		2, 30, 100.50,
+		2, 30*time.Minute, 100.50,
 	)
 
 	if err != nil {
@@ -59,7 +59,7 @@ func TestNew_Success(t *testing.T) {
 
 func TestNew_InvalidEventID(t *testing.T) {
 	userID := uuid.New().String()
-	_, err := booking.New("invalid-uuid", userID, "12345", "mail@mail.com", "Event", false, false, 1, 10, 10)
+	_, err := booking.New("invalid-uuid", userID, "12345", "mail@mail.com", "Event", false, false, 1, 10*time.Minute, 10)
 	if err == nil {
 		t.Fatal("expected error for invalid eventId")
 	}
@@ -67,7 +67,7 @@ func TestNew_InvalidEventID(t *testing.T) {
 
 func TestNew_InvalidUserID(t *testing.T) {
 	eventID := uuid.New().String()
-	_, err := booking.New(eventID, "invalid-user-id", "12345", "mail@mail.com", "Event", false, false, 1, 10, 10)
+	_, err := booking.New(eventID, "invalid-user-id", "12345", "mail@mail.com", "Event", false, false, 1, 10*time.Minute, 10)
 	if err == nil {
 		t.Fatal("expected error for invalid userId")
 	}
@@ -76,18 +76,97 @@ func TestNew_InvalidUserID(t *testing.T) {
 func TestNew_InvalidCount(t *testing.T) {
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	_, err := booking.New(eventID, userID, "12345", "mail@mail.com", "Event", false, false, 0, 10, 10)
+	_, err := booking.New(eventID, userID, "12345", "mail@mail.com", "Event", false, false, 0, 10*time.Minute, 10)
 	if err == nil {
 		t.Fatal("expected error for count <= 0")
 	}
 }
 
+func TestNew_SecondsTTL(t *testing.T) {
+	b, err := booking.New(uuid.New().String(), uuid.New().String(), "", "", "Event", false, false, 1, 90*time.Second, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := b.ExpiredAt.Sub(b.CreatedAt); diff < 89*time.Second || diff > 91*time.Second {
+		t.Errorf("expected the booking to expire in 90s, got %v", diff)
+	}
+}
+
 func TestBooking_Confirm(t *testing.T) {
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	b, _ := booking.New(eventID, userID, "12345", "mail@mail.com", "Event", false, false, 1, 10, 10)
+	b, _ := booking.New(eventID, userID, "12345", "mail@mail.com", "Event", false, false, 1, 10*time.Minute, 10)
 	b.Confirm()
 	if b.Status != booking.StatusConfirmed {
 		t.Fatal("status should be confirmed")
 	}
 }
+
+func TestStatus_Valid(t *testing.T) {
+	for _, s := range []booking.Status{booking.StatusCreated, booking.StatusConfirmed, booking.StatusCancelled} {
+		if !s.Valid() {
+			t.Errorf("status %q should be valid", s)
+		}
+	}
+	if booking.Status("cancelled").Valid() {
+		t.Error("unknown status should be invalid")
+	}
+}
+
+func TestBooking_Cancel(t *testing.T) {
+	b := &booking.Booking{Status: booking.StatusConfirmed, EventDate: time.Now().Add(48 * time.Hour)}
+	if err := b.Cancel(time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Status != booking.StatusCancelled {
+		t.Fatal("status should be cancelled")
+	}
+	if err := b.Cancel(time.Hour); err != booking.ErrAlreadyCancelled {
+		t.Fatalf("expected ErrAlreadyCancelled, got %v", err)
+	}
+}
+
+func TestBooking_Cancel_AfterCutoff(t *testing.T) {
+	b := &booking.Booking{Status: booking.StatusCreated, EventDate: time.Now().Add(30 * time.Minute)}
+	if err := b.Cancel(time.Hour); err != booking.ErrCancellationClosed {
+		t.Fatalf("expected ErrCancellationClosed, got %v", err)
+	}
+	if b.Status != booking.StatusCreated {
+		t.Fatal("status must not change")
+	}
+}
+
+func TestBooking_ConfirmBy(t *testing.T) {
+	owner := uuid.New()
+	pending := func() *booking.Booking {
+		return &booking.Booking{UserID: owner, Status: booking.StatusCreated, ExpiredAt: time.Now().Add(time.Minute)}
+	}
+
+	b := pending()
+	if err := b.ConfirmBy(owner); err != nil || b.Status != booking.StatusConfirmed {
+		t.Fatalf("expected confirmed booking, got status %s, err %v", b.Status, err)
+	}
+
+	cases := []struct {
+		name    string
+		mutate  func(b *booking.Booking)
+		user    uuid.UUID
+		wantErr error
+	}{
+		{"not owner", func(b *booking.Booking) {}, uuid.New(), booking.ErrNotOwner},
+		{"cancelled", func(b *booking.Booking) { b.Status = booking.StatusCancelled }, owner, booking.ErrAlreadyCancelled},
+		{"confirmed", func(b *booking.Booking) { b.Status = booking.StatusConfirmed }, owner, booking.ErrAlreadyConfirmed},
+		{"expired", func(b *booking.Booking) { b.ExpiredAt = time.Now().Add(-time.Second) }, owner, booking.ErrExpired},
+	}
+	for _, tc := range cases {
+		b := pending()
+		tc.mutate(b)
+		status := b.Status
+		if err := b.ConfirmBy(tc.user); err != tc.wantErr {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if b.Status != status {
+			t.Errorf("%s: status must not change", tc.name)
+		}
+	}
+}
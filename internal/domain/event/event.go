@@ -1,53 +1,237 @@
 package event
 
 import (
-	"errors"
 	"time"
 
+	"eventbooker/internal/apperr"
 	"eventbooker/internal/domain/booking"
 
 	"github.com/google/uuid"
 )
 
+// Status represents the state of an event.
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusCancelled Status = "canceled"
+)
+
+var (
+	ErrNotFound            = apperr.NotFound("event_not_found", "event not found")
+	ErrNotCreator          = apperr.Forbidden("event_forbidden", "only the event creator can modify the event")
+	ErrCancelled           = apperr.Conflict("event_cancelled", "event is cancelled")
+	ErrCapacityBelowBooked = apperr.Conflict("capacity_below_booked", "count of people cannot be less than already booked seats")
+	ErrSoldOut             = apperr.SoldOut("sold_out", "not enough available seats for the event")
+	ErrInvalidCapacity     = apperr.Validation("invalid_capacity", "count of people should be bigger than 0")
+	ErrInvalidPrice        = apperr.Validation("invalid_price", "price must be bigger or equal 0")
+	ErrInvalidBookingTTL   = apperr.Validation("invalid_booking_ttl", "booking TTL must be bigger than 0 for paid events")
+)
+
 // Event is the domain model for an event.
 type Event struct {
 	ID             uuid.UUID
 	CreatorID      uuid.UUID
+	Status         Status
 	Date           time.Time
 	Name           string
 	Description    string
 	MaxCountPeople int
 	FreePlaces     int
 	Price          float64
-	BookingTTL     int
+	BookingTTL     time.Duration
+	TicketTypes    []*TicketType
+	Limits         Limits
 	Bookings       []*booking.Booking
 }
 
-// New creates a new Event with validation.
-func New(creator, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*Event, error) {
+// New creates a new Event with a single default ticket type.
+func New(creator, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64) (*Event, error) {
+	return NewWithTicketTypes(creator, name, description, date, []TicketType{{
+		Name:           DefaultTicketTypeName,
+		Price:          price,
+		MaxCountPeople: maxCountPeople,
+		BookingTTL:     bookingTTL,
+	}})
+}
+
+// NewWithTicketTypes creates a new Event with the given tiers. Each tier starts fully available.
+func NewWithTicketTypes(creator, name, description string, date time.Time, types []TicketType) (*Event, error) {
 	creatorUID, err := uuid.Parse(creator)
 	if err != nil {
-		return nil, err
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid creator id", err)
 	}
 
-	if maxCountPeople <= 0 {
-		return nil, errors.New("count of people should be bigger than 0")
+	if len(types) == 0 {
+		return nil, ErrInvalidTicketType
 	}
 
-	if price < 0 {
-		return nil, errors.New("price must be bigger or equal 0")
+	e := &Event{
+		ID:          uuid.New(),
+		CreatorID:   creatorUID,
+		Status:      StatusActive,
+		Date:        date,
+		Name:        name,
+		Description: description,
+		TicketTypes: make([]*TicketType, 0, len(types)),
+		Bookings:    []*booking.Booking{},
 	}
 
-	return &Event{
-		ID:             uuid.New(),
-		CreatorID:      creatorUID,
-		Date:           date,
-		Name:           name,
-		Description:    description,
-		MaxCountPeople: maxCountPeople,
-		FreePlaces:     maxCountPeople,
-		BookingTTL:     bookingTTL,
-		Price:          price,
-		Bookings:       []*booking.Booking{},
-	}, nil
+	names := make(map[string]bool, len(types))
+	for _, t := range types {
+		t.ID, t.EventID, t.FreePlaces = uuid.New(), e.ID, t.MaxCountPeople
+		if err = t.validate(); err != nil {
+			return nil, err
+		}
+		if names[t.Name] {
+			return nil, ErrInvalidTicketType
+		}
+		names[t.Name] = true
+		e.TicketTypes = append(e.TicketTypes, &t)
+	}
+	e.syncTotals()
+
+	return e, nil
+}
+
+// Update holds the editable event fields. Nil fields are left unchanged.
+type Update struct {
+	Name           *string
+	Description    *string
+	Date           *time.Time
+	BookingTTL     *time.Duration
+	MaxCountPeople *int
+	Price          *float64
+
+	MaxSeatsPerBooking *int
+	MaxBookingsPerUser *int
+	MaxSeatsPerUser    *int
+}
+
+// ApplyBy applies u on behalf of userID, keeping already booked seats intact.
+// Price, capacity and TTL can only be changed at event level for single-tier events.
+func (e *Event) ApplyBy(userID uuid.UUID, u Update) error {
+	if e.CreatorID != userID {
+		return ErrNotCreator
+	}
+	if e.Status == StatusCancelled {
+		return ErrCancelled
+	}
+	if len(e.TicketTypes) > 1 && (u.MaxCountPeople != nil || u.Price != nil || u.BookingTTL != nil) {
+		return ErrAmbiguousTierUpdate
+	}
+
+	maxCountPeople, freePlaces := e.MaxCountPeople, e.FreePlaces
+	if u.MaxCountPeople != nil {
+		if *u.MaxCountPeople <= 0 {
+			return ErrInvalidCapacity
+		}
+		booked := e.MaxCountPeople - e.FreePlaces
+		if *u.MaxCountPeople < booked {
+			return ErrCapacityBelowBooked
+		}
+		maxCountPeople, freePlaces = *u.MaxCountPeople, *u.MaxCountPeople-booked
+	}
+
+	price := e.Price
+	if u.Price != nil {
+		if *u.Price < 0 {
+			return ErrInvalidPrice
+		}
+		price = *u.Price
+	}
+
+	bookingTTL := e.BookingTTL
+	if u.BookingTTL != nil {
+		bookingTTL = *u.BookingTTL
+	}
+	if price == 0 {
+		bookingTTL = 0
+	} else if bookingTTL <= 0 {
+		return ErrInvalidBookingTTL
+	}
+
+	limits := e.Limits
+	if u.MaxSeatsPerBooking != nil {
+		limits.MaxSeatsPerBooking = *u.MaxSeatsPerBooking
+	}
+	if u.MaxBookingsPerUser != nil {
+		limits.MaxBookingsPerUser = *u.MaxBookingsPerUser
+	}
+	if u.MaxSeatsPerUser != nil {
+		limits.MaxSeatsPerUser = *u.MaxSeatsPerUser
+	}
+	if err := limits.validate(); err != nil {
+		return err
+	}
+
+	if u.Name != nil {
+		e.Name = *u.Name
+	}
+	if u.Description != nil {
+		e.Description = *u.Description
+	}
+	if u.Date != nil {
+		e.Date = *u.Date
+	}
+	e.MaxCountPeople, e.FreePlaces = maxCountPeople, freePlaces
+	e.Price, e.BookingTTL = price, bookingTTL
+	e.Limits = limits
+	if len(e.TicketTypes) == 1 {
+		t := e.TicketTypes[0]
+		t.MaxCountPeople, t.FreePlaces = maxCountPeople, freePlaces
+		t.Price, t.BookingTTL = price, bookingTTL
+	}
+
+	return nil
+}
+
+// CancelBy marks the event cancelled on behalf of userID.
+func (e *Event) CancelBy(userID uuid.UUID) error {
+	if e.CreatorID != userID {
+		return ErrNotCreator
+	}
+	if e.Status == StatusCancelled {
+		return ErrCancelled
+	}
+	e.Status = StatusCancelled
+	return nil
+}
+
+// Sort is the ordering applied to the event catalogue.
+type Sort string
+
+const (
+	SortDateAsc   Sort = "date_asc"
+	SortDateDesc  Sort = "date_desc"
+	SortPriceAsc  Sort = "price_asc"
+	SortPriceDesc Sort = "price_desc"
+)
+
+// Valid reports whether s is a known sort order.
+func (s Sort) Valid() bool {
+	switch s {
+	case SortDateAsc, SortDateDesc, SortPriceAsc, SortPriceDesc:
+		return true
+	}
+	return false
+}
+
+// Filter holds the catalogue query parameters. Zero values mean "not set".
+type Filter struct {
+	DateFrom      time.Time
+	DateTo        time.Time
+	PriceMin      *float64
+	PriceMax      *float64
+	OnlyAvailable bool
+	CreatorID     string
+	Sort          Sort
+	Cursor        string
+	Limit         int
+}
+
+// Page is a single page of the event catalogue.
+type Page struct {
+	Events     []*Event
+	NextCursor string
 }
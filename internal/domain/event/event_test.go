@@ -12,7 +12,7 @@ func TestNew_Success(t *testing.T) {
 	creatorID := uuid.New().String()
 	date := time.Now().Add(24 * time.Hour)
 
-	e, err := event.New(creatorID, "Test Event", "Some description", date, 30, 100, 150.75)
+	e, err := event.New(creatorID, "Test Event", "Some description", date, 30*time.Minute, 100, 150.75)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -37,9 +37,12 @@ func TestNew_Success(t *testing.T) {
 	if e.Price != 150.75 {
 		t.Error("wrong price")
 	}
-	if e.BookingTTL != 30 {
+	if e.BookingTTL != 30*time.Minute {
 		t.Error("wrong BookingTTL")
 	}
+	if e.Status != event.StatusActive {
+		t.Error("new event must be active")
+	}
 	if e.Date != date {
 		t.Error("wrong date")
 	}
@@ -50,7 +53,7 @@ func TestNew_Success(t *testing.T) {
 
 func TestNew_InvalidCreatorID(t *testing.T) {
 	date := time.Now().Add(24 * time.Hour)
-	_, err := event.New("invalid-uuid", "Test", "Desc", date, 10, 10, 10)
+	_, err := event.New("invalid-uuid", "Test", "Desc", date, 10*time.Minute, 10, 10)
 	if err == nil {
 		t.Fatal("expected error for invalid Creator ID")
 	}
@@ -59,7 +62,7 @@ func TestNew_InvalidCreatorID(t *testing.T) {
 func TestNew_InvalidMaxCountPeople(t *testing.T) {
 	creator := uuid.New().String()
 	date := time.Now().Add(24 * time.Hour)
-	_, err := event.New(creator, "Test", "Desc", date, 10, 0, 10)
+	_, err := event.New(creator, "Test", "Desc", date, 10*time.Minute, 0, 10)
 	if err == nil {
 		t.Fatal("expected error for MaxCountPeople <= 0")
 	}
@@ -68,8 +71,84 @@ func TestNew_InvalidMaxCountPeople(t *testing.T) {
 func TestNew_InvalidPrice(t *testing.T) {
 	creator := uuid.New().String()
 	date := time.Now().Add(24 * time.Hour)
-	_, err := event.New(creator, "Test", "Desc", date, 10, 10, -1)
+	_, err := event.New(creator, "Test", "Desc", date, 10*time.Minute, 10, -1)
 	if err == nil {
 		t.Fatal("expected error for negative price")
 	}
 }
+
+func newActiveEvent(creator uuid.UUID) *event.Event {
+	return &event.Event{
+		CreatorID: creator, Status: event.StatusActive, Name: "Old",
+		MaxCountPeople: 10, FreePlaces: 4, Price: 100, BookingTTL: 15 * time.Minute,
+	}
+}
+
+func TestApplyBy_Success(t *testing.T) {
+	creator := uuid.New()
+	e := newActiveEvent(creator)
+	name, capacity, price := "New", 8, 50.0
+	if err := e.ApplyBy(creator, event.Update{Name: &name, MaxCountPeople: &capacity, Price: &price}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Name != "New" || e.MaxCountPeople != 8 || e.FreePlaces != 2 || e.Price != 50 {
+		t.Fatalf("update not applied: %+v", e)
+	}
+}
+
+func TestApplyBy_FreeResetsTTL(t *testing.T) {
+	creator := uuid.New()
+	e := newActiveEvent(creator)
+	price := 0.0
+	if err := e.ApplyBy(creator, event.Update{Price: &price}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.BookingTTL != 0 {
+		t.Fatal("BookingTTL must be 0 for free events")
+	}
+}
+
+func TestApplyBy_Errors(t *testing.T) {
+	creator := uuid.New()
+	belowBooked, negative := 5, -1.0
+	var zeroTTL time.Duration
+
+	if err := newActiveEvent(creator).ApplyBy(uuid.New(), event.Update{}); err != event.ErrNotCreator {
+		t.Errorf("expected ErrNotCreator, got %v", err)
+	}
+
+	cancelled := newActiveEvent(creator)
+	cancelled.Status = event.StatusCancelled
+	if err := cancelled.ApplyBy(creator, event.Update{}); err != event.ErrCancelled {
+		t.Errorf("expected ErrCancelled, got %v", err)
+	}
+
+	e := newActiveEvent(creator)
+	if err := e.ApplyBy(creator, event.Update{MaxCountPeople: &belowBooked}); err != event.ErrCapacityBelowBooked {
+		t.Errorf("expected ErrCapacityBelowBooked, got %v", err)
+	}
+	if e.MaxCountPeople != 10 || e.FreePlaces != 4 {
+		t.Error("event must not change on error")
+	}
+
+	if err := newActiveEvent(creator).ApplyBy(creator, event.Update{Price: &negative}); err == nil {
+		t.Error("expected error for negative price")
+	}
+	if err := newActiveEvent(creator).ApplyBy(creator, event.Update{BookingTTL: &zeroTTL}); err == nil {
+		t.Error("expected error for zero TTL on a paid event")
+	}
+}
+
+func TestCancelBy(t *testing.T) {
+	creator := uuid.New()
+	e := newActiveEvent(creator)
+	if err := e.CancelBy(uuid.New()); err != event.ErrNotCreator {
+		t.Fatalf("expected ErrNotCreator, got %v", err)
+	}
+	if err := e.CancelBy(creator); err != nil || e.Status != event.StatusCancelled {
+		t.Fatalf("expected cancelled event, got status %s, err %v", e.Status, err)
+	}
+	if err := e.CancelBy(creator); err != event.ErrCancelled {
+		t.Fatalf("expected ErrCancelled, got %v", err)
+	}
+}
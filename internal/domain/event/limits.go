@@ -0,0 +1,62 @@
+package event
+
+import "eventbooker/internal/apperr"
+
+var (
+	ErrInvalidLimits       = apperr.Validation("invalid_limits", "booking limits must not be negative")
+	ErrBookingTooLarge     = apperr.Validation("booking_too_large", "count exceeds the maximum seats per booking for the event")
+	ErrBookingLimitReached = apperr.Conflict("booking_limit_reached", "user already holds the maximum number of bookings for the event")
+	ErrSeatLimitReached    = apperr.Conflict("seat_limit_reached", "booking would exceed the maximum seats per user for the event")
+)
+
+// Limits restrict how much of an event a single user can hold. Zero means no limit.
+// Only active (created or confirmed) bookings count towards the per-user limits.
+type Limits struct {
+	MaxSeatsPerBooking int
+	MaxBookingsPerUser int
+	MaxSeatsPerUser    int
+}
+
+// PerUser reports whether the limits depend on the bookings a user already holds.
+func (l Limits) PerUser() bool {
+	return l.MaxBookingsPerUser > 0 || l.MaxSeatsPerUser > 0
+}
+
+// CheckCount checks the size of a single booking.
+func (l Limits) CheckCount(count int) error {
+	if l.MaxSeatsPerBooking > 0 && count > l.MaxSeatsPerBooking {
+		return ErrBookingTooLarge
+	}
+	return nil
+}
+
+// Allow checks a booking of count seats by a user who already holds `bookings`
+// active bookings with `seats` seats in total.
+func (l Limits) Allow(count, bookings, seats int) error {
+	if err := l.CheckCount(count); err != nil {
+		return err
+	}
+	if l.MaxBookingsPerUser > 0 && bookings >= l.MaxBookingsPerUser {
+		return ErrBookingLimitReached
+	}
+	if l.MaxSeatsPerUser > 0 && seats+count > l.MaxSeatsPerUser {
+		return ErrSeatLimitReached
+	}
+	return nil
+}
+
+func (l Limits) validate() error {
+	if l.MaxSeatsPerBooking < 0 || l.MaxBookingsPerUser < 0 || l.MaxSeatsPerUser < 0 {
+		return ErrInvalidLimits
+	}
+	return nil
+}
+
+// SetLimits validates and applies booking limits to the event.
+func (e *Event) SetLimits(l Limits) error {
+	if err := l.validate(); err != nil {
+		return err
+	}
+	e.Limits = l
+	return nil
+}
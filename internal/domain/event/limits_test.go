@@ -0,0 +1,68 @@
+package event_test
+
+import (
+	"testing"
+
+	"eventbooker/internal/domain/event"
+
+	"github.com/google/uuid"
+)
+
+func TestLimits_Allow(t *testing.T) {
+	l := event.Limits{MaxSeatsPerBooking: 4, MaxBookingsPerUser: 2, MaxSeatsPerUser: 6}
+
+	cases := []struct {
+		name                   string
+		count, bookings, seats int
+		want                   error
+	}{
+		{"first booking", 4, 0, 0, nil},
+		{"too many seats at once", 5, 0, 0, event.ErrBookingTooLarge},
+		{"second booking", 2, 1, 4, nil},
+		{"third booking", 1, 2, 2, event.ErrBookingLimitReached},
+		{"over the seats of the user", 3, 1, 4, event.ErrSeatLimitReached},
+	}
+	for _, c := range cases {
+		if err := l.Allow(c.count, c.bookings, c.seats); err != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	if err := (event.Limits{}).Allow(1000, 50, 5000); err != nil {
+		t.Errorf("expected zero limits to allow anything, got %v", err)
+	}
+	if (event.Limits{MaxSeatsPerBooking: 2}).PerUser() {
+		t.Error("expected the per-booking limit not to depend on the user")
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	e := newActiveEvent(uuid.New())
+	if err := e.SetLimits(event.Limits{MaxSeatsPerUser: -1}); err != event.ErrInvalidLimits {
+		t.Errorf("expected ErrInvalidLimits, got %v", err)
+	}
+	if err := e.SetLimits(event.Limits{MaxSeatsPerBooking: 2}); err != nil || e.Limits.MaxSeatsPerBooking != 2 {
+		t.Errorf("expected the limits to be set, got %v %+v", err, e.Limits)
+	}
+}
+
+func TestApplyBy_Limits(t *testing.T) {
+	creator := uuid.New()
+	e := newActiveEvent(creator)
+	e.Limits = event.Limits{MaxSeatsPerBooking: 2, MaxBookingsPerUser: 1}
+
+	seats, negative := 4, -1
+	if err := e.ApplyBy(creator, event.Update{MaxSeatsPerUser: &seats}); err != nil {
+		t.Fatal(err)
+	}
+	if e.Limits != (event.Limits{MaxSeatsPerBooking: 2, MaxBookingsPerUser: 1, MaxSeatsPerUser: 4}) {
+		t.Errorf("expected only the given limit to change, got %+v", e.Limits)
+	}
+
+	if err := e.ApplyBy(creator, event.Update{MaxBookingsPerUser: &negative}); err != event.ErrInvalidLimits {
+		t.Errorf("expected ErrInvalidLimits, got %v", err)
+	}
+	if e.Limits.MaxBookingsPerUser != 1 {
+		t.Error("event must not change on error")
+	}
+}
@@ -0,0 +1,93 @@
+package event
+
+import (
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTicketTypeName names the single tier of an event created without explicit ticket types.
+const DefaultTicketTypeName = "standard"
+
+var (
+	ErrTicketTypeNotFound  = apperr.NotFound("ticket_type_not_found", "ticket type not found for this event")
+	ErrTicketTypeRequired  = apperr.Validation("ticket_type_required", "event has several ticket types, ticket_type_id is required")
+	ErrInvalidTicketType   = apperr.Validation("invalid_ticket_type", "ticket type name is required and must be unique within the event")
+	ErrAmbiguousTierUpdate = apperr.Validation("ticket_types_update", "event has several ticket types, price, capacity and TTL cannot be changed at event level")
+)
+
+// TicketType is a tier of seats of an event with its own price, quota and booking TTL.
+type TicketType struct {
+	ID             uuid.UUID
+	EventID        uuid.UUID
+	Name           string
+	Price          float64
+	MaxCountPeople int
+	FreePlaces     int
+	BookingTTL     time.Duration
+}
+
+// validate checks a tier and normalizes the TTL of free tiers to 0.
+func (t *TicketType) validate() error {
+	if t.Name == "" {
+		return ErrInvalidTicketType
+	}
+	if t.MaxCountPeople <= 0 {
+		return ErrInvalidCapacity
+	}
+	if t.Price < 0 {
+		return ErrInvalidPrice
+	}
+	if t.Price == 0 {
+		t.BookingTTL = 0
+	} else if t.BookingTTL <= 0 {
+		return ErrInvalidBookingTTL
+	}
+	return nil
+}
+
+// TicketType returns the tier with the given ID. An empty ID selects the only
+// tier of a single-tier event.
+func (e *Event) TicketType(id string) (*TicketType, error) {
+	if id == "" {
+		if len(e.TicketTypes) == 1 {
+			return e.TicketTypes[0], nil
+		}
+		return nil, ErrTicketTypeRequired
+	}
+
+	tID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid ticket_type_id", err)
+	}
+
+	for _, t := range e.TicketTypes {
+		if t.ID == tID {
+			return t, nil
+		}
+	}
+	return nil, ErrTicketTypeNotFound
+}
+
+// syncTotals recomputes the event-level capacity, free seats, starting price and
+// TTL from its tiers, so catalogue filters and sorting keep working on events.
+func (e *Event) syncTotals() {
+	if len(e.TicketTypes) == 0 {
+		return
+	}
+
+	e.MaxCountPeople, e.FreePlaces, e.BookingTTL = 0, 0, 0
+	e.Price = e.TicketTypes[0].Price
+	for _, t := range e.TicketTypes {
+		e.MaxCountPeople += t.MaxCountPeople
+		e.FreePlaces += t.FreePlaces
+		if t.Price < e.Price {
+			e.Price = t.Price
+		}
+		if t.BookingTTL > e.BookingTTL {
+			e.BookingTTL = t.BookingTTL
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package event_test
+
+import (
+	"testing"
+	"time"
+
+	"eventbooker/internal/domain/event"
+
+	"github.com/google/uuid"
+)
+
+func TestNewWithTicketTypes_Totals(t *testing.T) {
+	e, err := event.NewWithTicketTypes(uuid.New().String(), "Gig", "Desc", time.Now().Add(time.Hour), []event.TicketType{
+		{Name: "vip", Price: 300, MaxCountPeople: 10, BookingTTL: 30 * time.Minute},
+		{Name: "standard", Price: 100, MaxCountPeople: 50, BookingTTL: 15 * time.Minute},
+		{Name: "student", Price: 0, MaxCountPeople: 20, BookingTTL: 15 * time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(e.TicketTypes) != 3 {
+		t.Fatalf("expected 3 ticket types, got %d", len(e.TicketTypes))
+	}
+	if e.MaxCountPeople != 80 || e.FreePlaces != 80 {
+		t.Errorf("wrong totals: capacity %d, free %d", e.MaxCountPeople, e.FreePlaces)
+	}
+	if e.Price != 0 || e.BookingTTL != 30*time.Minute {
+		t.Errorf("wrong summary: price %v, ttl %v", e.Price, e.BookingTTL)
+	}
+	for _, tt := range e.TicketTypes {
+		if tt.EventID != e.ID || tt.ID == uuid.Nil || tt.FreePlaces != tt.MaxCountPeople {
+			t.Errorf("ticket type not initialized: %+v", tt)
+		}
+	}
+	if e.TicketTypes[2].BookingTTL != 0 {
+		t.Error("free tier must have zero TTL")
+	}
+}
+
+func TestNewWithTicketTypes_Errors(t *testing.T) {
+	creator := uuid.New().String()
+	date := time.Now().Add(time.Hour)
+	cases := map[string][]event.TicketType{
+		"empty":         nil,
+		"no name":       {{Price: 10, MaxCountPeople: 1, BookingTTL: 5 * time.Minute}},
+		"duplicate":     {{Name: "a", MaxCountPeople: 1}, {Name: "a", MaxCountPeople: 1}},
+		"zero capacity": {{Name: "a", Price: 10, BookingTTL: 5 * time.Minute}},
+		"negative":      {{Name: "a", Price: -1, MaxCountPeople: 1, BookingTTL: 5 * time.Minute}},
+		"paid no ttl":   {{Name: "a", Price: 10, MaxCountPeople: 1}},
+	}
+	for name, types := range cases {
+		if _, err := event.NewWithTicketTypes(creator, "Gig", "Desc", date, types); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEvent_TicketType(t *testing.T) {
+	single, _ := event.New(uuid.New().String(), "Gig", "Desc", time.Now().Add(time.Hour), 15*time.Minute, 10, 100)
+	tt, err := single.TicketType("")
+	if err != nil || tt.Name != event.DefaultTicketTypeName {
+		t.Fatalf("expected default ticket type, got %+v, %v", tt, err)
+	}
+
+	multi, _ := event.NewWithTicketTypes(uuid.New().String(), "Gig", "Desc", time.Now().Add(time.Hour), []event.TicketType{
+		{Name: "vip", Price: 300, MaxCountPeople: 10, BookingTTL: 30 * time.Minute},
+		{Name: "standard", Price: 100, MaxCountPeople: 50, BookingTTL: 15 * time.Minute},
+	})
+	if _, err = multi.TicketType(""); err != event.ErrTicketTypeRequired {
+		t.Errorf("expected ErrTicketTypeRequired, got %v", err)
+	}
+	if _, err = multi.TicketType(uuid.New().String()); err != event.ErrTicketTypeNotFound {
+		t.Errorf("expected ErrTicketTypeNotFound, got %v", err)
+	}
+	if _, err = multi.TicketType("bad"); err == nil {
+		t.Error("expected error for malformed id")
+	}
+	if tt, err = multi.TicketType(multi.TicketTypes[1].ID.String()); err != nil || tt.Name != "standard" {
+		t.Errorf("expected standard tier, got %+v, %v", tt, err)
+	}
+}
+
+func TestApplyBy_TicketTypes(t *testing.T) {
+	creator := uuid.New()
+	single, _ := event.New(creator.String(), "Gig", "Desc", time.Now().Add(time.Hour), 15*time.Minute, 10, 100)
+	capacity, price := 20, 80.0
+	if err := single.ApplyBy(creator, event.Update{MaxCountPeople: &capacity, Price: &price}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tt := single.TicketTypes[0]
+	if tt.MaxCountPeople != 20 || tt.FreePlaces != 20 || tt.Price != 80 {
+		t.Errorf("single tier not updated: %+v", tt)
+	}
+
+	multi, _ := event.NewWithTicketTypes(creator.String(), "Gig", "Desc", time.Now().Add(time.Hour), []event.TicketType{
+		{Name: "vip", Price: 300, MaxCountPeople: 10, BookingTTL: 30 * time.Minute},
+		{Name: "standard", Price: 100, MaxCountPeople: 50, BookingTTL: 15 * time.Minute},
+	})
+	if err := multi.ApplyBy(creator, event.Update{Price: &price}); err != event.ErrAmbiguousTierUpdate {
+		t.Errorf("expected ErrAmbiguousTierUpdate, got %v", err)
+	}
+	name := "Renamed"
+	if err := multi.ApplyBy(creator, event.Update{Name: &name}); err != nil || multi.Name != name {
+		t.Errorf("expected rename to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVerificationToken = apperr.Validation("invalid_verification_token", "email verification token is invalid or expired")
+	ErrEmailAlreadyVerified     = apperr.Conflict("email_already_verified", "email is already verified")
+	ErrNoEmail                  = apperr.Validation("no_email", "user has no email to verify")
+	ErrEmailNotVerified         = apperr.Forbidden("email_not_verified", "email must be verified first")
+)
+
+// EmailVerification is a one-time token that proves the user owns Email. It is
+// emailed to that address, and only its hash is stored. The address is kept so
+// that a token sent before an email change cannot verify the new one.
+type EmailVerification struct {
+	Token     string
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+}
+
+// NewEmailVerification creates a verification token for the current email of the
+// user valid for ttl.
+func NewEmailVerification(u *User, ttl time.Duration) *EmailVerification {
+	return &EmailVerification{
+		Token:     newToken(32),
+		UserID:    u.ID,
+		Email:     u.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
@@ -0,0 +1,28 @@
+package user
+
+import (
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidResetToken = apperr.Validation("invalid_reset_token", "password reset token is invalid or expired")
+
+// PasswordReset is a one-time token that lets the user set a new password without
+// the old one. It is emailed to the user, and only its hash is stored.
+type PasswordReset struct {
+	Token     string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+}
+
+// NewPasswordReset creates a reset token for the user valid for ttl.
+func NewPasswordReset(userID uuid.UUID, ttl time.Duration) *PasswordReset {
+	return &PasswordReset{
+		Token:     newToken(32),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
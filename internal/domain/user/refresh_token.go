@@ -0,0 +1,58 @@
+package user
+
+import (
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenRevoked = apperr.Unauthorized("refresh_token_revoked", "refresh token was revoked")
+	ErrRefreshTokenReused  = apperr.Unauthorized("refresh_token_reused", "refresh token was already used, the session is revoked")
+)
+
+// RefreshToken is the stored state of an issued refresh token, identified by the
+// jti claim. Every refresh uses the token up and issues the next one in the same
+// family, so a family is one login session that ends with logout or when its
+// latest token expires.
+type RefreshToken struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	// UsedAt and RevokedAt stay zero while the token can be used.
+	UsedAt    time.Time
+	RevokedAt time.Time
+}
+
+// NewRefreshToken starts a new family for a login of the user, valid for ttl.
+func NewRefreshToken(userID uuid.UUID, ttl time.Duration) *RefreshToken {
+	id := uuid.New()
+	return &RefreshToken{
+		ID:        id,
+		FamilyID:  id,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+// Rotate uses the token up and returns its successor, valid for ttl. A token that
+// was already used has leaked or been replayed: ErrRefreshTokenReused tells the
+// caller to revoke the whole family.
+func (t *RefreshToken) Rotate(ttl time.Duration) (*RefreshToken, error) {
+	if !t.RevokedAt.IsZero() {
+		return nil, ErrRefreshTokenRevoked
+	}
+	if !t.UsedAt.IsZero() {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return &RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  t.FamilyID,
+		UserID:    t.UserID,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
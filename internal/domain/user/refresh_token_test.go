@@ -0,0 +1,47 @@
+package user_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	u "eventbooker/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+func TestRefreshToken_Rotate(t *testing.T) {
+	userID := uuid.New()
+	first := u.NewRefreshToken(userID, time.Hour)
+	if first.FamilyID != first.ID || first.UserID != userID {
+		t.Fatalf("unexpected token %+v", first)
+	}
+
+	next, err := first.Rotate(2 * time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if next.ID == first.ID || next.FamilyID != first.FamilyID || next.UserID != userID {
+		t.Errorf("unexpected successor %+v of %+v", next, first)
+	}
+	if time.Until(next.ExpiresAt) <= time.Hour {
+		t.Errorf("expected the successor to be valid for the new ttl, expires at %v", next.ExpiresAt)
+	}
+}
+
+func TestRefreshToken_RotateUsedOrRevoked(t *testing.T) {
+	now := time.Now()
+
+	used := u.NewRefreshToken(uuid.New(), time.Hour)
+	used.UsedAt = now
+	if _, err := used.Rotate(time.Hour); !errors.Is(err, u.ErrRefreshTokenReused) {
+		t.Errorf("expected reuse to be detected, got %v", err)
+	}
+
+	revoked := u.NewRefreshToken(uuid.New(), time.Hour)
+	revoked.UsedAt = now
+	revoked.RevokedAt = now
+	if _, err := revoked.Rotate(time.Hour); !errors.Is(err, u.ErrRefreshTokenRevoked) {
+		t.Errorf("expected a revoked token to be rejected, got %v", err)
+	}
+}
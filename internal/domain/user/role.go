@@ -0,0 +1,26 @@
+package user
+
+import "eventbooker/internal/apperr"
+
+// Role decides which parts of the API a user may use.
+type Role string
+
+const (
+	// RoleAttendee browses events and books seats. Every new account starts with it.
+	RoleAttendee Role = "attendee"
+	// RoleOrganizer also publishes events and subscribes to webhooks about them.
+	RoleOrganizer Role = "organizer"
+	// RoleAdmin also manages roles and notification deliveries.
+	RoleAdmin Role = "admin"
+)
+
+var ErrInvalidRole = apperr.Validation("invalid_role", "role must be one of attendee, organizer, admin")
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAttendee, RoleOrganizer, RoleAdmin:
+		return true
+	}
+	return false
+}
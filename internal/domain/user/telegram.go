@@ -0,0 +1,48 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidLinkToken = apperr.Validation("invalid_link_token", "telegram link token is invalid or expired")
+
+// TelegramLink is a one-time token that binds the Telegram chat it is sent from to
+// the user. It travels in a t.me deep link, and only its hash is stored.
+type TelegramLink struct {
+	Token     string
+	UserID    uuid.UUID
+	ExpiresAt time.Time
+	// URL is the deep link that opens the bot with the token.
+	URL string
+}
+
+// NewTelegramLink creates a link token for the user valid for ttl.
+func NewTelegramLink(userID uuid.UUID, ttl time.Duration) *TelegramLink {
+	// Deep link parameters are limited to 64 characters of A-Z, a-z, 0-9, _ and -.
+	return &TelegramLink{
+		Token:     newToken(24),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+// newToken returns a random URL-safe token of size bytes.
+func newToken(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// HashToken returns the form in which a one-time token is stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
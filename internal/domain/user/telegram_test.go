@@ -0,0 +1,64 @@
+package user_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	u "eventbooker/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+func TestNewTelegramLink(t *testing.T) {
+	userID := uuid.New()
+
+	l := u.NewTelegramLink(userID, time.Minute)
+	if l.UserID != userID || time.Until(l.ExpiresAt) > time.Minute {
+		t.Errorf("unexpected link %+v", l)
+	}
+	// Telegram only accepts these characters in deep link parameters.
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`).MatchString(l.Token) {
+		t.Errorf("token %q cannot be used in a deep link", l.Token)
+	}
+	if other := u.NewTelegramLink(userID, time.Minute); other.Token == l.Token {
+		t.Error("expected a new token every time")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	if u.HashToken("a") != u.HashToken("a") || u.HashToken("a") == u.HashToken("b") {
+		t.Error("expected a stable hash that differs between tokens")
+	}
+	if u.HashToken("a") == "a" {
+		t.Error("expected the token not to be stored as is")
+	}
+}
+
+func TestNewPasswordReset(t *testing.T) {
+	userID := uuid.New()
+
+	r := u.NewPasswordReset(userID, time.Minute)
+	if r.UserID != userID || time.Until(r.ExpiresAt) > time.Minute {
+		t.Errorf("unexpected reset %+v", r)
+	}
+	// The token travels in a link.
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`).MatchString(r.Token) {
+		t.Errorf("token %q is not 32 random bytes in URL-safe base64", r.Token)
+	}
+	if other := u.NewPasswordReset(userID, time.Minute); other.Token == r.Token {
+		t.Error("expected a new token every time")
+	}
+}
+
+func TestNewEmailVerification(t *testing.T) {
+	usr := &u.User{ID: uuid.New(), Email: "alice@example.com"}
+
+	v := u.NewEmailVerification(usr, time.Hour)
+	if v.UserID != usr.ID || v.Email != usr.Email || time.Until(v.ExpiresAt) > time.Hour {
+		t.Errorf("unexpected verification %+v", v)
+	}
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`).MatchString(v.Token) {
+		t.Errorf("token %q is not 32 random bytes in URL-safe base64", v.Token)
+	}
+}
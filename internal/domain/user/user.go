@@ -3,10 +3,19 @@ package user
 import (
 	"time"
 
+	"eventbooker/internal/apperr"
+
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrNotFound           = apperr.NotFound("user_not_found", "user not found")
+	ErrAlreadyExists      = apperr.Conflict("user_already_exists", "user with this login already exists")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid login or password")
+	ErrWrongPassword      = apperr.Validation("wrong_password", "current password is incorrect")
+)
+
 // User is the domain model for a user account.
 type User struct {
 	ID        uuid.UUID
@@ -15,11 +24,19 @@ type User struct {
 	CreatedAt time.Time
 	Email     string
 	Telegram  string
+	Locale    string
+	Role      Role
+	// EmailVerified is set once the user follows the link emailed to Email.
+	EmailVerified bool
+	// EmailNotification and TelegramNotification are the channels used for bookings
+	// that do not choose their own.
+	EmailNotification    bool
+	TelegramNotification bool
 }
 
 // New creates a new User with a hashed password.
-func New(login, password, email, telegram string) (*User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func New(login, password, email, telegram, locale string) (*User, error) {
+	hashedPassword, err := HashPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -31,5 +48,61 @@ func New(login, password, email, telegram string) (*User, error) {
 		CreatedAt: time.Now(),
 		Email:     email,
 		Telegram:  telegram,
+		Locale:    locale,
+		Role:      RoleAttendee,
+
+		EmailNotification:    true,
+		TelegramNotification: telegram != "",
 	}, nil
 }
+
+// Update holds the fields a user can edit in their profile. Nil fields are left
+// unchanged, an empty Telegram unlinks the chat.
+type Update struct {
+	Email                *string
+	Telegram             *string
+	Locale               *string
+	EmailNotification    *bool
+	TelegramNotification *bool
+}
+
+// Apply applies the update and reports whether the email changed. A new email is
+// not verified yet.
+func (u *User) Apply(upd Update) bool {
+	emailChanged := upd.Email != nil && *upd.Email != u.Email
+	if emailChanged {
+		u.Email = *upd.Email
+		u.EmailVerified = false
+	}
+	if upd.Telegram != nil {
+		u.Telegram = *upd.Telegram
+	}
+	if upd.Locale != nil {
+		u.Locale = *upd.Locale
+	}
+	if upd.EmailNotification != nil {
+		u.EmailNotification = *upd.EmailNotification
+	}
+	if upd.TelegramNotification != nil {
+		u.TelegramNotification = *upd.TelegramNotification
+	}
+	return emailChanged
+}
+
+// HashPassword returns the form in which a password is stored.
+func HashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// Channels returns the notification channels of a booking: the chosen ones, and the
+// defaults of the user for those left nil. Telegram is off by default without a chat.
+func (u *User) Channels(telegram, email *bool) (bool, bool) {
+	tg, em := u.TelegramNotification && u.Telegram != "", u.EmailNotification
+	if telegram != nil {
+		tg = *telegram
+	}
+	if email != nil {
+		em = *email
+	}
+	return tg, em
+}
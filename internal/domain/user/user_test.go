@@ -16,7 +16,7 @@ func TestNew_Success(t *testing.T) {
 	email := "test@example.com"
 	telegram := "@test"
 
-	user, err := u.New(login, password, email, telegram)
+	user, err := u.New(login, password, email, telegram, "en")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -35,6 +35,9 @@ func TestNew_Success(t *testing.T) {
 	if user.Telegram != telegram {
 		t.Errorf("expected telegram %s, got %s", telegram, user.Telegram)
 	}
+	if user.Locale != "en" {
+		t.Errorf("expected locale en, got %s", user.Locale)
+	}
 	if time.Since(user.CreatedAt) > time.Second {
 		t.Error("CreatedAt should be set to current time")
 	}
@@ -51,8 +54,57 @@ func TestNew_HashError(t *testing.T) {
 	for i := range veryLongPassword {
 		veryLongPassword[i] = 'A'
 	}
-	_, err := u.New("user", string(veryLongPassword), "e@mail.com", "@tg")
+	_, err := u.New("user", string(veryLongPassword), "e@mail.com", "@tg", "")
 	if err == nil {
 		t.Fatal("expected error from bcrypt, got nil")
 	}
 }
+
+func TestNew_NotificationDefaults(t *testing.T) {
+	withChat, _ := u.New("user", "MyPassword123", "e@mail.com", "42", "")
+	if !withChat.EmailNotification || !withChat.TelegramNotification {
+		t.Errorf("expected email and Telegram by default with a chat, got %+v", withChat)
+	}
+	withoutChat, _ := u.New("user", "MyPassword123", "e@mail.com", "", "")
+	if !withoutChat.EmailNotification || withoutChat.TelegramNotification {
+		t.Errorf("expected only email by default without a chat, got %+v", withoutChat)
+	}
+}
+
+func TestUser_Apply(t *testing.T) {
+	user := &u.User{Email: "old@mail.com", EmailVerified: true, Telegram: "42", Locale: "en", EmailNotification: true}
+
+	same, off, ru, none := "old@mail.com", false, "ru", ""
+	if user.Apply(u.Update{Email: &same, Locale: &ru, EmailNotification: &off, Telegram: &none}) {
+		t.Error("expected the same email not to count as a change")
+	}
+	if !user.EmailVerified || user.Locale != "ru" || user.EmailNotification || user.Telegram != "" {
+		t.Errorf("unexpected user after update %+v", user)
+	}
+
+	other := "new@mail.com"
+	if !user.Apply(u.Update{Email: &other}) {
+		t.Error("expected the email change to be reported")
+	}
+	if user.Email != other || user.EmailVerified || user.Locale != "ru" {
+		t.Errorf("expected a new unverified email and the rest unchanged, got %+v", user)
+	}
+}
+
+func TestUser_Channels(t *testing.T) {
+	user := &u.User{EmailNotification: true, TelegramNotification: true}
+
+	tg, email := user.Channels(nil, nil)
+	if tg || !email {
+		t.Errorf("expected email only without a linked chat, got telegram=%v email=%v", tg, email)
+	}
+
+	user.Telegram = "42"
+	on, off := true, false
+	if tg, email = user.Channels(nil, &off); !tg || email {
+		t.Errorf("expected the default Telegram and the chosen email, got telegram=%v email=%v", tg, email)
+	}
+	if tg, email = (&u.User{}).Channels(&on, nil); !tg || email {
+		t.Errorf("expected the chosen Telegram and the default email, got telegram=%v email=%v", tg, email)
+	}
+}
@@ -0,0 +1,80 @@
+package waitlist
+
+import (
+	"time"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/domain/booking"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound       = apperr.NotFound("waitlist_entry_not_found", "user is not on the waitlist for this event")
+	ErrAlreadyJoined  = apperr.Conflict("already_waitlisted", "user is already on the waitlist for this event")
+	ErrSeatsAvailable = apperr.Conflict("seats_available", "event has enough free seats, book it directly")
+)
+
+// Status represents the state of a waitlist entry.
+type Status string
+
+const (
+	StatusWaiting  Status = "waiting"
+	StatusPromoted Status = "promoted"
+	StatusLeft     Status = "left"
+	StatusRemoved  Status = "removed"
+)
+
+// Entry is a user's place in the queue for a sold-out event.
+type Entry struct {
+	ID                   uuid.UUID
+	EventID              uuid.UUID
+	UserID               uuid.UUID
+	TicketTypeID         uuid.UUID
+	Count                int
+	Status               Status
+	Position             int
+	CreatedAt            time.Time
+	TelegramNotification bool
+	EmailNotification    bool
+	BookingID            *uuid.UUID
+}
+
+// New creates a new waiting Entry with validation.
+func New(eventID, userID string, telegramNotification, emailNotification bool, count int) (*Entry, error) {
+	eID, err := uuid.Parse(eventID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid event id", err)
+	}
+
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid user id", err)
+	}
+
+	if count <= 0 {
+		return nil, booking.ErrInvalidCount
+	}
+
+	return &Entry{
+		ID:                   uuid.New(),
+		EventID:              eID,
+		UserID:               uID,
+		Count:                count,
+		Status:               StatusWaiting,
+		CreatedAt:            time.Now(),
+		TelegramNotification: telegramNotification,
+		EmailNotification:    emailNotification,
+	}, nil
+}
+
+// Fits reports whether the entry can be served from the given number of free seats.
+func (e *Entry) Fits(freePlaces int) bool {
+	return e.Status == StatusWaiting && e.Count <= freePlaces
+}
+
+// Promote marks the entry as served by the given hold booking.
+func (e *Entry) Promote(bookingID uuid.UUID) {
+	e.Status = StatusPromoted
+	e.BookingID = &bookingID
+}
@@ -0,0 +1,60 @@
+package waitlist_test
+
+import (
+	"errors"
+	"testing"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/waitlist"
+
+	"github.com/google/uuid"
+)
+
+func TestNew_Success(t *testing.T) {
+	e, err := waitlist.New(uuid.New().String(), uuid.New().String(), true, false, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Status != waitlist.StatusWaiting {
+		t.Errorf("expected status waiting, got %s", e.Status)
+	}
+	if e.Count != 2 || !e.TelegramNotification || e.EmailNotification {
+		t.Error("fields were not copied")
+	}
+}
+
+func TestNew_InvalidCount(t *testing.T) {
+	_, err := waitlist.New(uuid.New().String(), uuid.New().String(), false, false, 0)
+	if !errors.Is(err, booking.ErrInvalidCount) {
+		t.Fatalf("expected ErrInvalidCount, got %v", err)
+	}
+}
+
+func TestNew_InvalidIDs(t *testing.T) {
+	if _, err := waitlist.New("bad", uuid.New().String(), false, false, 1); err == nil {
+		t.Error("expected error for invalid event id")
+	}
+	if _, err := waitlist.New(uuid.New().String(), "bad", false, false, 1); err == nil {
+		t.Error("expected error for invalid user id")
+	}
+}
+
+func TestEntry_FitsAndPromote(t *testing.T) {
+	e, _ := waitlist.New(uuid.New().String(), uuid.New().String(), false, false, 3)
+
+	if e.Fits(2) {
+		t.Error("entry for 3 should not fit into 2 seats")
+	}
+	if !e.Fits(3) {
+		t.Error("entry for 3 should fit into 3 seats")
+	}
+
+	id := uuid.New()
+	e.Promote(id)
+	if e.Status != waitlist.StatusPromoted || e.BookingID == nil || *e.BookingID != id {
+		t.Fatalf("unexpected entry after promote: %+v", e)
+	}
+	if e.Fits(10) {
+		t.Error("promoted entry must not be promoted again")
+	}
+}
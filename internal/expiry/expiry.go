@@ -0,0 +1,34 @@
+// Package expiry releases bookings whose hold time ran out.
+//
+// Two implementations of Expirer exist: the RabbitMQ broker, which gets a delayed
+// message per booking, and the Postgres Sweeper, which polls for due bookings.
+// They can run together, the sweeper then acting as a safety net for lost messages.
+package expiry
+
+import (
+	"context"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+)
+
+// Expirer is a background process that expires unconfirmed bookings.
+type Expirer interface {
+	Start(ctx context.Context) error
+	Close() error
+}
+
+// Notifier sends booking notifications to their owners.
+type Notifier interface {
+	Notify(kind notification.Kind, b *booking.Booking)
+}
+
+// Finish runs after an expired booking was released: it notifies its owner and the
+// owners of the holds promoted from the waitlist into its seats. The expiry of those
+// holds was queued in the outbox together with them.
+func Finish(n Notifier, expired *booking.Booking, promoted []*booking.Booking) {
+	n.Notify(notification.KindBookingExpired, expired)
+	for _, b := range promoted {
+		n.Notify(notification.KindBookingPromoted, b)
+	}
+}
@@ -0,0 +1,108 @@
+package expiry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultSweepInterval = 30 * time.Second
+	defaultSweepBatch    = 100
+)
+
+// Store defines the repository operation needed by Sweeper.
+type Store interface {
+	ExpireNextDue(ctx context.Context) (*booking.Booking, []*booking.Booking, error)
+}
+
+// Sweeper is an Expirer that periodically polls Postgres for due bookings.
+// Several instances may run at once: each due row is taken by one of them.
+type Sweeper struct {
+	store    Store
+	notifier Notifier
+	interval time.Duration
+	batch    int
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSweeper creates a new Sweeper.
+func NewSweeper(store Store, notifier Notifier, cfg *config.ExpiryConfig) *Sweeper {
+	s := &Sweeper{
+		store:    store,
+		notifier: notifier,
+		interval: cfg.SweepInterval,
+		batch:    cfg.SweepBatch,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSweepInterval
+	}
+	if s.batch <= 0 {
+		s.batch = defaultSweepBatch
+	}
+	return s
+}
+
+// Start runs the polling loop in the background until ctx is done or Close is called.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.Sweep(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Sweep expires up to one batch of due bookings and returns how many were expired.
+func (s *Sweeper) Sweep(ctx context.Context) int {
+	for n := 0; n < s.batch; n++ {
+		if ctx.Err() != nil {
+			return n
+		}
+
+		expired, promoted, err := s.store.ExpireNextDue(ctx)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("expiry sweep failed")
+			return n
+		}
+		if expired == nil {
+			return n
+		}
+
+		wbzlog.Logger.Info().Msgf("sweeper expired booking %s", expired.ID)
+		Finish(s.notifier, expired, promoted)
+	}
+
+	return s.batch
+}
+
+// Close stops the polling loop and waits for the current sweep to finish.
+func (s *Sweeper) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
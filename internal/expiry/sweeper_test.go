@@ -0,0 +1,111 @@
+package expiry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/expiry"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	due   []*booking.Booking
+	holds map[uuid.UUID][]*booking.Booking
+	err   error
+}
+
+func (f *fakeStore) ExpireNextDue(ctx context.Context) (*booking.Booking, []*booking.Booking, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	if len(f.due) == 0 {
+		return nil, nil, nil
+	}
+	b := f.due[0]
+	f.due = f.due[1:]
+	return b, f.holds[b.ID], nil
+}
+
+type fakeNotifier struct {
+	sent map[notification.Kind][]*booking.Booking
+}
+
+func (f *fakeNotifier) Notify(kind notification.Kind, b *booking.Booking) {
+	if f.sent == nil {
+		f.sent = make(map[notification.Kind][]*booking.Booking)
+	}
+	f.sent[kind] = append(f.sent[kind], b)
+}
+
+func TestSweeper_Sweep_ExpiresDueAndNotifies(t *testing.T) {
+	expired := &booking.Booking{ID: uuid.New(), EmailNotification: true, EmailRecepient: "late@example.com"}
+	hold := &booking.Booking{ID: uuid.New(), Status: booking.StatusCreated, EmailNotification: true, EmailRecepient: "next@example.com"}
+	store := &fakeStore{
+		due:   []*booking.Booking{expired, {ID: uuid.New()}},
+		holds: map[uuid.UUID][]*booking.Booking{expired.ID: {hold}},
+	}
+	notifier := &fakeNotifier{}
+
+	s := expiry.NewSweeper(store, notifier, &config.ExpiryConfig{SweepBatch: 10})
+	if n := s.Sweep(context.Background()); n != 2 {
+		t.Fatalf("expected 2 expired bookings, got %d", n)
+	}
+	if got := notifier.sent[notification.KindBookingExpired]; len(got) != 2 || got[0] != expired {
+		t.Errorf("expected expiry notices for both bookings, got %v", got)
+	}
+	if got := notifier.sent[notification.KindBookingPromoted]; len(got) != 1 || got[0] != hold {
+		t.Errorf("expected promotion notice for the hold, got %v", got)
+	}
+}
+
+func TestSweeper_Sweep_StopsAtBatch(t *testing.T) {
+	store := &fakeStore{due: []*booking.Booking{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}}
+	s := expiry.NewSweeper(store, &fakeNotifier{}, &config.ExpiryConfig{SweepBatch: 2})
+
+	if n := s.Sweep(context.Background()); n != 2 {
+		t.Fatalf("expected batch of 2, got %d", n)
+	}
+	if len(store.due) != 1 {
+		t.Errorf("expected 1 booking left for the next sweep, got %d", len(store.due))
+	}
+}
+
+func TestSweeper_Sweep_StoreError(t *testing.T) {
+	store := &fakeStore{err: errors.New("db down")}
+	s := expiry.NewSweeper(store, &fakeNotifier{}, &config.ExpiryConfig{})
+
+	if n := s.Sweep(context.Background()); n != 0 {
+		t.Fatalf("expected nothing expired, got %d", n)
+	}
+}
+
+// signalStore reports every ExpireNextDue call on a channel.
+type signalStore struct{ calls chan struct{} }
+
+func (s *signalStore) ExpireNextDue(ctx context.Context) (*booking.Booking, []*booking.Booking, error) {
+	s.calls <- struct{}{}
+	return nil, nil, nil
+}
+
+func TestSweeper_StartClose(t *testing.T) {
+	store := &signalStore{calls: make(chan struct{}, 1)}
+	s := expiry.NewSweeper(store, &fakeNotifier{}, &config.ExpiryConfig{SweepInterval: time.Hour})
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-store.calls:
+	case <-time.After(time.Second):
+		t.Fatal("expected the first sweep to run on start")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
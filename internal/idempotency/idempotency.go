@@ -0,0 +1,94 @@
+// Package idempotency lets clients retry unsafe requests: the first response to a
+// request carrying an Idempotency-Key is stored per user and replayed to retries,
+// so a timed-out request never takes effect twice.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"eventbooker/internal/apperr"
+)
+
+// Header carries the key chosen by the client.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks responses replayed from a stored record.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength is the longest accepted key.
+const MaxKeyLength = 255
+
+var (
+	ErrInvalidKey = apperr.Validation("invalid_idempotency_key", "idempotency key must be 1 to 255 printable characters")
+	ErrKeyReused  = apperr.Validation("idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrInProgress = apperr.Conflict("idempotency_in_progress", "a request with this idempotency key is still being processed")
+)
+
+// Record is a key used by a user. StatusCode and Body stay empty while the first
+// request is being processed.
+type Record struct {
+	UserID      string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// New creates a Record for a request of the user, kept for ttl.
+func New(userID, key, requestHash string, ttl time.Duration) (*Record, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(ttl),
+	}, nil
+}
+
+// Done reports whether the response of the first request is stored.
+func (r *Record) Done() bool {
+	return r.StatusCode != 0
+}
+
+// Match checks that a retry is the same request as the stored one and that its
+// response can be replayed.
+func (r *Record) Match(requestHash string) error {
+	if r.RequestHash != requestHash {
+		return ErrKeyReused
+	}
+	if !r.Done() {
+		return ErrInProgress
+	}
+	return nil
+}
+
+// ValidateKey checks that a key is non-empty, not too long and printable ASCII.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Hash identifies a request by its method, path and body, so a key reused for
+// another request is detected.
+func Hash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package idempotency_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/idempotency"
+)
+
+func TestNew_ValidatesKey(t *testing.T) {
+	for _, key := range []string{"", strings.Repeat("a", idempotency.MaxKeyLength+1), "bad\nkey", "ключ"} {
+		if _, err := idempotency.New("user", key, "hash", time.Hour); !errors.Is(err, idempotency.ErrInvalidKey) {
+			t.Errorf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+
+	rec, err := idempotency.New("user", "0f8fad5b-d9cb-469f-a165-70867728950e", "hash", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Done() || time.Until(rec.ExpiresAt) > time.Hour {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
+
+func TestRecord_Match(t *testing.T) {
+	rec := &idempotency.Record{RequestHash: "a"}
+	if err := rec.Match("b"); !errors.Is(err, idempotency.ErrKeyReused) {
+		t.Errorf("expected ErrKeyReused, got %v", err)
+	}
+	if err := rec.Match("a"); !errors.Is(err, idempotency.ErrInProgress) {
+		t.Errorf("expected ErrInProgress, got %v", err)
+	}
+
+	rec.StatusCode = 200
+	if err := rec.Match("a"); err != nil {
+		t.Errorf("expected a replayable record, got %v", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	h := idempotency.Hash("POST", "/api/events/1/book", []byte(`{"count":1}`))
+	if h != idempotency.Hash("POST", "/api/events/1/book", []byte(`{"count":1}`)) {
+		t.Error("expected a stable hash")
+	}
+	for _, other := range []string{
+		idempotency.Hash("POST", "/api/events/2/book", []byte(`{"count":1}`)),
+		idempotency.Hash("POST", "/api/events/1/book", []byte(`{"count":2}`)),
+		idempotency.Hash("POST", "/api/events/1/boo", []byte(`k{"count":1}`)),
+	} {
+		if other == h {
+			t.Error("expected different requests to hash differently")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package notification
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/user"
+)
+
+// Templates of account emails.
+const (
+	templatePasswordReset     = "password_reset"
+	templateEmailVerification = "email_verification"
+)
+
+// PasswordResetMessage is the data passed to the password reset template. URL is
+// empty when no reset page is configured, and the bare token is sent instead.
+type PasswordResetMessage struct {
+	Login     string
+	Token     string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// EmailVerificationMessage is the data passed to the email verification template.
+type EmailVerificationMessage struct {
+	Login     string
+	Email     string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// AccountMailer emails users about their account rather than a booking. These
+// emails carry one-time secrets, so unlike booking notifications they are neither
+// logged nor retried: the user simply asks again.
+type AccountMailer struct {
+	templates *Templates
+	email     Sender
+	resetURL  string
+	verifyURL string
+}
+
+// NewAccountMailer creates a new AccountMailer.
+func NewAccountMailer(templates *Templates, email Sender, reset *config.PasswordResetConfig, verification *config.EmailVerificationConfig) *AccountMailer {
+	return &AccountMailer{templates: templates, email: email, resetURL: reset.URL, verifyURL: verification.URL}
+}
+
+// SendPasswordReset emails the reset token to the user in their locale.
+func (m *AccountMailer) SendPasswordReset(u *user.User, r *user.PasswordReset) error {
+	msg := PasswordResetMessage{Login: u.Login, Token: r.Token, ExpiresAt: r.ExpiresAt}
+	if m.resetURL != "" {
+		link, err := withToken(m.resetURL, r.Token)
+		if err != nil {
+			return err
+		}
+		msg.URL = link
+	}
+
+	c, err := m.templates.Render(u.Locale, templatePasswordReset, msg)
+	if err != nil {
+		return fmt.Errorf("render password reset: %w", err)
+	}
+	return m.email.Send(u.Email, c)
+}
+
+// SendEmailVerification emails the verification link to the address being verified.
+func (m *AccountMailer) SendEmailVerification(u *user.User, v *user.EmailVerification) error {
+	link, err := withToken(m.verifyURL, v.Token)
+	if err != nil {
+		return err
+	}
+	msg := EmailVerificationMessage{Login: u.Login, Email: v.Email, URL: link, ExpiresAt: v.ExpiresAt}
+
+	c, err := m.templates.Render(u.Locale, templateEmailVerification, msg)
+	if err != nil {
+		return fmt.Errorf("render email verification: %w", err)
+	}
+	return m.email.Send(v.Email, c)
+}
+
+// withToken adds the token to the query of a configured page URL.
+func withToken(page, token string) (string, error) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", fmt.Errorf("parse page url %q: %w", page, err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,181 @@
+package notification_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/user"
+	"eventbooker/internal/notification"
+)
+
+// smtpServer is a fake SMTP server that accepts one message and hands it over.
+type smtpServer struct {
+	ln       net.Listener
+	messages chan []byte
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln, messages: make(chan []byte, 1)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH"):
+			reply("235 authenticated")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var msg bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.messages <- msg.Bytes()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// plainText returns the recipient and the text part of a message sent by EmailSender.
+func plainText(t *testing.T, raw []byte) (string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(part)
+	return msg.Header.Get("To"), string(text)
+}
+
+func TestAccountMailer_SendPasswordReset(t *testing.T) {
+	server := newSMTPServer(t)
+	sender := notification.NewEmailSender(&config.AppConfig{Mail: config.MailConfig{
+		SMTPHost: "127.0.0.1", SMTPPort: server.port(), SMTPEmail: "noreply@example.com", SMTPPassword: "secret",
+	}})
+	mailer := notification.NewAccountMailer(newTemplates(t, ""), sender, &config.PasswordResetConfig{URL: "https://app.example.com/reset?lang=ru"}, &config.EmailVerificationConfig{})
+
+	u := &user.User{Login: "alice", Email: "alice@example.com", Locale: "ru"}
+	reset := &user.PasswordReset{Token: "tok_en-1", ExpiresAt: time.Date(2030, 1, 2, 15, 4, 0, 0, time.UTC)}
+	if err := mailer.SendPasswordReset(u, reset); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []byte
+	select {
+	case raw = <-server.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	to, text := plainText(t, raw)
+	if to != "<alice@example.com>" {
+		t.Errorf("expected the message to alice@example.com, got %q", to)
+	}
+	link := "https://app.example.com/reset?" + url.Values{"lang": {"ru"}, "token": {"tok_en-1"}}.Encode()
+	for _, want := range []string{"alice", link, "02.01.2030 15:04"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in the Russian text %q", want, text)
+		}
+	}
+}
+
+func TestAccountMailer_SendPasswordReset_NoURL(t *testing.T) {
+	server := newSMTPServer(t)
+	sender := notification.NewEmailSender(&config.AppConfig{Mail: config.MailConfig{
+		SMTPHost: "127.0.0.1", SMTPPort: server.port(), SMTPEmail: "noreply@example.com",
+	}})
+	mailer := notification.NewAccountMailer(newTemplates(t, ""), sender, &config.PasswordResetConfig{}, &config.EmailVerificationConfig{})
+
+	u := &user.User{Login: "bob", Email: "bob@example.com"}
+	if err := mailer.SendPasswordReset(u, &user.PasswordReset{Token: "tok123", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, text := plainText(t, <-server.messages)
+	if !strings.Contains(text, "code: tok123") || strings.Contains(text, "http") {
+		t.Errorf("expected the bare token without a link, got %q", text)
+	}
+}
+
+func TestAccountMailer_SendEmailVerification(t *testing.T) {
+	server := newSMTPServer(t)
+	sender := notification.NewEmailSender(&config.AppConfig{Mail: config.MailConfig{
+		SMTPHost: "127.0.0.1", SMTPPort: server.port(), SMTPEmail: "noreply@example.com",
+	}})
+	mailer := notification.NewAccountMailer(newTemplates(t, ""), sender, &config.PasswordResetConfig{},
+		&config.EmailVerificationConfig{URL: "https://api.example.com/api/auth/verify"})
+
+	// The link goes to the address being verified rather than the stored one.
+	u := &user.User{Login: "carol", Email: "old@example.com"}
+	v := &user.EmailVerification{Token: "tok456", Email: "carol@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := mailer.SendEmailVerification(u, v); err != nil {
+		t.Fatal(err)
+	}
+
+	to, text := plainText(t, <-server.messages)
+	if to != "<carol@example.com>" {
+		t.Errorf("expected the message to carol@example.com, got %q", to)
+	}
+	for _, want := range []string{"carol@example.com", "https://api.example.com/api/auth/verify?token=tok456"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in the text %q", want, text)
+		}
+	}
+}
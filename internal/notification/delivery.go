@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeliveryNotFound = apperr.NotFound("delivery_not_found", "notification delivery not found")
+	ErrDeliverySent     = apperr.Conflict("delivery_already_sent", "notification was already delivered")
+)
+
+// Channel is the way a notification reaches its recipient.
+type Channel string
+
+const (
+	ChannelEmail    Channel = "email"
+	ChannelTelegram Channel = "telegram"
+)
+
+// DeliveryStatus is the outcome of the latest attempt to deliver a notification.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending means the notification is queued and not attempted yet.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySent means the notification reached the channel.
+	DeliverySent DeliveryStatus = "sent"
+	// DeliveryFailed means the last attempt failed and another one is scheduled.
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliveryAbandoned means every attempt failed and no more are scheduled.
+	DeliveryAbandoned DeliveryStatus = "abandoned"
+)
+
+// Valid reports whether s is a known delivery status.
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliverySent, DeliveryFailed, DeliveryAbandoned:
+		return true
+	}
+	return false
+}
+
+// Delivery is one notification sent over one channel, together with the outcome of
+// its attempts. The rendered content is kept so a retry sends exactly the same text.
+type Delivery struct {
+	ID            uuid.UUID
+	BookingID     uuid.UUID
+	Kind          Kind
+	Channel       Channel
+	Recipient     string
+	Content       Content
+	Status        DeliveryStatus
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Requeue schedules another attempt of an undelivered notification. An abandoned
+// delivery gets one more attempt before it is abandoned again.
+func (d *Delivery) Requeue(now time.Time) error {
+	if d.Status == DeliverySent {
+		return ErrDeliverySent
+	}
+	d.Status = DeliveryFailed
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	return nil
+}
+
+// DeliveryFilter selects deliveries for the admin listing, newest first.
+type DeliveryFilter struct {
+	Statuses  []DeliveryStatus
+	BookingID uuid.UUID
+	Limit     int
+}
+
+// DeliveryLog queues the deliveries of the Dispatcher.
+type DeliveryLog interface {
+	RecordDelivery(ctx context.Context, d *Delivery) error
+}
+
+// DeliveryStore defines the repository operations needed by Retrier. Claimed are
+// pending deliveries and failed ones whose next attempt is due.
+type DeliveryStore interface {
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*Delivery, error)
+	MarkDeliverySent(ctx context.Context, id uuid.UUID) error
+	MarkDeliveryFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error
+	AbandonDelivery(ctx context.Context, id uuid.UUID, reason string) error
+	PruneDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error)
+}
+
+// permanentError is a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, such as a malformed recipient: the
+// delivery is abandoned at once.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -1,38 +1,258 @@
 package notification
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
 
 	"eventbooker/internal/config"
 )
 
-// EmailSender sends cancellation notifications via email.
+// EmailSender sends booking notifications via email.
 type EmailSender struct {
 	smtpHost     string
 	smtpPort     int
 	smtpEmail    string
 	smtpPassword string
+	from         mail.Address
+	timeout      time.Duration
 }
 
-// NewEmailSender creates a new EmailSender.
+// NewEmailSender creates a new EmailSender. Mail is sent from mail.from, or the SMTP
+// user when it is not set. Every email must be sent within notification.send_timeout.
 func NewEmailSender(cfg *config.AppConfig) *EmailSender {
+	from := cfg.Mail.From
+	if from == "" {
+		from = cfg.Mail.SMTPEmail
+	}
+
+	timeout := cfg.Notification.SendTimeout
+	if timeout <= 0 {
+		timeout = defaultSendTimeout
+	}
+
 	return &EmailSender{
 		smtpHost:     cfg.Mail.SMTPHost,
 		smtpPort:     cfg.Mail.SMTPPort,
 		smtpEmail:    cfg.Mail.SMTPEmail,
 		smtpPassword: cfg.Mail.SMTPPassword,
+		from:         mail.Address{Name: cfg.Mail.FromName, Address: from},
+		timeout:      timeout,
+	}
+}
+
+// Send emails the content to the given address: the text with its HTML alternative,
+// if any, and the attachments.
+func (s *EmailSender) Send(email string, c Content) error {
+	msg, err := buildMessage(s.from, email, c, time.Now())
+	if err != nil {
+		return fmt.Errorf("build email: %w", err)
+	}
+	return s.send(email, msg)
+}
+
+// send does what smtp.SendMail does, but within the timeout: a server that stops
+// answering must not hold the delivery worker.
+func (s *EmailSender) send(email string, msg []byte) error {
+	addr := net.JoinHostPort(s.smtpHost, strconv.Itoa(s.smtpPort))
+	conn, err := net.DialTimeout("tcp", addr, s.timeout)
+	if err != nil {
+		return err
+	}
+	if err = conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.smtpHost)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.smtpHost}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); !ok {
+		return fmt.Errorf("smtp: server doesn't support AUTH")
+	}
+	if err = c.Auth(smtp.PlainAuth("", s.smtpEmail, s.smtpPassword, s.smtpHost)); err != nil {
+		return err
+	}
+
+	if err = c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err = c.Rcpt(email); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage assembles a MIME message. The body is plain text, multipart/alternative
+// when there is HTML, and wrapped in multipart/mixed when there are attachments.
+func buildMessage(from mail.Address, to string, c Content, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	bodyHeader, err := writeBody(&body, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	writeHeader(&msg, "From", from.String())
+	writeHeader(&msg, "To", (&mail.Address{Address: to}).String())
+	writeHeader(&msg, "Subject", mime.QEncoding.Encode("utf-8", c.Subject))
+	writeHeader(&msg, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&msg, "Message-ID", messageID(from.Address))
+	writeHeader(&msg, "MIME-Version", "1.0")
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := bodyHeader.Get(key); v != "" {
+			writeHeader(&msg, key, v)
+		}
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+func writeHeader(w *bytes.Buffer, key, value string) {
+	w.WriteString(key + ": " + value + "\r\n")
+}
+
+// messageID returns a unique Message-ID in the domain of the sender.
+func messageID(from string) string {
+	domain := "eventbooker.local"
+	if _, d, ok := strings.Cut(from, "@"); ok && d != "" {
+		domain = d
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// writeBody writes the body of c and returns the headers describing it.
+func writeBody(w io.Writer, c Content) (textproto.MIMEHeader, error) {
+	if len(c.Attachments) == 0 {
+		return writeText(w, c)
+	}
+
+	mixed := multipart.NewWriter(w)
+
+	var text bytes.Buffer
+	textHeader, err := writeText(&text, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = writePart(mixed, textHeader, text.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range c.Attachments {
+		header := textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		}
+		if err = writePart(mixed, header, base64Lines(a.Data)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = mixed.Close(); err != nil {
+		return nil, err
 	}
+	return textproto.MIMEHeader{"Content-Type": {"multipart/mixed; boundary=" + mixed.Boundary()}}, nil
 }
 
-// Send sends a booking cancellation email.
-func (s *EmailSender) Send(email, eventName string, persons int) error {
-	auth := smtp.PlainAuth("", s.smtpEmail, s.smtpPassword, s.smtpHost)
-	to := []string{email}
-	msg := []byte("To: " + email + "\r\n" +
-		"Subject: Booking Cancelation\r\n" +
-		"\r\n" +
-		fmt.Sprintf("Your booking on %d persons on event: %s just cancelled\r\n", persons, eventName))
-	addr := fmt.Sprintf("%s:%d", s.smtpHost, s.smtpPort)
-	return smtp.SendMail(addr, auth, s.smtpEmail, to, msg)
+// writeText writes the text of c, together with its HTML alternative when there is one.
+func writeText(w io.Writer, c Content) (textproto.MIMEHeader, error) {
+	if c.HTML == "" {
+		return writeQuotedPrintable(w, "text/plain; charset=utf-8", c.Text)
+	}
+
+	alt := multipart.NewWriter(w)
+	for _, p := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", c.Text},
+		{"text/html; charset=utf-8", c.HTML},
+	} {
+		var buf bytes.Buffer
+		header, err := writeQuotedPrintable(&buf, p.contentType, p.body)
+		if err != nil {
+			return nil, err
+		}
+		if err = writePart(alt, header, buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()}}, nil
+}
+
+func writeQuotedPrintable(w io.Writer, contentType, s string) (textproto.MIMEHeader, error) {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}, nil
+}
+
+func writePart(mw *multipart.Writer, header textproto.MIMEHeader, body []byte) error {
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body)
+	return err
+}
+
+// base64Lines encodes data as base64 in lines of 76 characters.
+func base64Lines(data []byte) []byte {
+	const lineLen = 76
+
+	enc := base64.StdEncoding.EncodeToString(data)
+	var out bytes.Buffer
+	for len(enc) > lineLen {
+		out.WriteString(enc[:lineLen] + "\r\n")
+		enc = enc[lineLen:]
+	}
+	out.WriteString(enc + "\r\n")
+	return out.Bytes()
 }
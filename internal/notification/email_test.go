@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage_PlainText(t *testing.T) {
+	from := mail.Address{Name: "EventBooker", Address: "noreply@example.com"}
+	now := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	raw, err := buildMessage(from, "user@example.com", Content{Subject: "Бронь отменена", Text: "Ваша бронь отменена"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []string{"From", "To", "Date", "Message-ID", "MIME-Version"} {
+		if msg.Header.Get(h) == "" {
+			t.Errorf("missing %s header", h)
+		}
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("expected Message-ID in the sender domain, got %q", msg.Header.Get("Message-ID"))
+	}
+	if d, _ := msg.Header.Date(); !d.Equal(now) {
+		t.Errorf("expected Date %s, got %s", now, d)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "Бронь отменена" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain, got %q", ct)
+	}
+}
+
+func TestBuildMessage_AlternativeWithAttachment(t *testing.T) {
+	c := Content{
+		Subject:     "Booking Confirmed",
+		Text:        "See you there",
+		HTML:        "<p>See you there</p>",
+		Attachments: []Attachment{{Filename: "event.ics", ContentType: "text/calendar; charset=utf-8", Data: []byte("BEGIN:VCALENDAR\r\n")}},
+	}
+
+	raw, err := buildMessage(mail.Address{Address: "noreply@example.com"}, "user@example.com", c, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("expected multipart/mixed, got %q (%v)", mediaType, err)
+	}
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	alt, err := mixed.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, _ = mime.ParseMediaType(alt.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative first, got %q", mediaType)
+	}
+	var bodies []string
+	altReader := multipart.NewReader(alt, params["boundary"])
+	for {
+		p, err := altReader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(p)
+		bodies = append(bodies, p.Header.Get("Content-Type")+": "+string(b))
+	}
+	if len(bodies) != 2 || !strings.HasPrefix(bodies[0], "text/plain") || !strings.Contains(bodies[1], "<p>See you there</p>") {
+		t.Errorf("unexpected alternative parts %q", bodies)
+	}
+
+	att, err := mixed.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.FileName() != "event.ics" {
+		t.Errorf("expected event.ics attachment, got %q", att.FileName())
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, att))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "BEGIN:VCALENDAR\r\n" {
+		t.Errorf("unexpected attachment data %q", data)
+	}
+}
+
+func TestEmailSender_Send_TimesOut(t *testing.T) {
+	// The server accepts the connection and never greets the client.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	s := &EmailSender{smtpHost: "127.0.0.1", smtpPort: addr.Port, from: mail.Address{Address: "noreply@example.com"}, timeout: 100 * time.Millisecond}
+
+	start := time.Now()
+	if err = s.Send("user@example.com", Content{Subject: "Hi", Text: "Hi"}); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the send to give up after the timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"eventbooker/internal/domain/booking"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// icsEscaper escapes TEXT values as required by RFC 5545.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// CalendarInvite returns an iCalendar file with the event of a booking, so the
+// attendee can add it to their calendar. The booking ID keeps the UID stable across
+// notifications about the same booking.
+func CalendarInvite(b *booking.Booking, now time.Time) Attachment {
+	description := fmt.Sprintf("%d x %s", b.Count, b.TicketTypeName)
+	if b.TicketTypeName == "" {
+		description = fmt.Sprintf("%d", b.Count)
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//EventBooker//EventBooker//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + b.ID.String() + "@eventbooker",
+		"DTSTAMP:" + now.UTC().Format(icsTimeLayout),
+		"DTSTART:" + b.EventDate.UTC().Format(icsTimeLayout),
+		"SUMMARY:" + icsEscaper.Replace(b.EventName),
+		"DESCRIPTION:" + icsEscaper.Replace(description),
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(foldICSLine(l))
+		sb.WriteString("\r\n")
+	}
+
+	return Attachment{
+		Filename:    "event.ics",
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+		Data:        []byte(sb.String()),
+	}
+}
+
+// foldICSLine splits a content line into chunks of at most 75 octets, continuation
+// lines starting with a space. UTF-8 sequences are never split.
+func foldICSLine(line string) string {
+	const limit = 75
+
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		n := utf8.RuneLen(r)
+		if width+n > limit {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package notification_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+)
+
+func TestCalendarInvite(t *testing.T) {
+	b := &booking.Booking{
+		ID:             uuid.New(),
+		EventName:      "Concert; live, " + strings.Repeat("очень ", 20),
+		EventDate:      time.Date(2030, 5, 1, 19, 30, 0, 0, time.FixedZone("MSK", 3*3600)),
+		TicketTypeName: "vip",
+		Count:          2,
+	}
+
+	a := notification.CalendarInvite(b, time.Now())
+	ics := string(a.Data)
+
+	if !strings.Contains(ics, "DTSTART:20300501T163000Z\r\n") {
+		t.Errorf("expected the start in UTC, got:\n%s", ics)
+	}
+	if !strings.Contains(ics, "UID:"+b.ID.String()+"@eventbooker") {
+		t.Error("expected the booking ID in the UID")
+	}
+	if !strings.Contains(ics, `SUMMARY:Concert\; live\, `) {
+		t.Error("expected escaped summary")
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestDispatcher_Notify_AttachesInviteToConfirmed(t *testing.T) {
+	email := &fakeSender{}
+	log := &fakeDeliveries{}
+	d := notification.NewDispatcher(newTemplates(t, ""), email, &fakeSender{}, log, false)
+
+	b := &booking.Booking{ID: uuid.New(), EventName: "Gig", Count: 1, EmailNotification: true, EmailRecepient: "a@example.com"}
+	b.Status = booking.StatusCreated
+	d.Notify(notification.KindBookingCreated, b)
+	b.Status = booking.StatusConfirmed
+	d.Notify(notification.KindBookingConfirmed, b)
+	flush(t, d, log)
+
+	if len(email.msg) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(email.msg))
+	}
+	if len(email.msg[0].Attachments) != 0 {
+		t.Error("pending booking must not carry an invite")
+	}
+	if len(email.msg[1].Attachments) != 1 || email.msg[1].Attachments[0].Filename != "event.ics" {
+		t.Errorf("expected an invite for the confirmed booking, got %+v", email.msg[1].Attachments)
+	}
+	if !strings.Contains(email.msg[1].HTML, "<h2>Booking Confirmed</h2>") {
+		t.Errorf("expected the HTML layout, got %q", email.msg[1].HTML)
+	}
+}
@@ -0,0 +1,194 @@
+// Package notification tells users about their bookings through email and Telegram.
+//
+// Every notification has a Kind, which names the template its text is rendered from
+// in the owner's locale. Services and expirers queue them through a Dispatcher;
+// time-based ones (expiry warnings, event reminders) are found by the Scheduler.
+// Every delivery is queued in the log, and the Retrier sends it and re-sends it
+// while it fails, so a slow channel never holds up the caller.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// Kind identifies what a notification is about.
+type Kind string
+
+const (
+	KindBookingCreated   Kind = "booking_created"
+	KindBookingConfirmed Kind = "booking_confirmed"
+	KindBookingCancelled Kind = "booking_cancelled"
+	KindBookingExpired   Kind = "booking_expired"
+	KindBookingPromoted  Kind = "booking_promoted"
+	KindExpiryWarning    Kind = "expiry_warning"
+	KindEventReminder    Kind = "event_reminder"
+	KindEventCancelled   Kind = "event_cancelled"
+)
+
+// Message is a channel-independent notification about one booking. It is the data
+// passed to the templates.
+type Message struct {
+	Kind       Kind
+	EventName  string
+	EventDate  time.Time
+	TicketType string
+	Persons    int
+	// ExpiresAt is the payment deadline of a pending booking, zero once it is confirmed.
+	ExpiresAt time.Time
+}
+
+// ForBooking builds the message of the given kind about b.
+func ForBooking(kind Kind, b *booking.Booking) Message {
+	m := Message{
+		Kind:       kind,
+		EventName:  b.EventName,
+		EventDate:  b.EventDate,
+		TicketType: b.TicketTypeName,
+		Persons:    b.Count,
+	}
+	if b.Status == booking.StatusCreated {
+		m.ExpiresAt = b.ExpiredAt
+	}
+	return m
+}
+
+// Sender delivers rendered content to a recipient over one channel.
+type Sender interface {
+	Send(recipient string, c Content) error
+}
+
+// Dispatcher queues booking notifications for the channels the owner opted into
+// in the delivery log.
+type Dispatcher struct {
+	templates *Templates
+	email     Sender
+	tg        Sender
+	log       DeliveryLog
+	// requireVerified holds back emails to addresses their owners have not verified.
+	requireVerified bool
+	// queued wakes the Retrier up when a delivery is queued.
+	queued chan struct{}
+}
+
+// NewDispatcher creates a new Dispatcher. When requireVerified is set, emails are
+// only sent to addresses their owners verified, so typos do not bounce.
+func NewDispatcher(templates *Templates, email, tg Sender, log DeliveryLog, requireVerified bool) *Dispatcher {
+	return &Dispatcher{
+		templates:       templates,
+		email:           email,
+		tg:              tg,
+		log:             log,
+		requireVerified: requireVerified,
+		queued:          make(chan struct{}, 1),
+	}
+}
+
+// Notify renders the notification of the given kind about b in the owner's locale
+// and queues it for every channel. It does not wait for the channels: the Retrier
+// sends the deliveries.
+func (d *Dispatcher) Notify(kind Kind, b *booking.Booking) {
+	m, err := d.templates.Render(b.Locale, string(kind), ForBooking(kind, b))
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot render %s notification", kind)
+		return
+	}
+	if attachesInvite(kind, b) {
+		m.Attachments = append(m.Attachments, CalendarInvite(b, time.Now()))
+	}
+
+	if b.EmailNotification && d.emailVerified(b) {
+		d.deliver(kind, b.ID, ChannelEmail, b.EmailRecepient, m)
+	}
+
+	if b.TelegramNotification {
+		tm := m
+		tm.Buttons = d.buttons(kind, b)
+		d.deliver(kind, b.ID, ChannelTelegram, b.TelegramRecepient, tm)
+	}
+}
+
+// emailVerified reports whether the email recipient of b may be written to. The
+// verification state is loaded together with the booking.
+func (d *Dispatcher) emailVerified(b *booking.Booking) bool {
+	if !d.requireVerified || b.EmailVerified {
+		return true
+	}
+	wbzlog.Logger.Debug().Msgf("skipping email notification of booking %s to unverified address", b.ID)
+	return false
+}
+
+// buttons returns the inline buttons of a Telegram notification: a pending booking
+// can be confirmed or cancelled right from the message, a confirmed one cancelled.
+func (d *Dispatcher) buttons(kind Kind, b *booking.Booking) []Button {
+	switch kind {
+	case KindBookingCreated, KindBookingPromoted, KindExpiryWarning, KindBookingConfirmed, KindEventReminder:
+	default:
+		return nil
+	}
+
+	switch b.Status {
+	case booking.StatusCreated:
+		return d.templates.Buttons(b.Locale, b.ID, ActionConfirm, ActionCancel)
+	case booking.StatusConfirmed:
+		return d.templates.Buttons(b.Locale, b.ID, ActionCancel)
+	}
+	return nil
+}
+
+// deliver queues c for the recipient and wakes the Retrier up.
+func (d *Dispatcher) deliver(kind Kind, bookingID uuid.UUID, ch Channel, recipient string, c Content) {
+	now := time.Now()
+	del := &Delivery{
+		ID:            uuid.New(),
+		BookingID:     bookingID,
+		Kind:          kind,
+		Channel:       ch,
+		Recipient:     recipient,
+		Content:       c,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := d.log.RecordDelivery(context.Background(), del); err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot queue %s %s notification", kind, ch)
+		return
+	}
+
+	select {
+	case d.queued <- struct{}{}:
+	default:
+	}
+}
+
+// send sends c over the channel.
+func (d *Dispatcher) send(ch Channel, recipient string, c Content) error {
+	switch ch {
+	case ChannelEmail:
+		return d.email.Send(recipient, c)
+	case ChannelTelegram:
+		return d.tg.Send(recipient, c)
+	}
+	return Permanent(fmt.Errorf("unknown notification channel %q", ch))
+}
+
+// attachesInvite reports whether the notification carries a calendar invite:
+// only confirmed bookings are certain to take place.
+func attachesInvite(kind Kind, b *booking.Booking) bool {
+	if b.Status != booking.StatusConfirmed {
+		return false
+	}
+	switch kind {
+	case KindBookingConfirmed, KindBookingPromoted, KindEventReminder:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package notification
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"eventbooker/internal/config"
+
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultRetryInterval = 30 * time.Second
+	defaultRetryDelay    = time.Minute
+	defaultMaxRetryDelay = time.Hour
+	defaultMaxAttempts   = 8
+	defaultSendTimeout   = 10 * time.Second
+	defaultRetention     = 7 * 24 * time.Hour
+
+	// deliveryLease hides a claimed delivery from other retriers while it is being sent.
+	// It is stretched to cover a whole batch of slow sends.
+	deliveryLease = 2 * time.Minute
+
+	// pruneInterval is how often finished deliveries past the retention are deleted,
+	// in batches of pruneBatch.
+	pruneInterval = time.Hour
+	pruneBatch    = 1000
+)
+
+// Delivery metrics, served by expvar under "notifications".
+var (
+	metrics         = expvar.NewMap("notifications")
+	metricSent      = new(expvar.Int)
+	metricFailed    = new(expvar.Int)
+	metricAbandoned = new(expvar.Int)
+	metricRetried   = new(expvar.Int)
+	metricPruned    = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("sent", metricSent)
+	metrics.Set("failed", metricFailed)
+	metrics.Set("abandoned", metricAbandoned)
+	metrics.Set("retried", metricRetried)
+	metrics.Set("pruned", metricPruned)
+}
+
+// retryPolicy decides what happens to a delivery after a failed attempt.
+type retryPolicy struct {
+	delay       time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+}
+
+func newRetryPolicy(cfg *config.NotificationConfig) retryPolicy {
+	p := retryPolicy{delay: cfg.RetryDelay, maxDelay: cfg.MaxRetryDelay, maxAttempts: cfg.MaxAttempts}
+	if p.delay <= 0 {
+		p.delay = defaultRetryDelay
+	}
+	if p.maxDelay <= 0 {
+		p.maxDelay = defaultMaxRetryDelay
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultMaxAttempts
+	}
+	return p
+}
+
+// backoff doubles the retry delay with every attempt up to maxDelay.
+func (p retryPolicy) backoff(attempts int) time.Duration {
+	d := p.delay
+	for i := 1; i < attempts && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.maxDelay)
+}
+
+// gaveUp reports whether a delivery that failed with err after the given number of
+// attempts must not be retried.
+func (p retryPolicy) gaveUp(attempts int, err error) bool {
+	return attempts >= p.maxAttempts || isPermanent(err)
+}
+
+// fail records the failed attempt in d and schedules the next one, if any.
+func (p retryPolicy) fail(d *Delivery, err error, now time.Time) {
+	d.LastError = err.Error()
+	d.UpdatedAt = now
+	if p.gaveUp(d.Attempts, err) {
+		d.Status = DeliveryAbandoned
+		d.NextAttemptAt = time.Time{}
+		metricAbandoned.Add(1)
+		return
+	}
+	d.Status = DeliveryFailed
+	d.NextAttemptAt = now.Add(p.backoff(d.Attempts))
+	metricFailed.Add(1)
+}
+
+// Retrier sends queued deliveries as soon as the Dispatcher queues them and re-sends
+// failed ones with an exponential backoff until they succeed or run out of attempts.
+// Sent and abandoned deliveries are deleted once they are older than the retention.
+type Retrier struct {
+	store      DeliveryStore
+	dispatcher *Dispatcher
+	policy     retryPolicy
+	interval   time.Duration
+	batch      int
+	lease      time.Duration
+	retention  time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetrier creates a new Retrier.
+func NewRetrier(store DeliveryStore, dispatcher *Dispatcher, cfg *config.NotificationConfig) *Retrier {
+	r := &Retrier{
+		store:      store,
+		dispatcher: dispatcher,
+		policy:     newRetryPolicy(cfg),
+		interval:   cfg.RetryInterval,
+		batch:      cfg.Batch,
+		retention:  cfg.Retention,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultRetryInterval
+	}
+	if r.retention <= 0 {
+		r.retention = defaultRetention
+	}
+	if r.batch <= 0 {
+		r.batch = defaultScheduleBatch
+	}
+	sendTimeout := cfg.SendTimeout
+	if sendTimeout <= 0 {
+		sendTimeout = defaultSendTimeout
+	}
+	r.lease = max(deliveryLease, time.Duration(r.batch)*sendTimeout)
+	return r
+}
+
+// Start runs the delivery loop in the background until ctx is done or Close is called.
+// The loop wakes up every interval and whenever the Dispatcher queues a delivery.
+func (r *Retrier) Start(ctx context.Context) error {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		var pruned time.Time
+		for {
+			r.RetryOnce(ctx)
+
+			if time.Since(pruned) >= pruneInterval {
+				for ctx.Err() == nil {
+					if r.PruneOnce(ctx) < pruneBatch {
+						break
+					}
+				}
+				pruned = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.dispatcher.queued:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// RetryOnce sends one batch of queued and due failed deliveries and returns how many
+// were claimed.
+func (r *Retrier) RetryOnce(ctx context.Context) int {
+	deliveries, err := r.store.ClaimDeliveries(ctx, r.batch, r.lease)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim notification deliveries")
+		return 0
+	}
+
+	for _, d := range deliveries {
+		if d.Attempts > 1 {
+			metricRetried.Add(1)
+		}
+
+		err = r.dispatcher.send(d.Channel, d.Recipient, d.Content)
+		if err == nil {
+			metricSent.Add(1)
+			if err = r.store.MarkDeliverySent(ctx, d.ID); err != nil {
+				wbzlog.Logger.Error().Err(err).Msgf("failed to mark notification delivery %s as sent", d.ID)
+			}
+			continue
+		}
+
+		wbzlog.Logger.Error().Err(err).Msgf("%s %s notification %s failed, attempt %d", d.Channel, d.Kind, d.ID, d.Attempts)
+		r.policy.fail(d, err, time.Now())
+		if d.Status == DeliveryAbandoned {
+			err = r.store.AbandonDelivery(ctx, d.ID, d.LastError)
+		} else {
+			err = r.store.MarkDeliveryFailed(ctx, d.ID, d.LastError, r.policy.backoff(d.Attempts))
+		}
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("failed to record notification delivery failure %s", d.ID)
+		}
+	}
+
+	return len(deliveries)
+}
+
+// PruneOnce deletes one batch of deliveries sent or abandoned longer than the
+// retention ago and returns how many were deleted.
+func (r *Retrier) PruneOnce(ctx context.Context) int {
+	n, err := r.store.PruneDeliveries(ctx, r.retention, pruneBatch)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune notification deliveries")
+		return 0
+	}
+	metricPruned.Add(int64(n))
+	return n
+}
+
+// Close stops the retry loop and waits for the current batch to finish.
+func (r *Retrier) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	return nil
+}
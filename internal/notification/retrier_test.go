@@ -0,0 +1,201 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+)
+
+type failure struct {
+	reason  string
+	retryIn time.Duration
+}
+
+type fakeDeliveries struct {
+	recorded  []*notification.Delivery
+	due       []*notification.Delivery
+	sent      []uuid.UUID
+	failed    map[uuid.UUID]failure
+	abandoned map[uuid.UUID]string
+	// finished is the number of stored deliveries past the retention, pruneAge the
+	// retention of the last prune.
+	finished int
+	pruneAge time.Duration
+}
+
+func (f *fakeDeliveries) RecordDelivery(ctx context.Context, d *notification.Delivery) error {
+	f.recorded = append(f.recorded, d)
+	if d.Status == notification.DeliveryPending {
+		f.due = append(f.due, d)
+	}
+	return nil
+}
+
+func (f *fakeDeliveries) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*notification.Delivery, error) {
+	due := f.due
+	f.due = nil
+	for _, d := range due {
+		d.Attempts++
+	}
+	return due, nil
+}
+
+func (f *fakeDeliveries) MarkDeliverySent(ctx context.Context, id uuid.UUID) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeDeliveries) MarkDeliveryFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error {
+	if f.failed == nil {
+		f.failed = make(map[uuid.UUID]failure)
+	}
+	f.failed[id] = failure{reason: reason, retryIn: retryIn}
+	return nil
+}
+
+func (f *fakeDeliveries) AbandonDelivery(ctx context.Context, id uuid.UUID, reason string) error {
+	if f.abandoned == nil {
+		f.abandoned = make(map[uuid.UUID]string)
+	}
+	f.abandoned[id] = reason
+	return nil
+}
+
+func (f *fakeDeliveries) PruneDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	n := min(limit, f.finished)
+	f.finished -= n
+	f.pruneAge = olderThan
+	return n, nil
+}
+
+// flush sends the deliveries d queued in log the way the Retrier does in the background.
+func flush(t *testing.T, d *notification.Dispatcher, log *fakeDeliveries) {
+	t.Helper()
+	notification.NewRetrier(log, d, &config.NotificationConfig{}).RetryOnce(context.Background())
+}
+
+func TestDispatcher_Notify_QueuesDeliveries(t *testing.T) {
+	log := &fakeDeliveries{}
+	email, tg := &fakeSender{}, &fakeSender{err: errors.New("telegram down")}
+	d := notification.NewDispatcher(newTemplates(t, ""), email, tg, log, false)
+
+	b := &booking.Booking{
+		ID: uuid.New(), EventName: "Gig", Count: 1,
+		EmailNotification: true, EmailRecepient: "a@example.com",
+		TelegramNotification: true, TelegramRecepient: "42",
+	}
+	d.Notify(notification.KindBookingCancelled, b)
+
+	if len(log.recorded) != 2 {
+		t.Fatalf("expected 2 queued deliveries, got %d", len(log.recorded))
+	}
+	if len(email.msg) != 0 || len(tg.msg) != 0 {
+		t.Fatal("Notify must not wait for the channels")
+	}
+
+	mail, msg := log.recorded[0], log.recorded[1]
+	if mail.Channel != notification.ChannelEmail || mail.Status != notification.DeliveryPending || mail.BookingID != b.ID || mail.Attempts != 0 {
+		t.Errorf("unexpected email delivery %+v", mail)
+	}
+	if msg.Channel != notification.ChannelTelegram || msg.Status != notification.DeliveryPending {
+		t.Errorf("unexpected telegram delivery %+v", msg)
+	}
+	if msg.Content.Text == "" {
+		t.Error("expected the rendered content to be kept for the worker")
+	}
+
+	flush(t, d, log)
+
+	if len(log.sent) != 1 || log.sent[0] != mail.ID {
+		t.Errorf("expected %s to be marked sent, got %v", mail.ID, log.sent)
+	}
+	if f, found := log.failed[msg.ID]; !found || f.reason != "telegram down" || f.retryIn != time.Minute {
+		t.Errorf("expected a retry of the first attempt in 1m, got %+v", f)
+	}
+}
+
+func TestDispatcher_Notify_AbandonsPermanentFailures(t *testing.T) {
+	log := &fakeDeliveries{}
+	tg := &fakeSender{err: notification.Permanent(errors.New("invalid chat ID"))}
+	d := notification.NewDispatcher(newTemplates(t, ""), &fakeSender{}, tg, log, false)
+
+	d.Notify(notification.KindBookingCancelled, &booking.Booking{EventName: "Gig", TelegramNotification: true, TelegramRecepient: "me"})
+	flush(t, d, log)
+
+	if len(log.recorded) != 1 {
+		t.Fatalf("expected 1 queued delivery, got %d", len(log.recorded))
+	}
+	if _, found := log.abandoned[log.recorded[0].ID]; !found {
+		t.Errorf("expected the delivery to be abandoned after the first attempt, got %+v", log.recorded[0])
+	}
+}
+
+func TestRetrier_RetryOnce(t *testing.T) {
+	content := notification.Content{Subject: "Booking Cancelled", Text: "Cancelled"}
+	ok := &notification.Delivery{ID: uuid.New(), Channel: notification.ChannelEmail, Recipient: "ok@example.com", Content: content, Attempts: 1}
+	retry := &notification.Delivery{ID: uuid.New(), Channel: notification.ChannelTelegram, Recipient: "42", Content: content, Attempts: 2}
+	last := &notification.Delivery{ID: uuid.New(), Channel: notification.ChannelTelegram, Recipient: "43", Content: content, Attempts: 3}
+
+	store := &fakeDeliveries{due: []*notification.Delivery{ok, retry, last}}
+	email, tg := &fakeSender{}, &fakeSender{err: errors.New("telegram down")}
+	cfg := &config.NotificationConfig{RetryDelay: time.Minute, MaxRetryDelay: 5 * time.Minute, MaxAttempts: 4}
+	r := notification.NewRetrier(store, notification.NewDispatcher(newTemplates(t, ""), email, tg, store, false), cfg)
+
+	if n := r.RetryOnce(context.Background()); n != 3 {
+		t.Fatalf("expected 3 claimed deliveries, got %d", n)
+	}
+
+	if len(email.msg) != 1 || email.msg[0].Subject != "Booking Cancelled" {
+		t.Errorf("expected the stored content to be re-sent, got %+v", email.msg)
+	}
+	if len(store.sent) != 1 || store.sent[0] != ok.ID {
+		t.Errorf("expected %s to be marked sent, got %v", ok.ID, store.sent)
+	}
+	// The third attempt of retry failed: the delay doubled twice.
+	if f, found := store.failed[retry.ID]; !found || f.retryIn != 4*time.Minute || f.reason != "telegram down" {
+		t.Errorf("expected a retry in 4m, got %+v", f)
+	}
+	if _, found := store.abandoned[last.ID]; !found {
+		t.Errorf("expected %s to be abandoned after the last attempt", last.ID)
+	}
+}
+
+func TestRetrier_PruneOnce_DeletesInBatches(t *testing.T) {
+	store := &fakeDeliveries{finished: 1500}
+	r := notification.NewRetrier(store, notification.NewDispatcher(newTemplates(t, ""), &fakeSender{}, &fakeSender{}, store, false),
+		&config.NotificationConfig{Retention: 72 * time.Hour})
+
+	if n := r.PruneOnce(context.Background()); n != 1000 {
+		t.Errorf("expected a full batch of 1000, got %d", n)
+	}
+	if n := r.PruneOnce(context.Background()); n != 500 {
+		t.Errorf("expected the remaining 500, got %d", n)
+	}
+	if store.pruneAge != 72*time.Hour {
+		t.Errorf("expected the configured retention, got %s", store.pruneAge)
+	}
+}
+
+func TestDelivery_Requeue(t *testing.T) {
+	now := time.Now()
+
+	d := &notification.Delivery{Status: notification.DeliveryAbandoned}
+	if err := d.Requeue(now); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != notification.DeliveryFailed || !d.NextAttemptAt.Equal(now) {
+		t.Errorf("expected an immediate retry, got %s at %s", d.Status, d.NextAttemptAt)
+	}
+
+	sent := &notification.Delivery{Status: notification.DeliverySent}
+	if err := sent.Requeue(now); !errors.Is(err, notification.ErrDeliverySent) {
+		t.Errorf("expected ErrDeliverySent, got %v", err)
+	}
+}
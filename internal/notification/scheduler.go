@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultScheduleInterval = time.Minute
+	defaultScheduleBatch    = 100
+	defaultExpiryWarning    = 10 * time.Minute
+	defaultEventReminder    = 24 * time.Hour
+)
+
+// Store defines the repository operations needed by Scheduler. Each claim marks the
+// returned bookings as notified, so a booking is warned and reminded at most once.
+type Store interface {
+	ClaimExpiryWarnings(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error)
+	ClaimEventReminders(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error)
+}
+
+// Scheduler periodically sends the time-based notifications: a warning before a
+// pending booking expires and a reminder before the event of a confirmed one.
+type Scheduler struct {
+	store         Store
+	dispatcher    *Dispatcher
+	interval      time.Duration
+	batch         int
+	expiryWarning time.Duration
+	eventReminder time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(store Store, dispatcher *Dispatcher, cfg *config.NotificationConfig) *Scheduler {
+	s := &Scheduler{
+		store:         store,
+		dispatcher:    dispatcher,
+		interval:      cfg.Interval,
+		batch:         cfg.Batch,
+		expiryWarning: cfg.ExpiryWarning,
+		eventReminder: cfg.EventReminder,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultScheduleInterval
+	}
+	if s.batch <= 0 {
+		s.batch = defaultScheduleBatch
+	}
+	if s.expiryWarning <= 0 {
+		s.expiryWarning = defaultExpiryWarning
+	}
+	if s.eventReminder <= 0 {
+		s.eventReminder = defaultEventReminder
+	}
+	return s
+}
+
+// Start runs the polling loop in the background until ctx is done or Close is called.
+func (s *Scheduler) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// RunOnce sends up to one batch of each scheduled kind and returns how many
+// notifications were sent.
+func (s *Scheduler) RunOnce(ctx context.Context) int {
+	warnings, err := s.store.ClaimExpiryWarnings(ctx, s.expiryWarning, s.batch)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim expiry warnings")
+	}
+	for _, b := range warnings {
+		s.dispatcher.Notify(KindExpiryWarning, b)
+	}
+
+	reminders, err := s.store.ClaimEventReminders(ctx, s.eventReminder, s.batch)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim event reminders")
+	}
+	for _, b := range reminders {
+		s.dispatcher.Notify(KindEventReminder, b)
+	}
+
+	return len(warnings) + len(reminders)
+}
+
+// Close stops the polling loop and waits for the current run to finish.
+func (s *Scheduler) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
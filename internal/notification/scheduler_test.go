@@ -0,0 +1,136 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+)
+
+type fakeSender struct {
+	to  []string
+	msg []notification.Content
+	err error
+}
+
+func (f *fakeSender) Send(recipient string, c notification.Content) error {
+	f.to = append(f.to, recipient)
+	f.msg = append(f.msg, c)
+	return f.err
+}
+
+type fakeStore struct {
+	warnings, reminders  []*booking.Booking
+	warnLead, remindLead time.Duration
+	err                  error
+}
+
+func (f *fakeStore) ClaimExpiryWarnings(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error) {
+	f.warnLead = lead
+	return f.warnings, f.err
+}
+
+func (f *fakeStore) ClaimEventReminders(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error) {
+	f.remindLead = lead
+	return f.reminders, f.err
+}
+
+func TestDispatcher_Notify_UsesOptedInChannels(t *testing.T) {
+	email, tg := &fakeSender{}, &fakeSender{err: errors.New("telegram down")}
+	log := &fakeDeliveries{}
+	d := notification.NewDispatcher(newTemplates(t, ""), email, tg, log, false)
+
+	d.Notify(notification.KindBookingCancelled, &booking.Booking{
+		EventName: "Gig", Count: 2, EmailNotification: true, EmailRecepient: "a@example.com", TelegramRecepient: "42",
+	})
+	d.Notify(notification.KindBookingCancelled, &booking.Booking{
+		EventName: "Gig", Count: 1, TelegramNotification: true, TelegramRecepient: "42",
+	})
+	flush(t, d, log)
+
+	if len(email.to) != 1 || email.to[0] != "a@example.com" {
+		t.Errorf("expected one email to a@example.com, got %v", email.to)
+	}
+	if len(tg.to) != 1 || tg.to[0] != "42" {
+		t.Errorf("expected one telegram message to 42, got %v", tg.to)
+	}
+}
+
+func TestDispatcher_Notify_SkipsUnverifiedEmail(t *testing.T) {
+	email, tg := &fakeSender{}, &fakeSender{}
+	log := &fakeDeliveries{}
+	d := notification.NewDispatcher(newTemplates(t, ""), email, tg, log, true)
+
+	for _, b := range []*booking.Booking{
+		{EmailNotification: true, EmailRecepient: "alice@example.com", EmailVerified: true},
+		{EmailNotification: true, EmailRecepient: "bob@exmaple.com", TelegramNotification: true, TelegramRecepient: "42"},
+	} {
+		b.EventName, b.Count = "Gig", 1
+		d.Notify(notification.KindBookingCancelled, b)
+	}
+	flush(t, d, log)
+
+	if len(email.to) != 1 || email.to[0] != "alice@example.com" {
+		t.Errorf("expected an email to the verified address only, got %v", email.to)
+	}
+	if len(tg.to) != 1 {
+		t.Errorf("expected Telegram to be unaffected, got %v", tg.to)
+	}
+
+	// Without the policy unverified addresses are written to as well.
+	email = &fakeSender{}
+	d = notification.NewDispatcher(newTemplates(t, ""), email, tg, log, false)
+	d.Notify(notification.KindBookingCancelled, &booking.Booking{EventName: "Gig", Count: 1, EmailNotification: true, EmailRecepient: "bob@exmaple.com"})
+	flush(t, d, log)
+	if len(email.to) != 1 {
+		t.Errorf("expected the email to be sent when verification is not required, got %v", email.to)
+	}
+}
+
+func TestForBooking_DeadlineOnlyWhilePending(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+
+	pending := notification.ForBooking(notification.KindBookingCreated, &booking.Booking{Status: booking.StatusCreated, ExpiredAt: deadline})
+	if !pending.ExpiresAt.Equal(deadline) {
+		t.Errorf("expected payment deadline %s, got %s", deadline, pending.ExpiresAt)
+	}
+
+	confirmed := notification.ForBooking(notification.KindBookingPromoted, &booking.Booking{Status: booking.StatusConfirmed, ExpiredAt: deadline})
+	if !confirmed.ExpiresAt.IsZero() {
+		t.Errorf("expected no deadline for a confirmed booking, got %s", confirmed.ExpiresAt)
+	}
+}
+
+func TestScheduler_RunOnce_SendsWarningsAndReminders(t *testing.T) {
+	store := &fakeStore{
+		warnings:  []*booking.Booking{{Status: booking.StatusCreated, EmailNotification: true, EmailRecepient: "pay@example.com"}},
+		reminders: []*booking.Booking{{Status: booking.StatusConfirmed, EmailNotification: true, EmailRecepient: "go@example.com"}},
+	}
+	email, log := &fakeSender{}, &fakeDeliveries{}
+	d := notification.NewDispatcher(newTemplates(t, ""), email, &fakeSender{}, log, false)
+	s := notification.NewScheduler(store, d, &config.NotificationConfig{})
+
+	if n := s.RunOnce(context.Background()); n != 2 {
+		t.Fatalf("expected 2 notifications, got %d", n)
+	}
+	flush(t, d, log)
+	if store.warnLead != 10*time.Minute || store.remindLead != 24*time.Hour {
+		t.Errorf("expected default leads 10m and 24h, got %s and %s", store.warnLead, store.remindLead)
+	}
+	if len(email.msg) != 2 || email.msg[0].Subject != "Booking Expires Soon" || email.msg[1].Subject != "Event Reminder" {
+		t.Errorf("unexpected messages %+v", email.msg)
+	}
+}
+
+func TestScheduler_RunOnce_StoreError(t *testing.T) {
+	store := &fakeStore{err: errors.New("db down")}
+	s := notification.NewScheduler(store, notification.NewDispatcher(newTemplates(t, ""), &fakeSender{}, &fakeSender{}, &fakeDeliveries{}, false), &config.NotificationConfig{})
+
+	if n := s.RunOnce(context.Background()); n != 0 {
+		t.Fatalf("expected nothing sent, got %d", n)
+	}
+}
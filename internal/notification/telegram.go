@@ -1,72 +1,104 @@
 package notification
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"strconv"
-
-	"eventbooker/internal/config"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
-// TelegramSender sends cancellation notifications via Telegram.
+// errTelegramDisabled is returned when the bot could not be created at startup.
+var errTelegramDisabled = Permanent(errors.New("telegram bot is not configured"))
+
+// Actions of the inline buttons under booking messages, handled by the bot.
+const (
+	ActionConfirm = "confirm"
+	ActionCancel  = "cancel"
+)
+
+// buttonsTemplate defines one block per action with the label of its button.
+const buttonsTemplate = "buttons"
+
+// Button is an inline Telegram button. Data is sent back to the bot when it is pressed.
+type Button struct {
+	Text string `json:"text"`
+	Data string `json:"data"`
+}
+
+// CallbackData encodes an action on a booking as button data.
+func CallbackData(action string, bookingID uuid.UUID) string {
+	return action + ":" + bookingID.String()
+}
+
+// ParseCallbackData decodes button data made by CallbackData.
+func ParseCallbackData(data string) (action string, bookingID uuid.UUID, ok bool) {
+	action, id, _ := strings.Cut(data, ":")
+	if action != ActionConfirm && action != ActionCancel {
+		return "", uuid.Nil, false
+	}
+	bookingID, err := uuid.Parse(id)
+	if err != nil {
+		return "", uuid.Nil, false
+	}
+	return action, bookingID, true
+}
+
+// Buttons returns the buttons for the actions on a booking, labelled in the locale.
+func (t *Templates) Buttons(locale string, bookingID uuid.UUID, actions ...string) []Button {
+	out := make([]Button, 0, len(actions))
+	for _, action := range actions {
+		label, err := t.renderBlock(locale, buttonsTemplate, action, nil)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("cannot render %s button label", action)
+			label = action
+		}
+		out = append(out, Button{Text: label, Data: CallbackData(action, bookingID)})
+	}
+	return out
+}
+
+// TelegramSender sends booking notifications via Telegram.
 type TelegramSender struct {
 	bot *tgbotapi.BotAPI
 }
 
-// NewTelegramSender creates a new TelegramSender.
-func NewTelegramSender(cfg *config.AppConfig) *TelegramSender {
-	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.BotToken)
-	if err != nil {
-		wbzlog.Logger.Error().Err(err).Msg("failed to create Telegram bot")
+// NewTelegramSender creates a new TelegramSender. It returns nil when the bot could
+// not be created, and every Send then fails permanently.
+func NewTelegramSender(bot *tgbotapi.BotAPI) *TelegramSender {
+	if bot == nil {
 		return nil
 	}
-
-	ts := &TelegramSender{bot: bot}
-	go ts.listenForStartCommand()
-	return ts
+	return &TelegramSender{bot: bot}
 }
 
-// Send sends a booking cancellation message via Telegram.
-func (t *TelegramSender) Send(tg, eventName string, persons int) error {
+// Send sends the content to the given Telegram chat.
+func (t *TelegramSender) Send(tg string, c Content) error {
+	if t == nil {
+		return errTelegramDisabled
+	}
+
 	chatID, err := strconv.Atoi(tg)
 	if err != nil {
-		return fmt.Errorf("invalid chat ID: %w", err)
+		return Permanent(fmt.Errorf("invalid chat ID: %w", err))
 	}
 
-	msg := tgbotapi.NewMessage(int64(chatID),
-		fmt.Sprintf("Your booking on %d persons on event: %s just cancelled", persons, eventName))
+	msg := tgbotapi.NewMessage(int64(chatID), c.Text)
+	if len(c.Buttons) > 0 {
+		msg.ReplyMarkup = InlineKeyboard(c.Buttons)
+	}
 	_, err = t.bot.Send(msg)
 	return err
 }
 
-func (t *TelegramSender) listenForStartCommand() {
-	log.Println("Telegram listener started...")
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := t.bot.GetUpdatesChan(u)
-
-	for update := range updates {
-		if update.Message == nil {
-			continue
-		}
-
-		if update.Message.Text == "/start" {
-			chatID := update.Message.Chat.ID
-			username := update.Message.From.UserName
-
-			log.Printf("[TG] User %s started bot, chat_id=%d", username, chatID)
-
-			msg := tgbotapi.NewMessage(chatID,
-				fmt.Sprintf("👋 Привет, %s!\n\nТвой chat_id: `%d`\nОтправь его в приложение, чтобы получать уведомления.",
-					username, chatID))
-
-			if _, err := t.bot.Send(msg); err != nil {
-				wbzlog.Logger.Error().Err(err).Msg("failed to send Telegram message")
-			}
-		}
+// InlineKeyboard lays the buttons out in a single row.
+func InlineKeyboard(buttons []Button) tgbotapi.InlineKeyboardMarkup {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, b := range buttons {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(b.Text, b.Data))
 	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
 }
@@ -0,0 +1,63 @@
+package notification_test
+
+import (
+	"testing"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+)
+
+func TestCallbackData_RoundTrip(t *testing.T) {
+	id := uuid.New()
+
+	action, got, ok := notification.ParseCallbackData(notification.CallbackData(notification.ActionConfirm, id))
+	if !ok || action != notification.ActionConfirm || got != id {
+		t.Fatalf("unexpected parse result %q %s %v", action, got, ok)
+	}
+
+	for _, data := range []string{"", "confirm", "delete:" + id.String(), "cancel:not-a-uuid"} {
+		if _, _, ok := notification.ParseCallbackData(data); ok {
+			t.Errorf("expected %q to be rejected", data)
+		}
+	}
+}
+
+func TestDispatcher_TelegramButtons(t *testing.T) {
+	email, tg := &fakeSender{}, &fakeSender{}
+	templates := notification.NewTemplates(&config.NotificationConfig{})
+	log := &fakeDeliveries{}
+	d := notification.NewDispatcher(templates, email, tg, log, false)
+
+	pending := &booking.Booking{
+		ID: uuid.New(), EventName: "Gig", Status: booking.StatusCreated, Locale: "ru",
+		EmailNotification: true, EmailRecepient: "a@example.com",
+		TelegramNotification: true, TelegramRecepient: "42",
+	}
+	d.Notify(notification.KindBookingCreated, pending)
+	flush(t, d, log)
+
+	buttons := tg.msg[0].Buttons
+	if len(buttons) != 2 || buttons[0].Text != "✅ Подтвердить" || buttons[1].Data != "cancel:"+pending.ID.String() {
+		t.Errorf("expected localized confirm and cancel buttons, got %+v", buttons)
+	}
+	if len(email.msg[0].Buttons) != 0 {
+		t.Error("emails must not carry buttons")
+	}
+
+	confirmed := *pending
+	confirmed.Status = booking.StatusConfirmed
+	d.Notify(notification.KindEventReminder, &confirmed)
+	flush(t, d, log)
+	if buttons := tg.msg[1].Buttons; len(buttons) != 1 || buttons[0].Data != "cancel:"+pending.ID.String() {
+		t.Errorf("expected only a cancel button for a confirmed booking, got %+v", buttons)
+	}
+
+	d.Notify(notification.KindBookingExpired, pending)
+	flush(t, d, log)
+	if len(tg.msg[2].Buttons) != 0 {
+		t.Errorf("expected no buttons on an expiry notice, got %+v", tg.msg[2].Buttons)
+	}
+}
@@ -0,0 +1,319 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"eventbooker/internal/config"
+
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	// fallbackLocale is tried last, its templates are always embedded.
+	fallbackLocale = "en"
+
+	textExt = ".tmpl"
+	htmlExt = ".html.tmpl"
+
+	// layoutTemplate wraps the text of kinds without their own HTML template.
+	layoutTemplate = "layout"
+)
+
+var localeRe = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// NormalizeLocale canonicalizes a locale such as "en_us" to "en-US". It returns an
+// empty string when s is not a language code with an optional region.
+func NormalizeLocale(s string) string {
+	lang, region, _ := strings.Cut(strings.ReplaceAll(strings.TrimSpace(s), "_", "-"), "-")
+	loc := strings.ToLower(lang)
+	if region != "" {
+		loc += "-" + strings.ToUpper(region)
+	}
+	if !localeRe.MatchString(loc) {
+		return ""
+	}
+	return loc
+}
+
+// builtinTemplates are the default texts, used when the template directory has no override.
+//
+//go:embed templates
+var builtinTemplates embed.FS
+
+// Content is a rendered notification ready to be sent. It is stored as JSON in the
+// delivery log, so failed deliveries are retried with the same text.
+type Content struct {
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text"`
+	// HTML is the alternative HTML body of an email, empty when there is no template for it.
+	HTML        string       `json:"html,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Buttons are shown under a Telegram message; emails ignore them.
+	Buttons []Button `json:"buttons,omitempty"`
+}
+
+// Attachment is a file sent along with an email.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// HTMLData is passed to HTML templates: the rendered subject and text together with
+// the data the text was rendered from.
+type HTMLData struct {
+	Locale  string
+	Subject string
+	Text    string
+	Data    any
+}
+
+// Paragraphs splits the text into its non-empty lines.
+func (d HTMLData) Paragraphs() []string {
+	var out []string
+	for _, line := range strings.Split(d.Text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// Templates renders notifications from per-locale templates. <locale>/<name>.tmpl is a
+// text/template defining a "text" block and, for email, a "subject" block. The optional
+// <locale>/<name>.html.tmpl, or else <locale>/layout.html.tmpl, is an html/template
+// defining an "html" block for the HTML part of emails.
+// Files in the configured directory override the embedded ones and are re-read when
+// they change, so the copy can be edited without a restart.
+type Templates struct {
+	dir           string
+	defaultLocale string
+
+	mu    sync.Mutex
+	cache map[string]cachedTemplate
+}
+
+// executor is implemented by both text and HTML templates.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+	has(block string) bool
+}
+
+type textTemplate struct{ *texttemplate.Template }
+
+func (t textTemplate) has(block string) bool { return t.Lookup(block) != nil }
+
+type htmlTemplate struct{ *htmltemplate.Template }
+
+func (t htmlTemplate) has(block string) bool { return t.Lookup(block) != nil }
+
+type parseFunc func(name, src string) (executor, error)
+
+func parseText(name, src string) (executor, error) {
+	t, err := texttemplate.New(name).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return textTemplate{t}, nil
+}
+
+func parseHTML(name, src string) (executor, error) {
+	t, err := htmltemplate.New(name).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return htmlTemplate{t}, nil
+}
+
+type cachedTemplate struct {
+	modTime time.Time
+	tmpl    executor
+}
+
+// NewTemplates creates a new Templates.
+func NewTemplates(cfg *config.NotificationConfig) *Templates {
+	t := &Templates{
+		dir:           cfg.TemplatesDir,
+		defaultLocale: cfg.DefaultLocale,
+		cache:         make(map[string]cachedTemplate),
+	}
+	if t.defaultLocale == "" {
+		t.defaultLocale = fallbackLocale
+	}
+	return t
+}
+
+// Render executes the named template for the best matching locale: the locale
+// itself, its base language, the default locale and finally English.
+func (t *Templates) Render(locale, name string, data any) (Content, error) {
+	candidates := t.candidates(locale)
+
+	loc, tmpl := t.find(candidates, name+textExt, parseText)
+	if tmpl == nil {
+		return Content{}, fmt.Errorf("no template %q for locale %q", name, locale)
+	}
+
+	var (
+		c   Content
+		err error
+	)
+	if c.Text, err = execute(tmpl, "text", data); err != nil {
+		return Content{}, fmt.Errorf("render %s/%s: %w", loc, name, err)
+	}
+	if tmpl.has("subject") {
+		if c.Subject, err = execute(tmpl, "subject", data); err != nil {
+			return Content{}, fmt.Errorf("render %s/%s: %w", loc, name, err)
+		}
+	}
+
+	htmlLoc, html := t.find(candidates, name+htmlExt, parseHTML)
+	if html == nil {
+		htmlLoc, html = t.find(candidates, layoutTemplate+htmlExt, parseHTML)
+	}
+	if html != nil {
+		hd := HTMLData{Locale: loc, Subject: c.Subject, Text: c.Text, Data: data}
+		if c.HTML, err = execute(html, "html", hd); err != nil {
+			return Content{}, fmt.Errorf("render %s/%s html: %w", htmlLoc, name, err)
+		}
+	}
+
+	return c, nil
+}
+
+// renderBlock executes one block of the named text template for the best matching locale.
+func (t *Templates) renderBlock(locale, name, block string, data any) (string, error) {
+	loc, tmpl := t.find(t.candidates(locale), name+textExt, parseText)
+	if tmpl == nil {
+		return "", fmt.Errorf("no template %q for locale %q", name, locale)
+	}
+
+	out, err := execute(tmpl, block, data)
+	if err != nil {
+		return "", fmt.Errorf("render %s/%s: %w", loc, name, err)
+	}
+	return out, nil
+}
+
+// candidates lists the locales to try for locale, most specific first.
+func (t *Templates) candidates(locale string) []string {
+	var out []string
+	add := func(loc string) {
+		if loc = NormalizeLocale(loc); loc == "" {
+			return
+		}
+		for _, o := range out {
+			if o == loc {
+				return
+			}
+		}
+		out = append(out, loc)
+	}
+
+	for _, loc := range []string{locale, t.defaultLocale, fallbackLocale} {
+		add(loc)
+		if base, _, ok := strings.Cut(loc, "-"); ok {
+			add(base)
+		}
+	}
+	return out
+}
+
+// find returns the first of candidates that has the file, with its template.
+func (t *Templates) find(candidates []string, file string, parse parseFunc) (string, executor) {
+	for _, loc := range candidates {
+		if tmpl := t.lookup(loc, file, parse); tmpl != nil {
+			return loc, tmpl
+		}
+	}
+	return "", nil
+}
+
+// lookup returns the override from the template directory if there is a valid one,
+// the embedded template otherwise, or nil when neither exists.
+func (t *Templates) lookup(locale, file string, parse parseFunc) executor {
+	rel := filepath.Join(locale, file)
+
+	if t.dir != "" {
+		tmpl, err := t.loadOverride(filepath.Join(t.dir, rel), parse)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("cannot load notification template %s, using the built-in one", rel)
+		}
+		if tmpl != nil {
+			return tmpl
+		}
+	}
+
+	return t.loadBuiltin(locale, file, parse)
+}
+
+func (t *Templates) loadOverride(path string, parse parseFunc) (executor, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.cache[path]; ok && c.modTime.Equal(info.ModTime()) {
+		return c.tmpl, nil
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parse(filepath.Base(path), string(src))
+	if err != nil {
+		return nil, err
+	}
+	t.cache[path] = cachedTemplate{modTime: info.ModTime(), tmpl: tmpl}
+	return tmpl, nil
+}
+
+func (t *Templates) loadBuiltin(locale, file string, parse parseFunc) executor {
+	// embed.FS always uses forward slashes.
+	path := "templates/" + locale + "/" + file
+	key := "builtin:" + path
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.cache[key]; ok {
+		return c.tmpl
+	}
+
+	// A missing built-in template is cached as nil.
+	var tmpl executor
+	if src, err := fs.ReadFile(builtinTemplates, path); err == nil {
+		if tmpl, err = parse(file, string(src)); err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("cannot parse built-in notification template %s", path)
+		}
+	}
+	t.cache[key] = cachedTemplate{tmpl: tmpl}
+	return tmpl
+}
+
+func execute(tmpl executor, block string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, block, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,101 @@
+package notification_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/notification"
+)
+
+func newTemplates(t *testing.T, dir string) *notification.Templates {
+	t.Helper()
+	return notification.NewTemplates(&config.NotificationConfig{TemplatesDir: dir, DefaultLocale: "en"})
+}
+
+func writeTemplate(t *testing.T, dir, locale, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, locale, name+".tmpl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	cases := map[string]string{
+		"ru":              "ru",
+		"EN":              "en",
+		"en_us":           "en-US",
+		"pt-br":           "pt-BR",
+		"":                "",
+		"../en":           "",
+		"en-US-x-private": "",
+	}
+	for in, want := range cases {
+		if got := notification.NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTemplates_Render_Locales(t *testing.T) {
+	tmpl := newTemplates(t, "")
+	m := notification.Message{EventName: "Gig", Persons: 2, ExpiresAt: time.Date(2030, 1, 2, 15, 4, 0, 0, time.UTC)}
+
+	ru, err := tmpl.Render("ru-RU", string(notification.KindBookingCreated), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ru.Subject != "Бронь создана" || !strings.Contains(ru.Text, "02.01.2030 15:04") {
+		t.Errorf("expected the Russian template, got %+v", ru)
+	}
+
+	fallback, err := tmpl.Render("de", string(notification.KindBookingCreated), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback.Subject != "Booking Created" {
+		t.Errorf("expected the English fallback, got %+v", fallback)
+	}
+
+	if _, err = tmpl.Render("en", "unknown_kind", m); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestTemplates_Render_Override(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTemplate(t, dir, "en", "booking_cancelled", `{{define "subject"}}Bye{{end}}{{define "text"}}{{.EventName}} is off{{end}}`)
+	tmpl := newTemplates(t, dir)
+
+	c, err := tmpl.Render("", string(notification.KindBookingCancelled), notification.Message{EventName: "Gig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "Bye" || c.Text != "Gig is off" {
+		t.Errorf("expected the override, got %+v", c)
+	}
+
+	// Edited copy is picked up without a restart.
+	writeTemplate(t, dir, "en", "booking_cancelled", `{{define "subject"}}Sorry{{end}}{{define "text"}}{{.EventName}} is cancelled{{end}}`)
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ = tmpl.Render("", string(notification.KindBookingCancelled), notification.Message{EventName: "Gig"}); c.Subject != "Sorry" {
+		t.Errorf("expected the edited override, got %+v", c)
+	}
+
+	// A broken override falls back to the built-in template.
+	writeTemplate(t, dir, "en", "booking_expired", `{{define "text"}}{{.EventName`)
+	if c, err = tmpl.Render("", string(notification.KindBookingExpired), notification.Message{EventName: "Gig"}); err != nil || c.Subject != "Booking Expired" {
+		t.Errorf("expected the built-in template, got %+v, %v", c, err)
+	}
+}
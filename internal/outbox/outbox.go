@@ -0,0 +1,45 @@
+// Package outbox implements the transactional outbox: messages are stored in the
+// same transaction as the state change that produced them and a Relay delivers
+// them afterwards, so a broker outage cannot lose them.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TopicBookingExpiry carries a pending booking whose expiry must be scheduled.
+const TopicBookingExpiry = "booking.expiry"
+
+// Message is a stored outbox row.
+type Message struct {
+	ID        uuid.UUID
+	Topic     string
+	Payload   []byte
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// New creates a Message with v encoded as JSON.
+func New(topic string, v any) (*Message, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:        uuid.New(),
+		Topic:     topic,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Handler delivers the payload of a message of one topic.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Discard is a Handler that drops messages, for topics nobody consumes in a deployment.
+func Discard(context.Context, []byte) error { return nil }
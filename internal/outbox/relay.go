@@ -0,0 +1,215 @@
+package outbox
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"eventbooker/internal/config"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultInterval      = time.Second
+	defaultBatch         = 100
+	defaultLease         = 30 * time.Second
+	defaultRetryDelay    = time.Second
+	defaultMaxRetryDelay = 5 * time.Minute
+	defaultRetention     = 7 * 24 * time.Hour
+
+	// pruneInterval is how often sent messages past the retention are deleted, in
+	// batches of pruneBatch.
+	pruneInterval = time.Hour
+	pruneBatch    = 1000
+)
+
+// Relay metrics, served by expvar under "outbox".
+var (
+	metrics          = expvar.NewMap("outbox")
+	metricPublished  = new(expvar.Int)
+	metricFailed     = new(expvar.Int)
+	metricPruned     = new(expvar.Int)
+	metricLagSeconds = new(expvar.Float)
+)
+
+func init() {
+	metrics.Set("published", metricPublished)
+	metrics.Set("failed", metricFailed)
+	metrics.Set("pruned", metricPruned)
+	metrics.Set("lag_seconds", metricLagSeconds)
+}
+
+// Store defines the repository operations needed by Relay.
+type Store interface {
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*Message, error)
+	MarkOutboxSent(ctx context.Context, id uuid.UUID) error
+	MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error
+	PruneOutbox(ctx context.Context, olderThan time.Duration, limit int) (int, error)
+}
+
+// Relay periodically claims pending outbox messages and hands them to the handler
+// of their topic. A message is claimed for a lease, so a relay that dies mid-batch
+// only delays it; delivery is at least once. Sent messages are deleted once they
+// are older than the retention.
+type Relay struct {
+	store         Store
+	handlers      map[string]Handler
+	interval      time.Duration
+	batch         int
+	lease         time.Duration
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	retention     time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay creates a new Relay. Register handlers with Handle before Start.
+func NewRelay(store Store, cfg *config.OutboxConfig) *Relay {
+	r := &Relay{
+		store:         store,
+		handlers:      make(map[string]Handler),
+		interval:      cfg.Interval,
+		batch:         cfg.Batch,
+		lease:         cfg.Lease,
+		retryDelay:    cfg.RetryDelay,
+		maxRetryDelay: cfg.MaxRetryDelay,
+		retention:     cfg.Retention,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultInterval
+	}
+	if r.batch <= 0 {
+		r.batch = defaultBatch
+	}
+	if r.lease <= 0 {
+		r.lease = defaultLease
+	}
+	if r.retryDelay <= 0 {
+		r.retryDelay = defaultRetryDelay
+	}
+	if r.maxRetryDelay <= 0 {
+		r.maxRetryDelay = defaultMaxRetryDelay
+	}
+	if r.retention <= 0 {
+		r.retention = defaultRetention
+	}
+	return r
+}
+
+// Handle registers the handler of a topic.
+func (r *Relay) Handle(topic string, h Handler) {
+	r.handlers[topic] = h
+}
+
+// Start runs the relay loop in the background until ctx is done or Close is called.
+func (r *Relay) Start(ctx context.Context) error {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		var pruned time.Time
+		for {
+			// A full batch means more messages are likely waiting.
+			for ctx.Err() == nil {
+				if r.RelayOnce(ctx) < r.batch {
+					break
+				}
+			}
+
+			if time.Since(pruned) >= pruneInterval {
+				for ctx.Err() == nil {
+					if r.PruneOnce(ctx) < pruneBatch {
+						break
+					}
+				}
+				pruned = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// RelayOnce delivers one batch of pending messages and returns how many were claimed.
+func (r *Relay) RelayOnce(ctx context.Context) int {
+	msgs, err := r.store.ClaimOutbox(ctx, r.batch, r.lease)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim outbox messages")
+		return 0
+	}
+
+	for _, m := range msgs {
+		if err = r.deliver(ctx, m); err != nil {
+			metricFailed.Add(1)
+			retryIn := r.backoff(m.Attempts)
+			wbzlog.Logger.Error().Err(err).Msgf("outbox message %s (%s) failed, attempt %d, retry in %s", m.ID, m.Topic, m.Attempts, retryIn)
+			if err = r.store.MarkOutboxFailed(ctx, m.ID, err.Error(), retryIn); err != nil {
+				wbzlog.Logger.Error().Err(err).Msgf("failed to record outbox failure %s", m.ID)
+			}
+			continue
+		}
+
+		metricPublished.Add(1)
+		metricLagSeconds.Set(time.Since(m.CreatedAt).Seconds())
+		if err = r.store.MarkOutboxSent(ctx, m.ID); err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("failed to mark outbox message %s as sent", m.ID)
+		}
+	}
+
+	return len(msgs)
+}
+
+// PruneOnce deletes one batch of messages sent longer than the retention ago and
+// returns how many were deleted.
+func (r *Relay) PruneOnce(ctx context.Context) int {
+	n, err := r.store.PruneOutbox(ctx, r.retention, pruneBatch)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune outbox messages")
+		return 0
+	}
+	metricPruned.Add(int64(n))
+	return n
+}
+
+func (r *Relay) deliver(ctx context.Context, m *Message) error {
+	h, ok := r.handlers[m.Topic]
+	if !ok {
+		return fmt.Errorf("no handler for outbox topic %q", m.Topic)
+	}
+	return h(ctx, m.Payload)
+}
+
+// backoff doubles the retry delay with every attempt up to maxRetryDelay.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.retryDelay
+	for i := 1; i < attempts && d < r.maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, r.maxRetryDelay)
+}
+
+// Close stops the relay loop and waits for the current batch to finish.
+func (r *Relay) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	return nil
+}
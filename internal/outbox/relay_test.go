@@ -0,0 +1,149 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	pending []*outbox.Message
+	sent    []uuid.UUID
+	failed  map[uuid.UUID]time.Duration
+	// sentAt holds the age of every sent message still stored.
+	sentAt []time.Duration
+}
+
+func (f *fakeStore) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	n := min(limit, len(f.pending))
+	claimed := f.pending[:n]
+	f.pending = f.pending[n:]
+	for _, m := range claimed {
+		m.Attempts++
+	}
+	return claimed, nil
+}
+
+func (f *fakeStore) MarkOutboxSent(ctx context.Context, id uuid.UUID) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeStore) MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error {
+	if f.failed == nil {
+		f.failed = make(map[uuid.UUID]time.Duration)
+	}
+	f.failed[id] = retryIn
+	return nil
+}
+
+func (f *fakeStore) PruneOutbox(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	var kept []time.Duration
+	n := 0
+	for _, age := range f.sentAt {
+		if age > olderThan && n < limit {
+			n++
+			continue
+		}
+		kept = append(kept, age)
+	}
+	f.sentAt = kept
+	return n, nil
+}
+
+func newMessage(t *testing.T, topic string) *outbox.Message {
+	t.Helper()
+	m, err := outbox.New(topic, map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRelay_RelayOnce_DeliversAndMarksSent(t *testing.T) {
+	m := newMessage(t, outbox.TopicBookingExpiry)
+	store := &fakeStore{pending: []*outbox.Message{m}}
+
+	var got []byte
+	r := outbox.NewRelay(store, &config.OutboxConfig{})
+	r.Handle(outbox.TopicBookingExpiry, func(ctx context.Context, payload []byte) error {
+		got = payload
+		return nil
+	})
+
+	if n := r.RelayOnce(context.Background()); n != 1 {
+		t.Fatalf("expected 1 claimed message, got %d", n)
+	}
+	if string(got) != `{"k":"v"}` {
+		t.Errorf("unexpected payload %s", got)
+	}
+	if len(store.sent) != 1 || store.sent[0] != m.ID {
+		t.Errorf("expected message to be marked sent, got %v", store.sent)
+	}
+}
+
+func TestRelay_RelayOnce_FailureBacksOff(t *testing.T) {
+	first := newMessage(t, outbox.TopicBookingExpiry)
+	retried := newMessage(t, outbox.TopicBookingExpiry)
+	retried.Attempts = 3
+	unknown := newMessage(t, "unknown.topic")
+	store := &fakeStore{pending: []*outbox.Message{first, retried, unknown}}
+
+	r := outbox.NewRelay(store, &config.OutboxConfig{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second})
+	r.Handle(outbox.TopicBookingExpiry, func(ctx context.Context, payload []byte) error {
+		return errors.New("broker down")
+	})
+
+	r.RelayOnce(context.Background())
+
+	if len(store.sent) != 0 {
+		t.Fatalf("failed messages must not be marked sent: %v", store.sent)
+	}
+	if d := store.failed[first.ID]; d != time.Second {
+		t.Errorf("first attempt: expected 1s backoff, got %s", d)
+	}
+	if d := store.failed[retried.ID]; d != 5*time.Second {
+		t.Errorf("fourth attempt: expected backoff capped at 5s, got %s", d)
+	}
+	if _, ok := store.failed[unknown.ID]; !ok {
+		t.Error("message without a handler must be recorded as failed")
+	}
+}
+
+func TestRelay_Discard(t *testing.T) {
+	m := newMessage(t, outbox.TopicBookingExpiry)
+	store := &fakeStore{pending: []*outbox.Message{m}}
+
+	r := outbox.NewRelay(store, &config.OutboxConfig{})
+	r.Handle(outbox.TopicBookingExpiry, outbox.Discard)
+	r.RelayOnce(context.Background())
+
+	if len(store.sent) != 1 {
+		t.Fatal("discarded message must be marked sent")
+	}
+}
+
+func TestRelay_PruneOnce_DeletesMessagesPastRetention(t *testing.T) {
+	store := &fakeStore{sentAt: []time.Duration{time.Hour, 3 * time.Hour, 48 * time.Hour}}
+	r := outbox.NewRelay(store, &config.OutboxConfig{Retention: 2 * time.Hour})
+
+	if n := r.PruneOnce(context.Background()); n != 2 {
+		t.Fatalf("expected 2 pruned messages, got %d", n)
+	}
+	if len(store.sentAt) != 1 || store.sentAt[0] != time.Hour {
+		t.Errorf("expected only the recent message to be kept, got %v", store.sentAt)
+	}
+
+	// Without a configured retention messages are kept for a week.
+	store.sentAt = []time.Duration{48 * time.Hour, 8 * 24 * time.Hour}
+	r = outbox.NewRelay(store, &config.OutboxConfig{})
+	if n := r.PruneOnce(context.Background()); n != 1 {
+		t.Errorf("expected 1 message older than a week to be pruned, got %d", n)
+	}
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"eventbooker/internal/domain/booking"
+
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const bookingColumns = `
+	b.id, b.event_id, b.user_id, e.name, e.date, b.ticket_type_id, t.name, b.count, b.price, b.status, b.created_at, b.expired_at,
+	b.telegram_notification, b.email_notification, b.telegram_recepient, b.email_recepient, u.locale,
+	u.email_verified AND u.email IS NOT DISTINCT FROM b.email_recepient
+`
+
+const bookingTables = `bookings b JOIN events e ON e.id = b.event_id JOIN ticket_types t ON t.id = b.ticket_type_id JOIN users u ON u.id = b.user_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBooking(row rowScanner) (*booking.Booking, error) {
+	var b booking.Booking
+	if err := row.Scan(
+		&b.ID, &b.EventID, &b.UserID, &b.EventName, &b.EventDate, &b.TicketTypeID, &b.TicketTypeName, &b.Count, &b.Price, &b.Status,
+		&b.CreatedAt, &b.ExpiredAt, &b.TelegramNotification, &b.EmailNotification,
+		&b.TelegramRecepient, &b.EmailRecepient, &b.Locale, &b.EmailVerified,
+	); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// GetBooking retrieves a booking by ID together with its event name and date.
+func (r *Repository) GetBooking(ctx context.Context, id string) (*booking.Booking, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + bookingColumns + ` FROM ` + bookingTables + ` WHERE b.id = $1`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute get booking query")
+		return nil, err
+	}
+
+	b, err := scanBooking(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, booking.ErrNotFound
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to scan booking row")
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ListUserBookings returns the bookings of a user, newest first, optionally filtered by status.
+func (r *Repository) ListUserBookings(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + bookingColumns + ` FROM ` + bookingTables + ` WHERE b.user_id = $1`
+	args := []any{userID}
+
+	if len(statuses) > 0 {
+		placeholders := make([]string, 0, len(statuses))
+		for _, s := range statuses {
+			args = append(args, s)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		query += " AND b.status IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY b.created_at DESC, b.id"
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to list user bookings")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	bookings := []*booking.Booking{}
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+const insertBookingQuery = `
+	INSERT INTO bookings (id, event_id, user_id, ticket_type_id, count, price, status, created_at, expired_at,
+		telegram_notification, email_notification, telegram_recepient, email_recepient)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+`
+
+func (r *Repository) insertBooking(ctx context.Context, tx *sql.Tx, b *booking.Booking) error {
+	return retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, insertBookingQuery,
+			b.ID, b.EventID, b.UserID, b.TicketTypeID, b.Count, b.Price, b.Status,
+			b.CreatedAt, b.ExpiredAt, b.TelegramNotification, b.EmailNotification,
+			b.TelegramRecepient, b.EmailRecepient,
+		)
+		return err
+	})
+}
+
+// ExpireNextDue cancels the oldest pending booking whose hold time ran out and returns
+// it with the bookings promoted from the waitlist into its seats. Rows locked by a
+// concurrent sweeper or consumer are skipped. It returns a nil booking when nothing is due.
+func (r *Repository) ExpireNextDue(ctx context.Context) (*booking.Booking, []*booking.Booking, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in expire_next_due")
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `SELECT ` + bookingColumns + ` FROM ` + bookingTables + `
+		WHERE b.status = $1 AND b.expired_at < now()
+		ORDER BY b.expired_at
+		LIMIT 1
+		FOR UPDATE OF b SKIP LOCKED`
+
+	var (
+		b    *booking.Booking
+		none bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		b, err = scanBooking(tx.QueryRowContext(ctx, query, booking.StatusCreated))
+		none = errors.Is(err, sql.ErrNoRows)
+		if none {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to select due booking")
+		return nil, nil, err
+	}
+	if none {
+		return nil, nil, nil
+	}
+
+	promoted, err := r.releaseBooking(ctx, tx, b.ID.String(), b.EventID.String(), b.TicketTypeID, b.Count)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("failed to expire booking %s", b.ID)
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit expire_next_due transaction")
+		return nil, nil, err
+	}
+	b.Status = booking.StatusCancelled
+
+	return b, promoted, nil
+}
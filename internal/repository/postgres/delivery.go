@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const deliveryColumns = `
+	id, booking_id, kind, channel, recipient, content, status, attempts, last_error, next_attempt_at, created_at, updated_at
+`
+
+func scanDelivery(row rowScanner) (*notification.Delivery, error) {
+	var (
+		d           notification.Delivery
+		content     []byte
+		lastError   sql.NullString
+		nextAttempt sql.NullTime
+	)
+	if err := row.Scan(
+		&d.ID, &d.BookingID, &d.Kind, &d.Channel, &d.Recipient, &content, &d.Status, &d.Attempts,
+		&lastError, &nextAttempt, &d.CreatedAt, &d.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &d.Content); err != nil {
+		return nil, fmt.Errorf("decode delivery %s content: %w", d.ID, err)
+	}
+	d.LastError = lastError.String
+	d.NextAttemptAt = nextAttempt.Time
+	return &d, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// RecordDelivery stores a queued notification delivery.
+func (r *Repository) RecordDelivery(ctx context.Context, d *notification.Delivery) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	content, err := json.Marshal(d.Content)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO notification_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err = r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		d.ID, d.BookingID, d.Kind, d.Channel, d.Recipient, content, d.Status, d.Attempts,
+		nullString(d.LastError), nullTime(d.NextAttemptAt), d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert notification delivery")
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDeliveries leases up to limit pending or failed deliveries whose next attempt is
+// due, oldest first, and counts the attempt. Rows claimed concurrently are skipped.
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*notification.Delivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE notification_deliveries
+		SET next_attempt_at = now() + $2 * interval '1 millisecond', attempts = attempts + 1, updated_at = now()
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status IN ($3, $4) AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, limit, lease.Milliseconds(), notification.DeliveryPending, notification.DeliveryFailed)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim notification deliveries")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanDeliveries(rows)
+}
+
+// MarkDeliverySent records that a retried notification was delivered.
+func (r *Repository) MarkDeliverySent(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE notification_deliveries SET status = $2, next_attempt_at = NULL, updated_at = now() WHERE id = $1`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, notification.DeliverySent)
+	return err
+}
+
+// MarkDeliveryFailed records a failed retry and schedules the next one.
+func (r *Repository) MarkDeliveryFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE notification_deliveries
+		SET status = $2, last_error = $3, next_attempt_at = now() + $4 * interval '1 millisecond', updated_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, notification.DeliveryFailed, reason, retryIn.Milliseconds())
+	return err
+}
+
+// AbandonDelivery records the last failed retry of a delivery; it is not retried again.
+func (r *Repository) AbandonDelivery(ctx context.Context, id uuid.UUID, reason string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE notification_deliveries SET status = $2, last_error = $3, next_attempt_at = NULL, updated_at = now() WHERE id = $1`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, notification.DeliveryAbandoned, reason)
+	return err
+}
+
+// PruneDeliveries deletes up to limit deliveries that were sent or abandoned more than
+// olderThan ago and returns how many were deleted.
+func (r *Repository) PruneDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM notification_deliveries
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status IN ($3, $4) AND updated_at < now() - $1 * interval '1 millisecond'
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		olderThan.Milliseconds(), limit, notification.DeliverySent, notification.DeliveryAbandoned)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune notification deliveries")
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ListDeliveries returns deliveries matching the filter, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, f notification.DeliveryFilter) ([]*notification.Delivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var (
+		conds []string
+		args  []any
+	)
+	if len(f.Statuses) > 0 {
+		placeholders := make([]string, 0, len(f.Statuses))
+		for _, s := range f.Statuses {
+			args = append(args, s)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		conds = append(conds, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.BookingID != uuid.Nil {
+		args = append(args, f.BookingID)
+		conds = append(conds, fmt.Sprintf("booking_id = $%d", len(args)))
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM notification_deliveries`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d", len(args))
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to list notification deliveries")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanDeliveries(rows)
+}
+
+// RequeueDelivery schedules an immediate attempt of an undelivered notification.
+func (r *Repository) RequeueDelivery(ctx context.Context, id string) (*notification.Delivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in requeue_delivery")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	lockQuery := `SELECT ` + deliveryColumns + ` FROM notification_deliveries WHERE id = $1 FOR UPDATE`
+
+	var (
+		d        *notification.Delivery
+		notFound bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		d, err = scanDelivery(tx.QueryRowContext(ctx, lockQuery, id))
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if notFound {
+		return nil, notification.ErrDeliveryNotFound
+	}
+
+	if err = d.Requeue(time.Now()); err != nil {
+		return nil, err
+	}
+
+	updateQuery := `UPDATE notification_deliveries SET status = $2, next_attempt_at = $3, updated_at = $3 WHERE id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, updateQuery, d.ID, d.Status, d.NextAttemptAt)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to requeue notification delivery")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit requeue_delivery transaction")
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*notification.Delivery, error) {
+	deliveries := []*notification.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"eventbooker/internal/domain/user"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// SaveEmailVerification stores the hash of a verification token. Earlier tokens of
+// the user and expired tokens of everyone are dropped, so only the latest email works.
+func (r *Repository) SaveEmailVerification(ctx context.Context, v *user.EmailVerification) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH purged AS (
+			DELETE FROM email_verifications WHERE user_id = $1 OR expires_at < now()
+		)
+		INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES ($2, $1, $3, $4)`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		v.UserID, user.HashToken(v.Token), v.Email, v.ExpiresAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert email verification")
+		return err
+	}
+
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks the email of its user as
+// verified. The token only works while the user still has the address it was
+// sent to.
+func (r *Repository) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in verify_email")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	consumeQuery := `DELETE FROM email_verifications WHERE token_hash = $1 RETURNING user_id, email, expires_at`
+
+	var (
+		userID    uuid.UUID
+		email     string
+		expiresAt time.Time
+		notFound  bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		err := tx.QueryRowContext(ctx, consumeQuery, user.HashToken(token)).Scan(&userID, &email, &expiresAt)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to consume email verification")
+		return nil, err
+	}
+	if notFound || time.Now().After(expiresAt) {
+		return nil, user.ErrInvalidVerificationToken
+	}
+
+	updateQuery := `UPDATE users SET email_verified = true WHERE id = $1 AND email = $2 RETURNING ` + userColumns
+
+	var u *user.User
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		u, err = scanUser(tx.QueryRowContext(ctx, updateQuery, userID, email))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrInvalidVerificationToken
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to mark email verified")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit verify_email transaction")
+		return nil, err
+	}
+
+	return u, nil
+}
@@ -2,16 +2,29 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"eventbooker/internal/apperr"
 	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/waitlist"
 
+	"github.com/google/uuid"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
 // CreateBooking inserts a new booking and decrements available seats atomically.
+// The booking limits of the event are checked while its row is locked, so parallel
+// bookings of one user cannot get past them together. The expiry message of a
+// pending booking is queued in the outbox in the same transaction.
 func (r *Repository) CreateBooking(ctx context.Context, b *booking.Booking) error {
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
@@ -23,49 +36,132 @@ func (r *Repository) CreateBooking(ctx context.Context, b *booking.Booking) erro
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	insertQuery := `
-		INSERT INTO bookings (id, event_id, user_id, count, price, status, created_at, expired_at,
-			telegram_notification, email_notification, telegram_recepient, email_recepient)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	updateQuery := `
+		UPDATE events
+		SET available_seats = available_seats - $1
+		WHERE id = $2 AND available_seats >= $1 AND status = $3
+		RETURNING max_seats_per_booking, max_bookings_per_user, max_seats_per_user
 	`
 
+	var (
+		limits  event.Limits
+		soldOut bool
+	)
 	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
-		_, err := tx.ExecContext(ctx, insertQuery,
-			b.ID, b.EventID, b.UserID, b.Count, b.Price, b.Status,
-			b.CreatedAt, b.ExpiredAt, b.TelegramNotification, b.EmailNotification,
-			b.TelegramRecepient, b.EmailRecepient,
-		)
+		err := tx.QueryRowContext(ctx, updateQuery, b.Count, b.EventID, event.StatusActive).
+			Scan(&limits.MaxSeatsPerBooking, &limits.MaxBookingsPerUser, &limits.MaxSeatsPerUser)
+		soldOut = errors.Is(err, sql.ErrNoRows)
+		if soldOut {
+			return nil
+		}
 		return err
 	})
 	if err != nil {
+		return err
+	}
+	if soldOut {
+		return event.ErrSoldOut
+	}
+
+	var held, heldSeats int
+	if limits.PerUser() {
+		if held, heldSeats, err = r.userHoldings(ctx, tx, b.EventID, b.UserID); err != nil {
+			return err
+		}
+	}
+	if err = limits.Allow(b.Count, held, heldSeats); err != nil {
+		return err
+	}
+
+	if err = r.insertBooking(ctx, tx, b); err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to insert booking")
 		return err
 	}
 
-	updateQuery := `
-		UPDATE events
-		SET available_seats = available_seats - $1
-		WHERE id = $2 AND available_seats >= $1
+	if err = r.moveTierSeats(ctx, tx, b.TicketTypeID, -b.Count); err != nil {
+		return err
+	}
+
+	if err = r.scheduleExpiry(ctx, tx, b); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit transaction")
+		return err
+	}
+
+	return nil
+}
+
+// userHoldings returns how many active bookings with how many seats in total the
+// user holds for the event. Must run inside tx while the event row is locked.
+func (r *Repository) userHoldings(ctx context.Context, tx *sql.Tx, eventID, userID uuid.UUID) (bookings, seats int, err error) {
+	query := `
+		SELECT count(*), COALESCE(sum(count), 0) FROM bookings
+		WHERE event_id = $1 AND user_id = $2 AND status IN ($3, $4)
 	`
 
 	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
-		result, err := tx.ExecContext(ctx, updateQuery, b.Count, b.EventID)
-		if err != nil {
-			return err
-		}
+		return tx.QueryRowContext(ctx, query, eventID, userID, booking.StatusCreated, booking.StatusConfirmed).Scan(&bookings, &seats)
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to count user bookings")
+		return 0, 0, err
+	}
 
-		rows, err := result.RowsAffected()
-		if err != nil {
-			return err
-		}
+	return bookings, seats, nil
+}
 
-		if rows == 0 {
-			return fmt.Errorf("not enough available seats for the event")
+// ConfirmBooking confirms a pending booking of the given user in a single transaction.
+func (r *Repository) ConfirmBooking(ctx context.Context, id, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in confirm_booking")
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	lockQuery := `SELECT id, user_id, status, expired_at FROM bookings WHERE id = $1 FOR UPDATE`
+
+	var (
+		b        booking.Booking
+		notFound bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		err := tx.QueryRowContext(ctx, lockQuery, id).Scan(&b.ID, &b.UserID, &b.Status, &b.ExpiredAt)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
 		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if notFound {
+		return booking.ErrNotFound
+	}
 
-		return nil
+	if err = b.ConfirmBy(uID); err != nil {
+		return err
+	}
+
+	confirmQuery := `UPDATE bookings SET status = $2 WHERE id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, confirmQuery, id, b.Status)
+		return err
 	})
 	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to confirm booking")
 		return err
 	}
 
@@ -77,58 +173,92 @@ func (r *Repository) CreateBooking(ctx context.Context, b *booking.Booking) erro
 	return nil
 }
 
-// ConfirmBooking sets a booking's status to confirmed.
-func (r *Repository) ConfirmBooking(ctx context.Context, id string) error {
-	ctx, cancel := r.withTimeout(ctx)
-	defer cancel()
+// CancelBooking cancels a created or confirmed booking and returns the seats to the event.
+// Released seats go to the waitlist first; the resulting hold bookings are returned.
+func (r *Repository) CancelBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error) {
+	return r.cancelBooking(ctx, bookingID, eventID, booking.StatusCreated, booking.StatusConfirmed)
+}
 
-	query := `UPDATE bookings SET status = 'confirmed' WHERE id = $1`
-	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
-	return err
+// ExpireBooking cancels a booking that is still awaiting confirmation and returns the seats to the event.
+// Released seats go to the waitlist first; the resulting hold bookings are returned.
+func (r *Repository) ExpireBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error) {
+	return r.cancelBooking(ctx, bookingID, eventID, booking.StatusCreated)
 }
 
-// CancelBooking cancels a booking and returns the seats to the event.
-func (r *Repository) CancelBooking(ctx context.Context, bookingID, eventID string) error {
+func (r *Repository) cancelBooking(ctx context.Context, bookingID, eventID string, from ...booking.Status) ([]*booking.Booking, error) {
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
 	tx, err := r.db.Master.BeginTx(ctx, nil)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in cancel_booking")
-		return err
+		return nil, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	cancelQuery := `UPDATE bookings SET status = 'cancelled' WHERE id = $1`
+	lockQuery := `SELECT status, count, ticket_type_id FROM bookings WHERE id = $1 FOR UPDATE`
+
+	var (
+		status       booking.Status
+		count        int
+		ticketTypeID uuid.UUID
+		notFound     bool
+	)
 	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
-		result, err := tx.ExecContext(ctx, cancelQuery, bookingID)
-		if err != nil {
-			return err
+		err := tx.QueryRowContext(ctx, lockQuery, bookingID).Scan(&status, &count, &ticketTypeID)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
 		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if notFound {
+		return nil, booking.ErrNotFound
+	}
 
-		rows, err := result.RowsAffected()
-		if err != nil {
-			return err
+	if !slices.Contains(from, status) {
+		if status == booking.StatusConfirmed {
+			return nil, booking.ErrAlreadyConfirmed
 		}
+		return nil, booking.ErrAlreadyCancelled
+	}
 
-		if rows == 0 {
-			return fmt.Errorf("booking already cancelled")
-		}
+	promoted, err := r.releaseBooking(ctx, tx, bookingID, eventID, ticketTypeID, count)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// releaseBooking cancels a locked booking inside tx, returns its seats to the event and
+// its ticket type, and hands them to the waitlist.
+func (r *Repository) releaseBooking(ctx context.Context, tx *sql.Tx, bookingID, eventID string, ticketTypeID uuid.UUID, count int) ([]*booking.Booking, error) {
+	cancelQuery := `UPDATE bookings SET status = $2 WHERE id = $1`
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, cancelQuery, bookingID, booking.StatusCancelled)
+		return err
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	returnSeatsQuery := `
 		UPDATE events
-		SET available_seats = available_seats + (SELECT count FROM bookings WHERE id = $1)
+		SET available_seats = available_seats + $1
 		WHERE id = $2
 	`
 
 	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
-		result, err := tx.ExecContext(ctx, returnSeatsQuery, bookingID, eventID)
+		result, err := tx.ExecContext(ctx, returnSeatsQuery, count, eventID)
 		if err != nil {
 			return err
 		}
@@ -145,15 +275,14 @@ func (r *Repository) CancelBooking(ctx context.Context, bookingID, eventID strin
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err = tx.Commit(); err != nil {
-		wbzlog.Logger.Error().Err(err).Msg("failed to commit transaction")
-		return err
+	if err = r.moveTierSeats(ctx, tx, ticketTypeID, count); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return r.promoteWaitlist(ctx, tx, eventID)
 }
 
 // GetBookingStatus returns the status of a booking.
@@ -182,18 +311,39 @@ func (r *Repository) CreateEvent(ctx context.Context, e *event.Event) error {
 	defer cancel()
 
 	query := `
-		INSERT INTO events (id, creator_id, date, name, description, total_seats, available_seats, price, booking_ttl)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO events (id, creator_id, date, name, description, total_seats, available_seats, price, booking_ttl_seconds, status,
+			max_seats_per_booking, max_bookings_per_user, max_seats_per_user)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
-		e.ID, e.CreatorID, e.Date, e.Name, e.Description, e.MaxCountPeople, e.FreePlaces, e.Price, e.BookingTTL,
-	)
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in create_event")
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, query,
+			e.ID, e.CreatorID, e.Date, e.Name, e.Description, e.MaxCountPeople, e.FreePlaces, e.Price, seconds(e.BookingTTL), e.Status,
+			e.Limits.MaxSeatsPerBooking, e.Limits.MaxBookingsPerUser, e.Limits.MaxSeatsPerUser,
+		)
+		return err
+	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to insert event")
 		return err
 	}
 
+	if err = r.insertTicketTypes(ctx, tx, e); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit create_event transaction")
+		return err
+	}
+
 	return nil
 }
 
@@ -202,28 +352,30 @@ func (r *Repository) GetEvent(ctx context.Context, eventID string) (*event.Event
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	query := `
-		SELECT id, creator_id, date, name, description, total_seats, available_seats, price, booking_ttl
-		FROM events WHERE id = $1
-	`
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
 
 	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, eventID)
 	if err != nil {
 		return nil, err
 	}
 
-	var ev event.Event
-	if err = row.Scan(
-		&ev.ID, &ev.CreatorID, &ev.Date, &ev.Name, &ev.Description,
-		&ev.MaxCountPeople, &ev.FreePlaces, &ev.Price, &ev.BookingTTL,
-	); err != nil {
+	ev, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, event.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.attachTicketTypes(ctx, ev); err != nil {
 		return nil, err
 	}
 
 	bookingsQuery := `
-		SELECT id, event_id, user_id, count, price, status, created_at, expired_at,
-			telegram_notification, email_notification, telegram_recepient, email_recepient
-		FROM bookings WHERE event_id = $1
+		SELECT b.id, b.event_id, b.user_id, b.ticket_type_id, t.name, b.count, b.price, b.status, b.created_at, b.expired_at,
+			b.telegram_notification, b.email_notification, b.telegram_recepient, b.email_recepient
+		FROM bookings b JOIN ticket_types t ON t.id = b.ticket_type_id
+		WHERE b.event_id = $1
 	`
 
 	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, bookingsQuery, eventID)
@@ -236,7 +388,7 @@ func (r *Repository) GetEvent(ctx context.Context, eventID string) (*event.Event
 	for rows.Next() {
 		var b booking.Booking
 		if err = rows.Scan(
-			&b.ID, &b.EventID, &b.UserID, &b.Count, &b.Price, &b.Status,
+			&b.ID, &b.EventID, &b.UserID, &b.TicketTypeID, &b.TicketTypeName, &b.Count, &b.Price, &b.Status,
 			&b.CreatedAt, &b.ExpiredAt, &b.TelegramNotification, &b.EmailNotification,
 			&b.TelegramRecepient, &b.EmailRecepient,
 		); err != nil {
@@ -247,5 +399,376 @@ func (r *Repository) GetEvent(ctx context.Context, eventID string) (*event.Event
 	}
 
 	ev.Bookings = bookings
+	return ev, nil
+}
+
+// UpdateEvent applies an organizer's changes to an event in a single transaction.
+func (r *Repository) UpdateEvent(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in update_event")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ev, err := r.lockEvent(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ev.ApplyBy(uID, u); err != nil {
+		return nil, err
+	}
+
+	updateQuery := `
+		UPDATE events
+		SET date = $2, name = $3, description = $4, total_seats = $5, available_seats = $6, price = $7, booking_ttl_seconds = $8,
+			max_seats_per_booking = $9, max_bookings_per_user = $10, max_seats_per_user = $11
+		WHERE id = $1
+	`
+
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, updateQuery,
+			ev.ID, ev.Date, ev.Name, ev.Description, ev.MaxCountPeople, ev.FreePlaces, ev.Price, seconds(ev.BookingTTL),
+			ev.Limits.MaxSeatsPerBooking, ev.Limits.MaxBookingsPerUser, ev.Limits.MaxSeatsPerUser,
+		)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to update event")
+		return nil, err
+	}
+
+	if err = r.updateTicketTypes(ctx, tx, ev.TicketTypes); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+
+	return ev, nil
+}
+
+// CancelEvent marks an event cancelled and cancels all of its active bookings.
+// The returned event carries the bookings that were cancelled.
+func (r *Repository) CancelEvent(ctx context.Context, eventID, userID string) (*event.Event, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in cancel_event")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ev, err := r.lockEvent(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ev.CancelBy(uID); err != nil {
+		return nil, err
+	}
+
+	bookings, err := r.cancelEvent(ctx, tx, ev)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit transaction")
+		return nil, err
+	}
+
+	ev.Bookings = bookings
+	return ev, nil
+}
+
+// cancelEvent writes the cancellation of a locked event inside tx: its active
+// bookings are cancelled, its waitlist is cleared and all seats are freed. It
+// returns the cancelled bookings, which have to be notified.
+func (r *Repository) cancelEvent(ctx context.Context, tx *sql.Tx, ev *event.Event) ([]*booking.Booking, error) {
+	// Every active booking is cancelled; users are joined only for the locale and the
+	// verified address of the notification.
+	cancelBookingsQuery := `
+		WITH cancelled AS (
+			UPDATE bookings SET status = $2
+			WHERE event_id = $1 AND status IN ($3, $4)
+			RETURNING id, event_id, user_id, ticket_type_id, count, price, status, created_at, expired_at,
+				telegram_notification, email_notification, telegram_recepient, email_recepient
+		)
+		SELECT c.id, c.event_id, c.user_id, c.ticket_type_id, c.count, c.price, c.status, c.created_at, c.expired_at,
+			c.telegram_notification, c.email_notification, c.telegram_recepient, c.email_recepient, COALESCE(u.locale, ''),
+			COALESCE(u.email_verified AND u.email IS NOT DISTINCT FROM c.email_recepient, false)
+		FROM cancelled c
+		LEFT JOIN users u ON u.id = c.user_id
+	`
+
+	var bookings []*booking.Booking
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		bookings = nil
+
+		rows, err := tx.QueryContext(ctx, cancelBookingsQuery, ev.ID, booking.StatusCancelled, booking.StatusCreated, booking.StatusConfirmed)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var b booking.Booking
+			if err = rows.Scan(
+				&b.ID, &b.EventID, &b.UserID, &b.TicketTypeID, &b.Count, &b.Price, &b.Status,
+				&b.CreatedAt, &b.ExpiredAt, &b.TelegramNotification, &b.EmailNotification,
+				&b.TelegramRecepient, &b.EmailRecepient, &b.Locale, &b.EmailVerified,
+			); err != nil {
+				return err
+			}
+			b.EventName = ev.Name
+			b.EventDate = ev.Date
+			bookings = append(bookings, &b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to cancel event bookings")
+		return nil, err
+	}
+
+	removeWaitlistQuery := `UPDATE waitlist SET status = $2 WHERE event_id = $1 AND status = $3`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, removeWaitlistQuery, ev.ID, waitlist.StatusRemoved, waitlist.StatusWaiting)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to clear event waitlist")
+		return nil, err
+	}
+
+	resetTiersQuery := `UPDATE ticket_types SET available_seats = total_seats WHERE event_id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, resetTiersQuery, ev.ID)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to reset ticket type seats")
+		return nil, err
+	}
+
+	cancelEventQuery := `UPDATE events SET status = $2, available_seats = total_seats WHERE id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, cancelEventQuery, ev.ID, ev.Status)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to cancel event")
+		return nil, err
+	}
+
+	ev.FreePlaces = ev.MaxCountPeople
+	for _, t := range ev.TicketTypes {
+		t.FreePlaces = t.MaxCountPeople
+	}
+	return bookings, nil
+}
+
+const eventColumns = `id, creator_id, date, name, description, total_seats, available_seats, price, booking_ttl_seconds, status,
+	max_seats_per_booking, max_bookings_per_user, max_seats_per_user`
+
+func scanEvent(row rowScanner) (*event.Event, error) {
+	var (
+		ev         event.Event
+		ttlSeconds int64
+	)
+	if err := row.Scan(
+		&ev.ID, &ev.CreatorID, &ev.Date, &ev.Name, &ev.Description,
+		&ev.MaxCountPeople, &ev.FreePlaces, &ev.Price, &ttlSeconds, &ev.Status,
+		&ev.Limits.MaxSeatsPerBooking, &ev.Limits.MaxBookingsPerUser, &ev.Limits.MaxSeatsPerUser,
+	); err != nil {
+		return nil, err
+	}
+	ev.BookingTTL = time.Duration(ttlSeconds) * time.Second
+	ev.Bookings = []*booking.Booking{}
 	return &ev, nil
 }
+
+func (r *Repository) lockEvent(ctx context.Context, tx *sql.Tx, eventID string) (*event.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1 FOR UPDATE`
+
+	var (
+		ev       *event.Event
+		notFound bool
+	)
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		ev, err = scanEvent(tx.QueryRowContext(ctx, query, eventID))
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if notFound {
+		return nil, event.ErrNotFound
+	}
+
+	if err = r.lockTicketTypes(ctx, tx, ev); err != nil {
+		return nil, err
+	}
+
+	return ev, nil
+}
+
+// ListEvents returns a page of events matching the filter, ordered by the requested sort.
+func (r *Repository) ListEvents(ctx context.Context, f event.Filter) (*event.Page, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if !f.DateFrom.IsZero() {
+		conds = append(conds, "date >= "+arg(f.DateFrom))
+	}
+	if !f.DateTo.IsZero() {
+		conds = append(conds, "date <= "+arg(f.DateTo))
+	}
+	if f.PriceMin != nil {
+		conds = append(conds, "price >= "+arg(*f.PriceMin))
+	}
+	if f.PriceMax != nil {
+		conds = append(conds, "price <= "+arg(*f.PriceMax))
+	}
+	conds = append(conds, "status = "+arg(event.StatusActive))
+	if f.OnlyAvailable {
+		conds = append(conds, "available_seats > 0")
+	}
+	if f.CreatorID != "" {
+		conds = append(conds, "creator_id = "+arg(f.CreatorID))
+	}
+
+	column, direction := sortColumn(f.Sort)
+
+	if f.Cursor != "" {
+		c, err := decodeEventCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		cmp := ">"
+		if direction == "DESC" {
+			cmp = "<"
+		}
+
+		var value any = c.Date
+		if column == "price" {
+			value = c.Price
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(value), arg(c.ID)))
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events WHERE ` + strings.Join(conds, " AND ")
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, arg(f.Limit+1))
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to list events")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := make([]*event.Event, 0, f.Limit+1)
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &event.Page{Events: events}
+	if len(events) > f.Limit {
+		page.Events = events[:f.Limit]
+		page.NextCursor, err = encodeEventCursor(page.Events[f.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.attachTicketTypes(ctx, page.Events...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// eventCursor is the keyset position of the last event on a page.
+type eventCursor struct {
+	Date  time.Time `json:"d"`
+	Price float64   `json:"p"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encodeEventCursor(ev *event.Event) (string, error) {
+	raw, err := json.Marshal(eventCursor{Date: ev.Date, Price: ev.Price, ID: ev.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+var errInvalidCursor = apperr.Validation("invalid_cursor", "invalid cursor")
+
+func decodeEventCursor(s string) (*eventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var c eventCursor
+	if err = json.Unmarshal(raw, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &c, nil
+}
+
+func sortColumn(s event.Sort) (column, direction string) {
+	switch s {
+	case event.SortDateDesc:
+		return "date", "DESC"
+	case event.SortPriceAsc:
+		return "price", "ASC"
+	case event.SortPriceDesc:
+		return "price", "DESC"
+	default:
+		return "date", "ASC"
+	}
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"eventbooker/internal/idempotency"
+
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// ClaimIdempotencyKey reserves the key of the record for its request and returns
+// nil. When the key is already held, the stored record is returned instead. An
+// expired key is taken over, and so is one whose lease ran out without a response,
+// provided the request is the same. The holder renews the lease while it runs, so
+// that only happens when it died; whatever it did before is not undone, so the
+// takeover is at-least-once. Expired keys of the user are dropped.
+func (r *Repository) ClaimIdempotencyKey(ctx context.Context, rec *idempotency.Record, lease time.Duration) (*idempotency.Record, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	claimQuery := `
+		WITH purged AS (
+			DELETE FROM idempotency_keys WHERE user_id = $1 AND key <> $2 AND expires_at < now()
+		)
+		INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at, locked_until)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond')
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = NULL, response = NULL,
+			created_at = now(), expires_at = EXCLUDED.expires_at, locked_until = EXCLUDED.locked_until
+		WHERE idempotency_keys.expires_at < now()
+			OR (idempotency_keys.status_code IS NULL
+				AND idempotency_keys.request_hash = EXCLUDED.request_hash
+				AND idempotency_keys.locked_until < now())
+		RETURNING true`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, claimQuery,
+		rec.UserID, rec.Key, rec.RequestHash, rec.ExpiresAt, lease.Milliseconds(),
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute claim idempotency key query")
+		return nil, err
+	}
+
+	var claimed bool
+	err = row.Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim idempotency key")
+		return nil, err
+	}
+
+	getQuery := `SELECT request_hash, status_code, response, expires_at FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	row, err = r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, getQuery, rec.UserID, rec.Key)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute get idempotency key query")
+		return nil, err
+	}
+
+	stored := idempotency.Record{UserID: rec.UserID, Key: rec.Key}
+	var statusCode sql.NullInt64
+	err = row.Scan(&stored.RequestHash, &statusCode, &stored.Body, &stored.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The first request failed and released the key in the meantime.
+		return nil, idempotency.ErrInProgress
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to scan idempotency key row")
+		return nil, err
+	}
+	stored.StatusCode = int(statusCode.Int64)
+
+	return &stored, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request that claimed the key.
+func (r *Repository) CompleteIdempotencyKey(ctx context.Context, userID, key string, statusCode int, body []byte) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET status_code = $3, response = $4 WHERE user_id = $1 AND key = $2`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		userID, key, statusCode, body,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to store idempotent response")
+		return err
+	}
+
+	return nil
+}
+
+// ExtendIdempotencyKey renews the lease of a key that has no response yet for
+// another lease, but not past the expiry of the key.
+func (r *Repository) ExtendIdempotencyKey(ctx context.Context, userID, key string, lease time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE idempotency_keys SET locked_until = LEAST(now() + $3 * interval '1 millisecond', expires_at)
+		WHERE user_id = $1 AND key = $2 AND status_code IS NULL`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, userID, key, lease.Milliseconds())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to extend idempotency key")
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a claimed key whose request failed, so it can be retried.
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, userID, key)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to release idempotency key")
+		return err
+	}
+
+	return nil
+}
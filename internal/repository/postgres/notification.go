@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// ClaimExpiryWarnings marks up to limit pending bookings that expire within lead as
+// warned and returns them. Bookings whose whole hold is shorter than lead are skipped:
+// their owner was just told the deadline when booking.
+func (r *Repository) ClaimExpiryWarnings(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error) {
+	query := `
+		WITH due AS (
+			UPDATE bookings SET expiry_warned_at = now()
+			WHERE id IN (
+				SELECT id FROM bookings
+				WHERE status = $1 AND expiry_warned_at IS NULL
+					AND expired_at > now()
+					AND expired_at <= now() + $2 * interval '1 millisecond'
+					AND expired_at - created_at > $2 * interval '1 millisecond'
+				ORDER BY expired_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id
+		)
+		SELECT ` + bookingColumns + ` FROM ` + bookingTables + ` WHERE b.id IN (SELECT id FROM due)`
+
+	return r.claimBookings(ctx, "expiry warnings", query, booking.StatusCreated, lead.Milliseconds(), limit)
+}
+
+// ClaimEventReminders marks up to limit confirmed bookings of active events starting
+// within lead as reminded and returns them. Bookings made later than lead before the
+// event are skipped.
+func (r *Repository) ClaimEventReminders(ctx context.Context, lead time.Duration, limit int) ([]*booking.Booking, error) {
+	query := `
+		WITH due AS (
+			UPDATE bookings SET reminded_at = now()
+			WHERE id IN (
+				SELECT b.id FROM bookings b JOIN events e ON e.id = b.event_id
+				WHERE b.status = $1 AND b.reminded_at IS NULL AND e.status = $4
+					AND e.date > now()
+					AND e.date <= now() + $2 * interval '1 millisecond'
+					AND e.date - b.created_at > $2 * interval '1 millisecond'
+				ORDER BY e.date
+				LIMIT $3
+				FOR UPDATE OF b SKIP LOCKED
+			)
+			RETURNING id
+		)
+		SELECT ` + bookingColumns + ` FROM ` + bookingTables + ` WHERE b.id IN (SELECT id FROM due)`
+
+	return r.claimBookings(ctx, "event reminders", query, booking.StatusConfirmed, lead.Milliseconds(), limit, event.StatusActive)
+}
+
+func (r *Repository) claimBookings(ctx context.Context, what, query string, args ...any) ([]*booking.Booking, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("failed to claim %s", what)
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var bookings []*booking.Booking
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/outbox"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// insertOutbox stores a message inside tx, so it is committed together with the
+// change that produced it.
+func (r *Repository) insertOutbox(ctx context.Context, tx *sql.Tx, m *outbox.Message) error {
+	query := `INSERT INTO outbox (id, topic, payload, created_at) VALUES ($1, $2, $3, $4)`
+
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, query, m.ID, m.Topic, m.Payload, m.CreatedAt)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert outbox message")
+		return err
+	}
+
+	return nil
+}
+
+// ClaimOutbox leases up to limit pending messages, oldest first. Claimed messages are
+// hidden from other relays until the lease runs out. Rows claimed concurrently are skipped.
+func (r *Repository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = now() + $2 * interval '1 millisecond', attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND next_attempt_at <= now()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, payload, attempts, created_at
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, limit, lease.Milliseconds())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim outbox messages")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var msgs []*outbox.Message
+	for rows.Next() {
+		var m outbox.Message
+		if err = rows.Scan(&m.ID, &m.Topic, &m.Payload, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// MarkOutboxSent records that a message was delivered.
+func (r *Repository) MarkOutboxSent(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE outbox SET sent_at = now(), last_error = NULL WHERE id = $1`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
+	return err
+}
+
+// MarkOutboxFailed records a failed delivery and schedules the next attempt.
+func (r *Repository) MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryIn time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE outbox SET last_error = $2, next_attempt_at = now() + $3 * interval '1 millisecond' WHERE id = $1`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, reason, retryIn.Milliseconds())
+	return err
+}
+
+// PruneOutbox deletes up to limit messages sent more than olderThan ago and returns
+// how many were deleted.
+func (r *Repository) PruneOutbox(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM outbox
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at < now() - $1 * interval '1 millisecond'
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, olderThan.Milliseconds(), limit)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune outbox messages")
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// scheduleExpiry queues the expiry message of a pending booking inside tx.
+// Confirmed (free) bookings never expire and get no message.
+func (r *Repository) scheduleExpiry(ctx context.Context, tx *sql.Tx, b *booking.Booking) error {
+	if b.Status != booking.StatusCreated {
+		return nil
+	}
+
+	m, err := outbox.New(outbox.TopicBookingExpiry, b)
+	if err != nil {
+		return err
+	}
+
+	return r.insertOutbox(ctx, tx, m)
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"eventbooker/internal/domain/user"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// SavePasswordReset stores the hash of a reset token. Earlier tokens of the user and
+// expired tokens of everyone are dropped, so only the latest email works.
+func (r *Repository) SavePasswordReset(ctx context.Context, p *user.PasswordReset) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH purged AS (
+			DELETE FROM password_resets WHERE user_id = $1 OR expires_at < now()
+		)
+		INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($2, $1, $3)`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		p.UserID, user.HashToken(p.Token), p.ExpiresAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert password reset")
+		return err
+	}
+
+	return nil
+}
+
+// LastPasswordResetAt returns when the latest reset token of the user was issued, or
+// the zero time when there is none.
+func (r *Repository) LastPasswordResetAt(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT max(created_at) FROM password_resets WHERE user_id = $1`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, userID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute last password reset query")
+		return time.Time{}, err
+	}
+
+	var last sql.NullTime
+	if err = row.Scan(&last); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to scan last password reset")
+		return time.Time{}, err
+	}
+
+	return last.Time, nil
+}
+
+// ResetPassword consumes a reset token, stores the new password hash of its user and
+// revokes all their sessions, so whoever knew the old password is logged out.
+func (r *Repository) ResetPassword(ctx context.Context, token string, password []byte) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in reset_password")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	consumeQuery := `DELETE FROM password_resets WHERE token_hash = $1 RETURNING user_id, expires_at`
+
+	var (
+		userID    uuid.UUID
+		expiresAt time.Time
+		notFound  bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		err := tx.QueryRowContext(ctx, consumeQuery, user.HashToken(token)).Scan(&userID, &expiresAt)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to consume password reset")
+		return nil, err
+	}
+	if notFound || time.Now().After(expiresAt) {
+		return nil, user.ErrInvalidResetToken
+	}
+
+	u, err := r.setPassword(ctx, tx, userID.String(), password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit reset_password transaction")
+		return nil, err
+	}
+
+	return u, nil
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"eventbooker/internal/domain/user"
+
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const refreshTokenColumns = `id, family_id, user_id, expires_at, used_at, revoked_at`
+
+func scanRefreshToken(row rowScanner) (*user.RefreshToken, error) {
+	var (
+		t                 user.RefreshToken
+		usedAt, revokedAt sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.FamilyID, &t.UserID, &t.ExpiresAt, &usedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	t.UsedAt = usedAt.Time
+	t.RevokedAt = revokedAt.Time
+	return &t, nil
+}
+
+// SaveRefreshToken stores the first token of a new family. Expired tokens of the
+// user are dropped: they can no longer be presented, so reuse cannot be detected
+// on them anyway.
+func (r *Repository) SaveRefreshToken(ctx context.Context, t *user.RefreshToken) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH purged AS (
+			DELETE FROM refresh_tokens WHERE user_id = $3 AND expires_at < now()
+		)
+		INSERT INTO refresh_tokens (id, family_id, user_id, expires_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		t.ID, t.FamilyID, t.UserID, t.ExpiresAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert refresh token")
+		return err
+	}
+
+	return nil
+}
+
+// RotateRefreshToken uses up the token with the given id and stores its successor,
+// valid for ttl. An unknown token is reported as revoked. When the token was
+// already used, its whole family is revoked and user.ErrRefreshTokenReused is
+// returned.
+func (r *Repository) RotateRefreshToken(ctx context.Context, id string, ttl time.Duration) (*user.RefreshToken, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in rotate_refresh_token")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The lock makes concurrent refreshes with the same token wait, so only the
+	// first one succeeds and the rest are seen as reuse.
+	selectQuery := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE id = $1 FOR UPDATE`
+
+	var cur *user.RefreshToken
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		cur, err = scanRefreshToken(tx.QueryRowContext(ctx, selectQuery, id))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrRefreshTokenRevoked
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to get refresh token")
+		return nil, err
+	}
+
+	next, err := cur.Rotate(ttl)
+	if errors.Is(err, user.ErrRefreshTokenReused) {
+		wbzlog.Logger.Warn().Msgf("refresh token %s of user %s reused, revoking family %s", cur.ID, cur.UserID, cur.FamilyID)
+		if err := r.revokeFamily(ctx, tx, cur.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to commit rotate_refresh_token transaction")
+			return nil, err
+		}
+		return nil, user.ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, []any{cur.ID}},
+		{`DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < now()`, []any{cur.UserID}},
+		{`INSERT INTO refresh_tokens (id, family_id, user_id, expires_at) VALUES ($1, $2, $3, $4)`,
+			[]any{next.ID, next.FamilyID, next.UserID, next.ExpiresAt}},
+	}
+	for _, st := range statements {
+		err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, st.query, st.args...)
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to rotate refresh token")
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit rotate_refresh_token transaction")
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// RevokeRefreshTokenFamily ends the session the token with the given id belongs to.
+// Unknown tokens are ignored.
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, id string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE id = $1) AND revoked_at IS NULL`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to revoke refresh token family")
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens ends every session of the user.
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, userID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to revoke refresh tokens of user")
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) revokeFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, query, familyID)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to revoke refresh token family")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/user"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// SaveTelegramLink stores the hash of a link token. Earlier tokens of the user and
+// expired tokens of everyone are dropped.
+func (r *Repository) SaveTelegramLink(ctx context.Context, l *user.TelegramLink) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH purged AS (
+			DELETE FROM telegram_links WHERE user_id = $1 OR expires_at < now()
+		)
+		INSERT INTO telegram_links (token_hash, user_id, expires_at) VALUES ($2, $1, $3)`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		l.UserID, user.HashToken(l.Token), l.ExpiresAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert telegram link")
+		return err
+	}
+
+	return nil
+}
+
+// LinkTelegram consumes a link token and binds the chat to its user. The chat is
+// taken from any other account, whose active bookings stop notifying it, and the
+// active bookings of the user are notified in the chat from now on.
+func (r *Repository) LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in link_telegram")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	consumeQuery := `DELETE FROM telegram_links WHERE token_hash = $1 RETURNING user_id, expires_at`
+
+	var (
+		userID    uuid.UUID
+		expiresAt time.Time
+		notFound  bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		err := tx.QueryRowContext(ctx, consumeQuery, user.HashToken(token)).Scan(&userID, &expiresAt)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to consume telegram link")
+		return nil, err
+	}
+	if notFound || time.Now().After(expiresAt) {
+		return nil, user.ErrInvalidLinkToken
+	}
+
+	active := []any{booking.StatusCreated, booking.StatusConfirmed}
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE users SET telegram = '' WHERE telegram = $1 AND id <> $2`, []any{chatID, userID}},
+		{`UPDATE bookings SET telegram_notification = false
+			WHERE telegram_recepient = $1 AND user_id <> $2 AND status IN ($3, $4)`, append([]any{chatID, userID}, active...)},
+		{`UPDATE bookings SET telegram_recepient = $1
+			WHERE user_id = $2 AND status IN ($3, $4)`, append([]any{chatID, userID}, active...)},
+	}
+	for _, st := range statements {
+		err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, st.query, st.args...)
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to move telegram chat to the linked user")
+			return nil, err
+		}
+	}
+
+	linkQuery := `UPDATE users SET telegram = $1 WHERE id = $2 RETURNING ` + userColumns
+
+	var u *user.User
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		u, err = scanUser(tx.QueryRowContext(ctx, linkQuery, chatID, userID))
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to link telegram chat")
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit link_telegram transaction")
+		return nil, err
+	}
+
+	return u, nil
+}
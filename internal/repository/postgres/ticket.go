@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"eventbooker/internal/domain/event"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const ticketTypeColumns = `id, event_id, name, price, total_seats, available_seats, booking_ttl_seconds`
+
+func scanTicketType(row rowScanner) (*event.TicketType, error) {
+	var (
+		t          event.TicketType
+		ttlSeconds int64
+	)
+	if err := row.Scan(&t.ID, &t.EventID, &t.Name, &t.Price, &t.MaxCountPeople, &t.FreePlaces, &ttlSeconds); err != nil {
+		return nil, err
+	}
+	t.BookingTTL = time.Duration(ttlSeconds) * time.Second
+	return &t, nil
+}
+
+// seconds converts a booking TTL to the whole seconds it is stored in.
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+// insertTicketTypes stores the tiers of a new event inside tx.
+func (r *Repository) insertTicketTypes(ctx context.Context, tx *sql.Tx, ev *event.Event) error {
+	query := `INSERT INTO ticket_types (` + ticketTypeColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	for _, t := range ev.TicketTypes {
+		err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, query, t.ID, t.EventID, t.Name, t.Price, t.MaxCountPeople, t.FreePlaces, seconds(t.BookingTTL))
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to insert ticket type")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateTicketTypes writes back the tiers of an event inside tx.
+func (r *Repository) updateTicketTypes(ctx context.Context, tx *sql.Tx, types []*event.TicketType) error {
+	query := `
+		UPDATE ticket_types
+		SET name = $2, price = $3, total_seats = $4, available_seats = $5, booking_ttl_seconds = $6
+		WHERE id = $1
+	`
+
+	for _, t := range types {
+		err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, query, t.ID, t.Name, t.Price, t.MaxCountPeople, t.FreePlaces, seconds(t.BookingTTL))
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to update ticket type")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachTicketTypes loads the tiers of the given events, cheapest first.
+func (r *Repository) attachTicketTypes(ctx context.Context, events ...*event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*event.Event, len(events))
+	args := make([]any, 0, len(events))
+	placeholders := make([]string, 0, len(events))
+	for _, ev := range events {
+		ev.TicketTypes = []*event.TicketType{}
+		byID[ev.ID] = ev
+		args = append(args, ev.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := `SELECT ` + ticketTypeColumns + ` FROM ticket_types
+		WHERE event_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY price, name`
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to load ticket types")
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		t, err := scanTicketType(rows)
+		if err != nil {
+			return err
+		}
+		if ev, ok := byID[t.EventID]; ok {
+			ev.TicketTypes = append(ev.TicketTypes, t)
+		}
+	}
+
+	return rows.Err()
+}
+
+// lockTicketTypes loads and locks the tiers of an event inside tx.
+func (r *Repository) lockTicketTypes(ctx context.Context, tx *sql.Tx, ev *event.Event) error {
+	query := `SELECT ` + ticketTypeColumns + ` FROM ticket_types WHERE event_id = $1 ORDER BY price, name FOR UPDATE`
+
+	return retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		ev.TicketTypes = []*event.TicketType{}
+
+		rows, err := tx.QueryContext(ctx, query, ev.ID)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			t, err := scanTicketType(rows)
+			if err != nil {
+				return err
+			}
+			ev.TicketTypes = append(ev.TicketTypes, t)
+		}
+		return rows.Err()
+	})
+}
+
+// moveTierSeats adds delta seats to a tier inside tx. A negative delta reserves
+// seats and fails with event.ErrSoldOut when the tier has too few left.
+func (r *Repository) moveTierSeats(ctx context.Context, tx *sql.Tx, ticketTypeID uuid.UUID, delta int) error {
+	query := `
+		UPDATE ticket_types
+		SET available_seats = available_seats + $1
+		WHERE id = $2 AND available_seats + $1 >= 0
+	`
+
+	var soldOut bool
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		result, err := tx.ExecContext(ctx, query, delta, ticketTypeID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		soldOut = rows == 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if soldOut {
+		return event.ErrSoldOut
+	}
+
+	return nil
+}
@@ -5,18 +5,31 @@ import (
 	"database/sql"
 	"errors"
 
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
 	"eventbooker/internal/domain/user"
 
+	"github.com/google/uuid"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
+const userColumns = `id, login, password, created_at, email, telegram, locale, role, email_verified, email_notification, telegram_notification`
+
+func scanUser(row rowScanner) (*user.User, error) {
+	var u user.User
+	if err := row.Scan(&u.ID, &u.Login, &u.Password, &u.CreatedAt, &u.Email, &u.Telegram, &u.Locale, &u.Role, &u.EmailVerified, &u.EmailNotification, &u.TelegramNotification); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 // GetUser retrieves a user by login.
 func (r *Repository) GetUser(ctx context.Context, login string) (*user.User, error) {
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	query := `SELECT id, login, password, created_at, email, telegram FROM users WHERE login = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE login = $1`
 
 	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, login)
 	if err != nil {
@@ -24,17 +37,16 @@ func (r *Repository) GetUser(ctx context.Context, login string) (*user.User, err
 		return nil, err
 	}
 
-	var u user.User
-	err = row.Scan(&u.ID, &u.Login, &u.Password, &u.CreatedAt, &u.Email, &u.Telegram)
+	u, err := scanUser(row)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("user not found")
+		return nil, user.ErrNotFound
 	}
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to scan user row")
 		return nil, err
 	}
 
-	return &u, nil
+	return u, nil
 }
 
 // GetUserByUUID retrieves a user by UUID.
@@ -42,7 +54,7 @@ func (r *Repository) GetUserByUUID(ctx context.Context, id string) (*user.User,
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	query := `SELECT id, login, password, created_at, email, telegram FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
 	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
 	if err != nil {
@@ -50,17 +62,43 @@ func (r *Repository) GetUserByUUID(ctx context.Context, id string) (*user.User,
 		return nil, err
 	}
 
-	var u user.User
-	err = row.Scan(&u.ID, &u.Login, &u.Password, &u.CreatedAt, &u.Email, &u.Telegram)
+	u, err := scanUser(row)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("user not found")
+		return nil, user.ErrNotFound
 	}
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to scan user row")
 		return nil, err
 	}
 
-	return &u, nil
+	return u, nil
+}
+
+// GetUserByTelegram retrieves the user who registered the Telegram chat ID. When
+// several accounts share the chat, the oldest one is returned.
+func (r *Repository) GetUserByTelegram(ctx context.Context, chatID string) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE telegram = $1 ORDER BY created_at, id LIMIT 1`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, chatID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute get user by telegram query")
+		return nil, err
+	}
+
+	u, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to scan user row")
+		return nil, err
+	}
+
+	return u, nil
 }
 
 // SaveUser inserts a new user.
@@ -68,10 +106,11 @@ func (r *Repository) SaveUser(ctx context.Context, u *user.User) error {
 	ctx, cancel := r.withTimeout(ctx)
 	defer cancel()
 
-	query := `INSERT INTO users (id, login, password, created_at, email, telegram) VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO users (id, login, password, created_at, email, telegram, locale, role, email_verified, email_notification, telegram_notification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
 	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
-		u.ID, u.Login, u.Password, u.CreatedAt, u.Email, u.Telegram,
+		u.ID, u.Login, u.Password, u.CreatedAt, u.Email, u.Telegram, u.Locale, u.Role, u.EmailVerified, u.EmailNotification, u.TelegramNotification,
 	)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("failed to insert user")
@@ -80,3 +119,316 @@ func (r *Repository) SaveUser(ctx context.Context, u *user.User) error {
 
 	return nil
 }
+
+// SetUserRole changes the role of a user and returns the updated user.
+func (r *Repository) SetUserRole(ctx context.Context, id string, role user.Role) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET role = $2 WHERE id = $1 RETURNING ` + userColumns
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, role)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute set user role query")
+		return nil, err
+	}
+
+	u, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to set user role")
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// UpdateUser stores the profile fields of a user and returns the updated user. The
+// active bookings of the user move to the new email and Telegram chat in the same
+// transaction, and stop notifying a channel that was removed.
+func (r *Repository) UpdateUser(ctx context.Context, u *user.User) (*user.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in update_user")
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE users
+		SET email = $2, telegram = $3, locale = $4, email_verified = $5, email_notification = $6, telegram_notification = $7
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	var (
+		updated  *user.User
+		notFound bool
+	)
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		updated, err = scanUser(tx.QueryRowContext(ctx, query,
+			u.ID, u.Email, u.Telegram, u.Locale, u.EmailVerified, u.EmailNotification, u.TelegramNotification,
+		))
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to update user")
+		return nil, err
+	}
+	if notFound {
+		return nil, user.ErrNotFound
+	}
+
+	active := []any{booking.StatusCreated, booking.StatusConfirmed}
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE bookings SET email_recepient = $2, email_notification = email_notification AND $2 <> ''
+			WHERE user_id = $1 AND status IN ($3, $4)`, append([]any{u.ID, updated.Email}, active...)},
+		{`UPDATE bookings SET telegram_recepient = $2, telegram_notification = telegram_notification AND $2 <> ''
+			WHERE user_id = $1 AND status IN ($3, $4)`, append([]any{u.ID, updated.Telegram}, active...)},
+	}
+	for _, st := range statements {
+		err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, st.query, st.args...)
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to move active bookings to the new recipients")
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit update_user transaction")
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// ChangePassword stores the new password hash of a user and revokes all their
+// sessions.
+func (r *Repository) ChangePassword(ctx context.Context, id string, password []byte) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in change_password")
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = r.setPassword(ctx, tx, id, password); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit change_password transaction")
+		return err
+	}
+
+	return nil
+}
+
+// setPassword stores the password hash of a user inside tx and revokes all their
+// sessions, so whoever knew the old password is logged out.
+func (r *Repository) setPassword(ctx context.Context, tx *sql.Tx, id string, password []byte) (*user.User, error) {
+	updateQuery := `UPDATE users SET password = $1 WHERE id = $2 RETURNING ` + userColumns
+
+	var u *user.User
+	err := retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var err error
+		u, err = scanUser(tx.QueryRowContext(ctx, updateQuery, password, id))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to update password")
+		return nil, err
+	}
+
+	revokeQuery := `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, revokeQuery, id)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to revoke refresh tokens after password change")
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// DeleteUser deletes a user together with everything they own. Upcoming events of
+// the user are cancelled as by CancelEvent, and the cancelled bookings of other
+// users are returned in cancelled. Active bookings of the user for events that have
+// not started are cancelled too, so their seats go back to the events and to their
+// waitlists; the resulting hold bookings of other users are returned in promoted.
+// Past and cancelled events stay with their bookings and lose their creator.
+func (r *Repository) DeleteUser(ctx context.Context, id string) (promoted, cancelled []*booking.Booking, err error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	uID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot start transaction in delete_user")
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The lock keeps the user from creating events or bookings meanwhile.
+	lockQuery := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	var notFound bool
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		var locked uuid.UUID
+		err := tx.QueryRowContext(ctx, lockQuery, id).Scan(&locked)
+		notFound = errors.Is(err, sql.ErrNoRows)
+		if notFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to lock user for deletion")
+		return nil, nil, err
+	}
+	if notFound {
+		return nil, nil, user.ErrNotFound
+	}
+
+	eventsQuery := `SELECT id FROM events WHERE creator_id = $1 AND status <> $2 AND date > now() ORDER BY id`
+
+	var eventIDs []string
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		eventIDs = nil
+
+		rows, err := tx.QueryContext(ctx, eventsQuery, id, event.StatusCancelled)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var eventID string
+			if err = rows.Scan(&eventID); err != nil {
+				return err
+			}
+			eventIDs = append(eventIDs, eventID)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to get upcoming events of deleted user")
+		return nil, nil, err
+	}
+
+	for _, eventID := range eventIDs {
+		ev, err := r.lockEvent(ctx, tx, eventID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = ev.CancelBy(uID); err != nil {
+			return nil, nil, err
+		}
+		bookings, err := r.cancelEvent(ctx, tx, ev)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, b := range bookings {
+			// Bookings of the user are deleted with the account.
+			if b.UserID != uID {
+				cancelled = append(cancelled, b)
+			}
+		}
+	}
+
+	// Released seats must not be handed to the user being deleted.
+	leaveWaitlistQuery := `DELETE FROM waitlist WHERE user_id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, leaveWaitlistQuery, id)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to remove waitlist entries of deleted user")
+		return nil, nil, err
+	}
+
+	activeQuery := `
+		SELECT b.id, b.event_id, b.ticket_type_id, b.count
+		FROM bookings b
+		JOIN events e ON e.id = b.event_id
+		WHERE b.user_id = $1 AND b.status IN ($2, $3) AND e.date > now()
+		FOR UPDATE OF b`
+
+	var active []*booking.Booking
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		active = nil
+
+		rows, err := tx.QueryContext(ctx, activeQuery, id, booking.StatusCreated, booking.StatusConfirmed)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var b booking.Booking
+			if err = rows.Scan(&b.ID, &b.EventID, &b.TicketTypeID, &b.Count); err != nil {
+				return err
+			}
+			active = append(active, &b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to get active bookings of deleted user")
+		return nil, nil, err
+	}
+
+	for _, b := range active {
+		p, err := r.releaseBooking(ctx, tx, b.ID.String(), b.EventID.String(), b.TicketTypeID, b.Count)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("failed to release booking %s of deleted user", b.ID)
+			return nil, nil, err
+		}
+		promoted = append(promoted, p...)
+	}
+
+	deleteQuery := `DELETE FROM users WHERE id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, deleteQuery, id)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to delete user")
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to commit delete_user transaction")
+		return nil, nil, err
+	}
+
+	return promoted, cancelled, nil
+}
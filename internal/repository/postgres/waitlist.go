@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/waitlist"
+
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// JoinWaitlist puts a user in the queue for an event and sets the entry's position.
+func (r *Repository) JoinWaitlist(ctx context.Context, e *waitlist.Entry) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	insertQuery := `
+		INSERT INTO waitlist (id, event_id, user_id, ticket_type_id, count, status, created_at, telegram_notification, email_notification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (event_id, user_id) WHERE status = 'waiting' DO NOTHING
+	`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, insertQuery,
+		e.ID, e.EventID, e.UserID, e.TicketTypeID, e.Count, e.Status, e.CreatedAt, e.TelegramNotification, e.EmailNotification,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert waitlist entry")
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return waitlist.ErrAlreadyJoined
+	}
+
+	positionQuery := `
+		SELECT count(*) FROM waitlist
+		WHERE event_id = $1 AND status = $2 AND (created_at, id) <= ($3, $4)
+	`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, positionQuery,
+		e.EventID, waitlist.StatusWaiting, e.CreatedAt, e.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return row.Scan(&e.Position)
+}
+
+// LeaveWaitlist removes a user's waiting entry for an event.
+func (r *Repository) LeaveWaitlist(ctx context.Context, eventID, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE waitlist SET status = $3 WHERE event_id = $1 AND user_id = $2 AND status = $4`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		eventID, userID, waitlist.StatusLeft, waitlist.StatusWaiting,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to leave waitlist")
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return waitlist.ErrNotFound
+	}
+
+	return nil
+}
+
+// waitingEntry is a queued entry joined with the contacts needed for its hold booking.
+type waitingEntry struct {
+	waitlist.Entry
+	telegram string
+	email    string
+	locale   string
+	verified bool
+}
+
+// promoteWaitlist turns waiting entries into hold bookings while their ticket types have
+// free seats. Entries are served in join order; an entry that does not fit keeps its place
+// and smaller entries behind it may still be served. Must run inside tx after seats were returned.
+func (r *Repository) promoteWaitlist(ctx context.Context, tx *sql.Tx, eventID string) ([]*booking.Booking, error) {
+	ev, err := r.lockEvent(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if ev.Status != event.StatusActive || ev.FreePlaces <= 0 {
+		return nil, nil
+	}
+
+	selectQuery := `
+		SELECT w.id, w.user_id, w.ticket_type_id, w.count, w.status, w.created_at, w.telegram_notification, w.email_notification,
+			COALESCE(u.telegram, ''), COALESCE(u.email, ''), u.locale, u.email_verified
+		FROM waitlist w JOIN users u ON u.id = w.user_id
+		WHERE w.event_id = $1 AND w.status = $2
+		ORDER BY w.created_at, w.id
+		FOR UPDATE OF w
+	`
+
+	var entries []*waitingEntry
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		entries = nil
+
+		rows, err := tx.QueryContext(ctx, selectQuery, ev.ID, waitlist.StatusWaiting)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			w := &waitingEntry{}
+			if err = rows.Scan(
+				&w.ID, &w.UserID, &w.TicketTypeID, &w.Count, &w.Status, &w.CreatedAt, &w.TelegramNotification, &w.EmailNotification,
+				&w.telegram, &w.email, &w.locale, &w.verified,
+			); err != nil {
+				return err
+			}
+			w.EventID = ev.ID
+			entries = append(entries, w)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to select waitlist entries")
+		return nil, err
+	}
+
+	promoteQuery := `UPDATE waitlist SET status = $2, booking_id = $3 WHERE id = $1`
+
+	var promoted []*booking.Booking
+	for _, w := range entries {
+		tier, err := ev.TicketType(w.TicketTypeID.String())
+		if err != nil {
+			return nil, err
+		}
+		if !w.Fits(tier.FreePlaces) {
+			continue
+		}
+		var held, heldSeats int
+		if ev.Limits.PerUser() {
+			if held, heldSeats, err = r.userHoldings(ctx, tx, ev.ID, w.UserID); err != nil {
+				return nil, err
+			}
+		}
+		// An entry over the limits of its user keeps its place until the user frees some.
+		if ev.Limits.Allow(w.Count, held, heldSeats) != nil {
+			continue
+		}
+
+		b, err := booking.New(ev.ID.String(), w.UserID.String(), w.telegram, w.email, ev.Name,
+			w.TelegramNotification, w.EmailNotification, w.Count, tier.BookingTTL, tier.Price)
+		if err != nil {
+			return nil, err
+		}
+		b.EventDate = ev.Date
+		b.Locale = w.locale
+		b.EmailVerified = w.verified
+		b.TicketTypeID = tier.ID
+		b.TicketTypeName = tier.Name
+		if tier.Price == 0 {
+			b.Confirm()
+		}
+
+		if err = r.insertBooking(ctx, tx, b); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to insert waitlist hold booking")
+			return nil, err
+		}
+
+		if err = r.scheduleExpiry(ctx, tx, b); err != nil {
+			return nil, err
+		}
+
+		w.Promote(b.ID)
+		err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+			_, err := tx.ExecContext(ctx, promoteQuery, w.ID, w.Status, w.BookingID)
+			return err
+		})
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("failed to promote waitlist entry")
+			return nil, err
+		}
+
+		tier.FreePlaces -= w.Count
+		ev.FreePlaces -= w.Count
+		promoted = append(promoted, b)
+	}
+
+	if len(promoted) == 0 {
+		return nil, nil
+	}
+
+	if err = r.updateTicketTypes(ctx, tx, ev.TicketTypes); err != nil {
+		return nil, err
+	}
+
+	seatsQuery := `UPDATE events SET available_seats = $2 WHERE id = $1`
+	err = retry.DoContext(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, func() error {
+		_, err := tx.ExecContext(ctx, seatsQuery, ev.ID, ev.FreePlaces)
+		return err
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to reserve seats for waitlist")
+		return nil, err
+	}
+
+	return promoted, nil
+}
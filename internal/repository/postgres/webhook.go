@@ -0,0 +1,328 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const subscriptionColumns = `id, user_id, url, secret, event_types, created_at`
+
+func scanSubscription(row rowScanner) (*webhook.Subscription, error) {
+	var (
+		s     webhook.Subscription
+		types []byte
+	)
+	if err := row.Scan(&s.ID, &s.UserID, &s.URL, &s.Secret, &types, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(types, &s.Types); err != nil {
+		return nil, fmt.Errorf("decode webhook %s event types: %w", s.ID, err)
+	}
+	return &s, nil
+}
+
+// CreateWebhook stores a new webhook subscription.
+func (r *Repository) CreateWebhook(ctx context.Context, s *webhook.Subscription) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	types, err := json.Marshal(s.Types)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO webhook_subscriptions (` + subscriptionColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		s.ID, s.UserID, s.URL, s.Secret, types, s.CreatedAt)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to insert webhook subscription")
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhook returns a webhook subscription by ID.
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*webhook.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	row, err := r.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to execute get webhook query")
+		return nil, err
+	}
+
+	s, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, webhook.ErrNotFound
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to scan webhook row")
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ListWebhooks returns the webhook subscriptions of a user, newest first.
+func (r *Repository) ListWebhooks(ctx context.Context, userID string) ([]*webhook.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE user_id = $1 ORDER BY created_at DESC, id`
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, userID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to list webhooks")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	subs := []*webhook.Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhook removes a webhook subscription together with its delivery history.
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM webhook_subscriptions WHERE id = $1`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to delete webhook")
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return webhook.ErrNotFound
+	}
+
+	return nil
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.subscription_id, d.message_id, d.event_type, d.payload, d.status, d.attempts,
+	d.last_status_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at, s.url, s.secret
+`
+
+func scanWebhookDelivery(row rowScanner) (*webhook.Delivery, error) {
+	var (
+		d           webhook.Delivery
+		statusCode  sql.NullInt64
+		lastError   sql.NullString
+		nextAttempt sql.NullTime
+		deliveredAt sql.NullTime
+	)
+	if err := row.Scan(
+		&d.ID, &d.SubscriptionID, &d.MessageID, &d.Type, &d.Payload, &d.Status, &d.Attempts,
+		&statusCode, &lastError, &nextAttempt, &d.CreatedAt, &deliveredAt, &d.URL, &d.Secret,
+	); err != nil {
+		return nil, err
+	}
+	d.LastStatusCode = int(statusCode.Int64)
+	d.LastError = lastError.String
+	d.NextAttemptAt = nextAttempt.Time
+	d.DeliveredAt = deliveredAt.Time
+	return &d, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]*webhook.Delivery, error) {
+	deliveries := []*webhook.Delivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a subscription, newest first.
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*webhook.Delivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.subscription_id = $1
+		ORDER BY d.created_at DESC, d.id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, subscriptionID, limit)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to list webhook deliveries")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// EnqueueWebhook creates a pending delivery of the message for every subscription of
+// the event's organizer that receives messages of type t, and returns their number.
+func (r *Repository) EnqueueWebhook(ctx context.Context, eventID uuid.UUID, t webhook.Type, messageID uuid.UUID, payload []byte) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhook_deliveries (id, subscription_id, message_id, event_type, payload, status, next_attempt_at)
+		SELECT gen_random_uuid(), s.id, $2, $3, $4, $5, now()
+		FROM webhook_subscriptions s JOIN events e ON e.creator_id = s.user_id
+		WHERE e.id = $1 AND s.event_types ? $3
+	`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		eventID, messageID, t, payload, webhook.DeliveryPending)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to enqueue webhook deliveries")
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ClaimWebhookDeliveries leases up to limit due pending or failed deliveries, oldest
+// first, and counts the attempt. Rows claimed concurrently are skipped.
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*webhook.Delivery, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH due AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = now() + $2 * interval '1 millisecond', attempts = attempts + 1
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status IN ($3, $4) AND next_attempt_at <= now()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `
+		FROM due d JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	`
+
+	rows, err := r.db.QueryWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		limit, lease.Milliseconds(), webhook.DeliveryPending, webhook.DeliveryFailed)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim webhook deliveries")
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// MarkWebhookDelivered records a successful delivery.
+func (r *Repository) MarkWebhookDelivered(ctx context.Context, id uuid.UUID, statusCode int) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, last_status_code = $3, last_error = NULL, next_attempt_at = NULL, delivered_at = now()
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query, id, webhook.DeliveryDelivered, statusCode)
+	return err
+}
+
+// MarkWebhookFailed records a failed attempt and schedules the next one.
+func (r *Repository) MarkWebhookFailed(ctx context.Context, id uuid.UUID, statusCode int, reason string, retryIn time.Duration) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = now() + $5 * interval '1 millisecond'
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		id, webhook.DeliveryFailed, nullStatusCode(statusCode), reason, retryIn.Milliseconds())
+	return err
+}
+
+// AbandonWebhook records the last failed attempt of a delivery; it is not retried again.
+func (r *Repository) AbandonWebhook(ctx context.Context, id uuid.UUID, statusCode int, reason string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = NULL
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		id, webhook.DeliveryAbandoned, nullStatusCode(statusCode), reason)
+	return err
+}
+
+// PruneWebhookDeliveries deletes up to limit delivered or abandoned deliveries created
+// more than olderThan ago and returns how many were deleted. Abandoned deliveries carry
+// no finish time, so their age is counted from creation.
+func (r *Repository) PruneWebhookDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM webhook_deliveries
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ($3, $4) AND created_at < now() - $1 * interval '1 millisecond'
+			LIMIT $2
+		)
+	`
+
+	res, err := r.db.ExecWithRetry(ctx, retry.Strategy{Attempts: r.retry.Attempts, Delay: r.retry.Delay, Backoff: r.retry.Backoffs}, query,
+		olderThan.Milliseconds(), limit, webhook.DeliveryDelivered, webhook.DeliveryAbandoned)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune webhook deliveries")
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// nullStatusCode stores a missing response, such as a timeout, as NULL.
+func nullStatusCode(code int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(code), Valid: code != 0}
+}
@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/config"
 	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
 	"eventbooker/internal/domain/user"
+	"eventbooker/internal/domain/waitlist"
+	"eventbooker/internal/notification"
 
 	"github.com/google/uuid"
 	wbzlog "github.com/wb-go/wbf/zlog"
@@ -16,35 +19,47 @@ import (
 // BookingRepository defines the storage operations needed by BookingService.
 type BookingRepository interface {
 	CreateBooking(ctx context.Context, b *booking.Booking) error
-	ConfirmBooking(ctx context.Context, id string) error
+	ConfirmBooking(ctx context.Context, id, userID string) error
+	GetBooking(ctx context.Context, id string) (*booking.Booking, error)
+	ListUserBookings(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error)
+	CancelBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error)
 	GetEvent(ctx context.Context, eventID string) (*event.Event, error)
 	GetUserByUUID(ctx context.Context, id string) (*user.User, error)
+	JoinWaitlist(ctx context.Context, e *waitlist.Entry) error
+	LeaveWaitlist(ctx context.Context, eventID, userID string) error
 }
 
-// BookingBroker defines the message broker operations needed by BookingService.
-type BookingBroker interface {
-	PublishMsg(ctx context.Context, b *booking.Booking) error
+// Notifier sends booking notifications to their owners.
+type Notifier interface {
+	Notify(kind notification.Kind, b *booking.Booking)
 }
 
 // BookingService handles booking business logic.
 type BookingService struct {
-	repo   BookingRepository
-	broker BookingBroker
+	repo     BookingRepository
+	notifier Notifier
+	cfg      *config.BookingConfig
+	// requireVerified rejects bookings of users whose email is not verified yet.
+	requireVerified bool
 }
 
-// NewBookingService creates a new BookingService.
-func NewBookingService(repo BookingRepository, broker BookingBroker) *BookingService {
+// NewBookingService creates a new BookingService. Expiry of pending bookings is
+// scheduled by the repository through the outbox.
+func NewBookingService(repo BookingRepository, notifier Notifier, cfg *config.BookingConfig, verification *config.EmailVerificationConfig) *BookingService {
 	return &BookingService{
-		repo:   repo,
-		broker: broker,
+		repo:            repo,
+		notifier:        notifier,
+		cfg:             cfg,
+		requireVerified: verification.BlocksBookings(),
 	}
 }
 
-// Create creates a new booking.
-func (s *BookingService) Create(ctx context.Context, eventID, userID string, telegramNotification, emailNotification bool, count int) (*booking.Booking, error) {
+// Create creates a new booking. Notification channels left nil take the defaults
+// from the profile of the user.
+func (s *BookingService) Create(ctx context.Context, eventID, userID, ticketTypeID string, telegramNotification, emailNotification *bool, count int) (*booking.Booking, error) {
 	if _, err := uuid.Parse(eventID); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("invalid event id")
-		return nil, err
+		return nil, invalidID("event_id", err)
 	}
 
 	ev, err := s.repo.GetEvent(ctx, eventID)
@@ -52,32 +67,54 @@ func (s *BookingService) Create(ctx context.Context, eventID, userID string, tel
 		return nil, err
 	}
 
-	if ev.FreePlaces-count < 0 {
-		return nil, errors.New("not enough available seats")
+	if ev.Status == event.StatusCancelled {
+		return nil, event.ErrCancelled
+	}
+
+	tier, err := ev.TicketType(ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tier.FreePlaces-count < 0 {
+		return nil, event.ErrSoldOut
+	}
+
+	// Per-user limits are checked by the repository together with the seats.
+	if err = ev.Limits.CheckCount(count); err != nil {
+		return nil, err
 	}
 
 	if _, err := uuid.Parse(userID); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
-		return nil, err
+		return nil, invalidID("user_id", err)
 	}
 
 	u, err := s.repo.GetUserByUUID(ctx, userID)
 	if err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("user not found")
-		return nil, fmt.Errorf("user not found")
+		return nil, err
 	}
 	if u == nil {
 		wbzlog.Logger.Debug().Msg("user is nil")
-		return nil, fmt.Errorf("user not found")
+		return nil, user.ErrNotFound
+	}
+	if err = s.checkVerified(u); err != nil {
+		return nil, err
 	}
 
-	b, err := booking.New(eventID, userID, u.Telegram, u.Email, ev.Name, telegramNotification, emailNotification, count, ev.BookingTTL, ev.Price)
+	tg, email := u.Channels(telegramNotification, emailNotification)
+	b, err := booking.New(eventID, userID, u.Telegram, u.Email, ev.Name, tg, email, count, tier.BookingTTL, tier.Price)
 	if err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("cannot create booking")
 		return nil, err
 	}
+	b.TicketTypeID = tier.ID
+	b.TicketTypeName = tier.Name
+	b.Locale = u.Locale
+	b.EmailVerified = u.EmailVerified
 
-	if ev.Price == 0 {
+	if tier.Price == 0 {
 		b.Confirm()
 	}
 
@@ -85,21 +122,174 @@ func (s *BookingService) Create(ctx context.Context, eventID, userID string, tel
 		return nil, err
 	}
 
-	if ev.Price != 0 {
-		if err = s.broker.PublishMsg(ctx, b); err != nil {
-			return nil, err
-		}
+	if b.Status == booking.StatusConfirmed {
+		s.notifier.Notify(notification.KindBookingConfirmed, b)
+	} else {
+		s.notifier.Notify(notification.KindBookingCreated, b)
 	}
 
 	return b, nil
 }
 
-// Confirm confirms an existing booking.
-func (s *BookingService) Confirm(ctx context.Context, id string) error {
+// Confirm confirms a pending booking owned by the given user.
+func (s *BookingService) Confirm(ctx context.Context, id, userID string) error {
 	if _, err := uuid.Parse(id); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("invalid booking id")
+		return invalidID("booking_id", err)
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return invalidID("user_id", err)
+	}
+
+	if err := s.repo.ConfirmBooking(ctx, id, userID); err != nil {
 		return err
 	}
 
-	return s.repo.ConfirmBooking(ctx, id)
+	b, err := s.repo.GetBooking(ctx, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot load confirmed booking %s for notification", id)
+		return nil
+	}
+	s.notifier.Notify(notification.KindBookingConfirmed, b)
+
+	return nil
+}
+
+// Get returns a booking owned by the given user.
+func (s *BookingService) Get(ctx context.Context, id, userID string) (*booking.Booking, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid booking id")
+		return nil, invalidID("booking_id", err)
+	}
+
+	b, err := s.repo.GetBooking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.UserID.String() != userID {
+		wbzlog.Logger.Debug().Msgf("booking %s requested by non-owner %s", id, userID)
+		return nil, booking.ErrNotFound
+	}
+
+	return b, nil
+}
+
+// List returns the bookings of a user, optionally filtered by status.
+func (s *BookingService) List(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	for _, st := range statuses {
+		if !st.Valid() {
+			return nil, apperr.Validation("invalid_status", fmt.Sprintf("unknown booking status %q", st))
+		}
+	}
+
+	return s.repo.ListUserBookings(ctx, userID, statuses)
+}
+
+// Cancel cancels a booking on behalf of its owner and returns the seats to the event.
+func (s *BookingService) Cancel(ctx context.Context, id, userID string) (*booking.Booking, error) {
+	b, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.Cancel(s.cfg.CancelCutoff); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msgf("cannot cancel booking %s", id)
+		return nil, err
+	}
+
+	promoted, err := s.repo.CancelBooking(ctx, b.ID.String(), b.EventID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifier.Notify(notification.KindBookingCancelled, b)
+	for _, p := range promoted {
+		s.notifier.Notify(notification.KindBookingPromoted, p)
+	}
+
+	return b, nil
+}
+
+// JoinWaitlist queues a user for seats of a sold-out ticket type. Notification
+// channels left nil take the defaults from the profile of the user.
+func (s *BookingService) JoinWaitlist(ctx context.Context, eventID, userID, ticketTypeID string, telegramNotification, emailNotification *bool, count int) (*waitlist.Entry, error) {
+	e, err := waitlist.New(eventID, userID, false, false, count)
+	if err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("cannot create waitlist entry")
+		return nil, err
+	}
+
+	ev, err := s.repo.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ev.Status == event.StatusCancelled {
+		return nil, event.ErrCancelled
+	}
+
+	tier, err := ev.TicketType(ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	e.TicketTypeID = tier.ID
+
+	if count > tier.MaxCountPeople {
+		return nil, apperr.Validation("invalid_count", fmt.Sprintf("count must not exceed ticket type capacity of %d", tier.MaxCountPeople))
+	}
+
+	if err = ev.Limits.CheckCount(count); err != nil {
+		return nil, err
+	}
+
+	if tier.FreePlaces >= count {
+		return nil, waitlist.ErrSeatsAvailable
+	}
+
+	// The profile is only needed for default channels and, since a waitlisted user
+	// is booked without asking again, for the verification check.
+	u := &user.User{}
+	if s.requireVerified || telegramNotification == nil || emailNotification == nil {
+		if u, err = s.repo.GetUserByUUID(ctx, userID); err != nil {
+			return nil, err
+		}
+		if err = s.checkVerified(u); err != nil {
+			return nil, err
+		}
+	}
+	e.TelegramNotification, e.EmailNotification = u.Channels(telegramNotification, emailNotification)
+
+	if err = s.repo.JoinWaitlist(ctx, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// checkVerified rejects users with an unverified email when the policy requires it.
+// Accounts without an email have nothing to verify.
+func (s *BookingService) checkVerified(u *user.User) error {
+	if s.requireVerified && u.Email != "" && !u.EmailVerified {
+		wbzlog.Logger.Debug().Msgf("booking by user %s with unverified email", u.ID)
+		return user.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// LeaveWaitlist removes a user from the waitlist of an event.
+func (s *BookingService) LeaveWaitlist(ctx context.Context, eventID, userID string) error {
+	if _, err := uuid.Parse(eventID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid event id")
+		return invalidID("event_id", err)
+	}
+
+	return s.repo.LeaveWaitlist(ctx, eventID, userID)
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/config"
 	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
 	"eventbooker/internal/domain/user"
+	"eventbooker/internal/domain/waitlist"
+	"eventbooker/internal/notification"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,8 +24,16 @@ type mockBookingRepo struct{ mock.Mock }
 func (m *mockBookingRepo) CreateBooking(ctx context.Context, b *booking.Booking) error {
 	return m.Called(b).Error(0)
 }
-func (m *mockBookingRepo) ConfirmBooking(ctx context.Context, id string) error {
-	return m.Called(id).Error(0)
+func (m *mockBookingRepo) ConfirmBooking(ctx context.Context, id, userID string) error {
+	return m.Called(id, userID).Error(0)
+}
+func (m *mockBookingRepo) GetBooking(ctx context.Context, id string) (*booking.Booking, error) {
+	args := m.Called(id)
+	return args.Get(0).(*booking.Booking), args.Error(1)
+}
+func (m *mockBookingRepo) ListUserBookings(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+	args := m.Called(userID, statuses)
+	return args.Get(0).([]*booking.Booking), args.Error(1)
 }
 func (m *mockBookingRepo) GetEvent(ctx context.Context, eventID string) (*event.Event, error) {
 	args := m.Called(eventID)
@@ -31,143 +44,509 @@ func (m *mockBookingRepo) GetUserByUUID(ctx context.Context, id string) (*user.U
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-type mockBroker struct{ mock.Mock }
+func (m *mockBookingRepo) CancelBooking(ctx context.Context, bookingID, eventID string) ([]*booking.Booking, error) {
+	args := m.Called(bookingID, eventID)
+	promoted, _ := args.Get(0).([]*booking.Booking)
+	return promoted, args.Error(1)
+}
+func (m *mockBookingRepo) JoinWaitlist(ctx context.Context, e *waitlist.Entry) error {
+	return m.Called(e).Error(0)
+}
+func (m *mockBookingRepo) LeaveWaitlist(ctx context.Context, eventID, userID string) error {
+	return m.Called(eventID, userID).Error(0)
+}
 
-func (m *mockBroker) PublishMsg(ctx context.Context, b *booking.Booking) error {
-	return m.Called(b).Error(0)
+type mockNotifier struct{ mock.Mock }
+
+func (m *mockNotifier) Notify(kind notification.Kind, b *booking.Booking) {
+	m.Called(kind, b)
+}
+
+// quietNotifier accepts any notification without asserting on it.
+func quietNotifier() *mockNotifier {
+	n := new(mockNotifier)
+	n.On("Notify", mock.Anything, mock.Anything).Maybe()
+	return n
+}
+
+// flag returns a pointer to a notification channel choice.
+func flag(v bool) *bool { return &v }
+
+func newTestBookingService(repo *mockBookingRepo) *BookingService {
+	return NewBookingService(repo, quietNotifier(), &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
+}
+
+// withTier gives a test event a single ticket type mirroring its event-level fields.
+func withTier(ev *event.Event) *event.Event {
+	ev.TicketTypes = []*event.TicketType{{
+		ID:             uuid.New(),
+		EventID:        ev.ID,
+		Name:           event.DefaultTicketTypeName,
+		Price:          ev.Price,
+		MaxCountPeople: ev.MaxCountPeople,
+		FreePlaces:     ev.FreePlaces,
+		BookingTTL:     ev.BookingTTL,
+	}}
+	return ev
 }
 
 func TestBookingService_Create_Success(t *testing.T) {
 	repo := new(mockBookingRepo)
-	broker := new(mockBroker)
-	svc := NewBookingService(repo, broker)
+	notifier := new(mockNotifier)
+	svc := NewBookingService(repo, notifier, &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
 
 	eventID := uuid.New()
 	userID := uuid.New()
 
-	ev := &event.Event{ID: eventID, Name: "Test", FreePlaces: 10, Price: 100, BookingTTL: 1}
+	ev := withTier(&event.Event{ID: eventID, Name: "Test", FreePlaces: 10, Price: 100, BookingTTL: 1 * time.Minute})
 	u := &user.User{ID: userID, Telegram: "@test", Email: "test@example.com"}
 
 	repo.On("GetEvent", eventID.String()).Return(ev, nil)
 	repo.On("GetUserByUUID", userID.String()).Return(u, nil)
 	repo.On("CreateBooking", mock.Anything).Return(nil)
-	broker.On("PublishMsg", mock.Anything).Return(nil)
+	notifier.On("Notify", notification.KindBookingCreated, mock.Anything).Return()
 
-	b, err := svc.Create(context.Background(), eventID.String(), userID.String(), true, true, 2)
+	b, err := svc.Create(context.Background(), eventID.String(), userID.String(), "", flag(true), flag(true), 2)
 	assert.NoError(t, err)
-	assert.NotNil(t, b)
+	assert.Equal(t, booking.StatusCreated, b.Status)
 	repo.AssertExpectations(t)
-	broker.AssertExpectations(t)
+	notifier.AssertExpectations(t)
 }
 
 func TestBookingService_Create_InvalidEventID(t *testing.T) {
-	svc := NewBookingService(new(mockBookingRepo), new(mockBroker))
-	b, err := svc.Create(context.Background(), "invalid-id", uuid.New().String(), true, true, 1)
+	svc := newTestBookingService(new(mockBookingRepo))
+	b, err := svc.Create(context.Background(), "invalid-id", uuid.New().String(), "", flag(true), flag(true), 1)
 	assert.Error(t, err)
 	assert.Nil(t, b)
 }
 
 func TestBookingService_Create_GetEventError(t *testing.T) {
 	repo := new(mockBookingRepo)
-	svc := NewBookingService(repo, new(mockBroker))
+	svc := newTestBookingService(repo)
 	eventID := uuid.New().String()
 	repo.On("GetEvent", eventID).Return((*event.Event)(nil), errors.New("db error"))
-	b, err := svc.Create(context.Background(), eventID, uuid.New().String(), true, true, 1)
+	b, err := svc.Create(context.Background(), eventID, uuid.New().String(), "", flag(true), flag(true), 1)
 	assert.Error(t, err)
 	assert.Nil(t, b)
 }
 
 func TestBookingService_Create_InvalidUserID(t *testing.T) {
 	repo := new(mockBookingRepo)
-	svc := NewBookingService(repo, new(mockBroker))
+	svc := newTestBookingService(repo)
 	eventID := uuid.New()
-	ev := &event.Event{ID: eventID, Name: "Test", FreePlaces: 10, Price: 100, BookingTTL: 1}
+	ev := withTier(&event.Event{ID: eventID, Name: "Test", FreePlaces: 10, Price: 100, BookingTTL: 1 * time.Minute})
 	repo.On("GetEvent", eventID.String()).Return(ev, nil)
-	_, err := svc.Create(context.Background(), eventID.String(), "invalid-uuid", false, false, 1)
+	_, err := svc.Create(context.Background(), eventID.String(), "invalid-uuid", "", flag(false), flag(false), 1)
 	assert.Error(t, err)
 }
 
 func TestBookingService_Create_GetUserError(t *testing.T) {
 	repo := new(mockBookingRepo)
-	svc := NewBookingService(repo, new(mockBroker))
+	svc := newTestBookingService(repo)
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	ev := &event.Event{ID: uuid.New(), FreePlaces: 5, Name: "Test"}
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 5, Name: "Test"})
 	repo.On("GetEvent", eventID).Return(ev, nil)
-	repo.On("GetUserByUUID", userID).Return((*user.User)(nil), errors.New("user not found"))
-	b, err := svc.Create(context.Background(), eventID, userID, true, true, 1)
+	repo.On("GetUserByUUID", userID).Return((*user.User)(nil), user.ErrNotFound)
+	b, err := svc.Create(context.Background(), eventID, userID, "", flag(true), flag(true), 1)
 	assert.Error(t, err)
 	assert.Nil(t, b)
 }
 
 func TestBookingService_Create_UserNil(t *testing.T) {
 	repo := new(mockBookingRepo)
-	svc := NewBookingService(repo, new(mockBroker))
+	svc := newTestBookingService(repo)
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	ev := &event.Event{ID: uuid.New(), FreePlaces: 5, Name: "Test"}
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 5, Name: "Test"})
 	repo.On("GetEvent", eventID).Return(ev, nil)
 	repo.On("GetUserByUUID", userID).Return((*user.User)(nil), nil)
-	b, err := svc.Create(context.Background(), eventID, userID, true, true, 1)
+	b, err := svc.Create(context.Background(), eventID, userID, "", flag(true), flag(true), 1)
 	assert.Error(t, err)
 	assert.Nil(t, b)
 }
 
 func TestBookingService_Create_CreateBookingError(t *testing.T) {
 	repo := new(mockBookingRepo)
-	broker := new(mockBroker)
-	svc := NewBookingService(repo, broker)
+	svc := newTestBookingService(repo)
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	ev := &event.Event{ID: uuid.New(), FreePlaces: 10, Price: 10, Name: "Test"}
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 10, Price: 10, Name: "Test"})
 	u := &user.User{ID: uuid.New(), Telegram: "@test", Email: "mail@example.com"}
 	repo.On("GetEvent", eventID).Return(ev, nil)
 	repo.On("GetUserByUUID", userID).Return(u, nil)
 	repo.On("CreateBooking", mock.Anything).Return(errors.New("insert error"))
-	b, err := svc.Create(context.Background(), eventID, userID, true, true, 2)
+	b, err := svc.Create(context.Background(), eventID, userID, "", flag(true), flag(true), 2)
 	assert.Error(t, err)
 	assert.Nil(t, b)
 }
 
-func TestBookingService_Create_PublishMsgError(t *testing.T) {
+func TestBookingService_Create_FreeEvent_Confirmed(t *testing.T) {
 	repo := new(mockBookingRepo)
-	broker := new(mockBroker)
-	svc := NewBookingService(repo, broker)
+	notifier := new(mockNotifier)
+	svc := NewBookingService(repo, notifier, &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
 	eventID := uuid.New().String()
 	userID := uuid.New().String()
-	ev := &event.Event{ID: uuid.New(), FreePlaces: 10, Price: 10, Name: "Test"}
-	u := &user.User{ID: uuid.New(), Telegram: "@test", Email: "mail@example.com"}
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 10, Price: 0, Name: "Free Event"})
+	u := &user.User{ID: uuid.New(), Telegram: "@x", Email: "y"}
 	repo.On("GetEvent", eventID).Return(ev, nil)
 	repo.On("GetUserByUUID", userID).Return(u, nil)
 	repo.On("CreateBooking", mock.Anything).Return(nil)
-	broker.On("PublishMsg", mock.Anything).Return(errors.New("broker error"))
-	b, err := svc.Create(context.Background(), eventID, userID, true, true, 1)
-	assert.Error(t, err)
-	assert.Nil(t, b)
+	notifier.On("Notify", notification.KindBookingConfirmed, mock.Anything).Return()
+	b, err := svc.Create(context.Background(), eventID, userID, "", flag(true), flag(true), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, booking.StatusConfirmed, b.Status)
+	notifier.AssertExpectations(t)
 }
 
-func TestBookingService_Create_FreeEvent_NoPublish(t *testing.T) {
+func TestBookingService_Create_TicketType(t *testing.T) {
 	repo := new(mockBookingRepo)
-	broker := new(mockBroker)
-	svc := NewBookingService(repo, broker)
-	eventID := uuid.New().String()
+	svc := newTestBookingService(repo)
+	eventID := uuid.New()
 	userID := uuid.New().String()
-	ev := &event.Event{ID: uuid.New(), FreePlaces: 10, Price: 0, Name: "Free Event"}
-	u := &user.User{ID: uuid.New(), Telegram: "@x", Email: "y"}
-	repo.On("GetEvent", eventID).Return(ev, nil)
-	repo.On("GetUserByUUID", userID).Return(u, nil)
+	standard := &event.TicketType{ID: uuid.New(), Name: "standard", Price: 10, MaxCountPeople: 50, FreePlaces: 50, BookingTTL: 15 * time.Minute}
+	vip := &event.TicketType{ID: uuid.New(), Name: "vip", Price: 50, MaxCountPeople: 5, FreePlaces: 5, BookingTTL: 30 * time.Minute}
+	ev := &event.Event{ID: eventID, Name: "Test", FreePlaces: 55, Price: 10, TicketTypes: []*event.TicketType{standard, vip}}
+	repo.On("GetEvent", eventID.String()).Return(ev, nil)
+	repo.On("GetUserByUUID", userID).Return(&user.User{}, nil)
 	repo.On("CreateBooking", mock.Anything).Return(nil)
-	b, err := svc.Create(context.Background(), eventID, userID, true, true, 1)
+
+	b, err := svc.Create(context.Background(), eventID.String(), userID, vip.ID.String(), flag(false), flag(false), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, vip.ID, b.TicketTypeID)
+	assert.Equal(t, "vip", b.TicketTypeName)
+	assert.Equal(t, float64(100), b.Price)
+}
+
+func TestBookingService_Create_TicketTypeRequired(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := &event.Event{ID: uuid.New(), FreePlaces: 10, TicketTypes: []*event.TicketType{{ID: uuid.New()}, {ID: uuid.New()}}}
+	repo.On("GetEvent", eventID).Return(ev, nil)
+
+	_, err := svc.Create(context.Background(), eventID, uuid.New().String(), "", flag(false), flag(false), 1)
+	assert.ErrorIs(t, err, event.ErrTicketTypeRequired)
+}
+
+func TestBookingService_Create_TicketTypeSoldOut(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	vip := &event.TicketType{ID: uuid.New(), Name: "vip", Price: 50, MaxCountPeople: 5, FreePlaces: 1, BookingTTL: 30 * time.Minute}
+	ev := &event.Event{ID: uuid.New(), FreePlaces: 40, TicketTypes: []*event.TicketType{{ID: uuid.New(), FreePlaces: 39}, vip}}
+	repo.On("GetEvent", eventID).Return(ev, nil)
+
+	_, err := svc.Create(context.Background(), eventID, uuid.New().String(), vip.ID.String(), flag(false), flag(false), 2)
+	assert.ErrorIs(t, err, event.ErrSoldOut)
+	repo.AssertNotCalled(t, "CreateBooking", mock.Anything)
+}
+
+func TestBookingService_Create_BookingTooLarge(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New()
+	ev := withTier(&event.Event{ID: eventID, FreePlaces: 10, Price: 100, BookingTTL: 1 * time.Minute, Limits: event.Limits{MaxSeatsPerBooking: 2}})
+	repo.On("GetEvent", eventID.String()).Return(ev, nil)
+
+	_, err := svc.Create(context.Background(), eventID.String(), uuid.New().String(), "", flag(false), flag(false), 3)
+	assert.ErrorIs(t, err, event.ErrBookingTooLarge)
+	repo.AssertNotCalled(t, "CreateBooking", mock.Anything)
+}
+
+func TestBookingService_Create_UserLimitReached(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID, userID := uuid.New(), uuid.New()
+	ev := withTier(&event.Event{ID: eventID, FreePlaces: 10, Price: 100, BookingTTL: 1 * time.Minute, Limits: event.Limits{MaxBookingsPerUser: 1}})
+	repo.On("GetEvent", eventID.String()).Return(ev, nil)
+	repo.On("GetUserByUUID", userID.String()).Return(&user.User{ID: userID}, nil)
+	repo.On("CreateBooking", mock.Anything).Return(event.ErrBookingLimitReached)
+
+	b, err := svc.Create(context.Background(), eventID.String(), userID.String(), "", flag(false), flag(false), 1)
+	assert.ErrorIs(t, err, event.ErrBookingLimitReached)
+	assert.Nil(t, b)
+}
+
+func TestBookingService_Confirm_Notifies(t *testing.T) {
+	repo := new(mockBookingRepo)
+	notifier := new(mockNotifier)
+	svc := NewBookingService(repo, notifier, &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
+	id := uuid.New().String()
+	userID := uuid.New().String()
+	b := &booking.Booking{Status: booking.StatusConfirmed, EventName: "Test", Count: 1}
+	repo.On("ConfirmBooking", id, userID).Return(nil)
+	repo.On("GetBooking", id).Return(b, nil)
+	notifier.On("Notify", notification.KindBookingConfirmed, b).Return()
+
+	err := svc.Confirm(context.Background(), id, userID)
 	assert.NoError(t, err)
-	assert.NotNil(t, b)
-	broker.AssertNotCalled(t, "PublishMsg", mock.Anything)
+	notifier.AssertExpectations(t)
 }
 
 func TestBookingService_Confirm_ErrorRepo(t *testing.T) {
 	repo := new(mockBookingRepo)
-	svc := NewBookingService(repo, new(mockBroker))
+	svc := newTestBookingService(repo)
+	id := uuid.New().String()
+	userID := uuid.New().String()
+	repo.On("ConfirmBooking", id, userID).Return(errors.New("db error"))
+	err := svc.Confirm(context.Background(), id, userID)
+	assert.Error(t, err)
+}
+
+func TestBookingService_Confirm_DomainError(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
 	id := uuid.New().String()
-	repo.On("ConfirmBooking", id).Return(errors.New("db error"))
-	err := svc.Confirm(context.Background(), id)
+	userID := uuid.New().String()
+	repo.On("ConfirmBooking", id, userID).Return(booking.ErrExpired)
+	err := svc.Confirm(context.Background(), id, userID)
+	assert.ErrorIs(t, err, booking.ErrExpired)
+}
+
+func TestBookingService_Confirm_InvalidUserID(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	err := svc.Confirm(context.Background(), uuid.New().String(), "invalid-uuid")
 	assert.Error(t, err)
+	repo.AssertNotCalled(t, "ConfirmBooking", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_Get_Success(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	userID := uuid.New()
+	b := &booking.Booking{ID: uuid.New(), UserID: userID}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	result, err := svc.Get(context.Background(), b.ID.String(), userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, b, result)
+}
+
+func TestBookingService_Get_NotOwner(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	b := &booking.Booking{ID: uuid.New(), UserID: uuid.New()}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	result, err := svc.Get(context.Background(), b.ID.String(), uuid.New().String())
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestBookingService_Get_InvalidID(t *testing.T) {
+	svc := newTestBookingService(new(mockBookingRepo))
+	_, err := svc.Get(context.Background(), "invalid-id", uuid.New().String())
+	assert.Error(t, err)
+}
+
+func TestBookingService_List_Success(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	userID := uuid.New().String()
+	statuses := []booking.Status{booking.StatusCreated, booking.StatusConfirmed}
+	bookings := []*booking.Booking{{ID: uuid.New()}}
+	repo.On("ListUserBookings", userID, statuses).Return(bookings, nil)
+	result, err := svc.List(context.Background(), userID, statuses)
+	assert.NoError(t, err)
+	assert.Equal(t, bookings, result)
+	repo.AssertExpectations(t)
+}
+
+func TestBookingService_List_InvalidStatus(t *testing.T) {
+	svc := newTestBookingService(new(mockBookingRepo))
+	_, err := svc.List(context.Background(), uuid.New().String(), []booking.Status{"expired"})
+	assert.Error(t, err)
+}
+
+func TestBookingService_Cancel_Success(t *testing.T) {
+	repo := new(mockBookingRepo)
+	notifier := new(mockNotifier)
+	svc := NewBookingService(repo, notifier, &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
+	userID := uuid.New()
+	b := &booking.Booking{
+		ID: uuid.New(), EventID: uuid.New(), UserID: userID, EventName: "Test", Count: 2,
+		Status: booking.StatusConfirmed, EventDate: time.Now().Add(24 * time.Hour),
+		EmailNotification: true, EmailRecepient: "mail@example.com",
+		TelegramNotification: true, TelegramRecepient: "123",
+	}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	repo.On("CancelBooking", b.ID.String(), b.EventID.String()).Return(nil, nil)
+	notifier.On("Notify", notification.KindBookingCancelled, b).Return()
+
+	result, err := svc.Cancel(context.Background(), b.ID.String(), userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, booking.StatusCancelled, result.Status)
+	repo.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestBookingService_Cancel_NotOwner(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	b := &booking.Booking{ID: uuid.New(), UserID: uuid.New(), Status: booking.StatusCreated, EventDate: time.Now().Add(24 * time.Hour)}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	_, err := svc.Cancel(context.Background(), b.ID.String(), uuid.New().String())
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_Cancel_AlreadyCancelled(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	userID := uuid.New()
+	b := &booking.Booking{ID: uuid.New(), UserID: userID, Status: booking.StatusCancelled, EventDate: time.Now().Add(24 * time.Hour)}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	_, err := svc.Cancel(context.Background(), b.ID.String(), userID.String())
+	assert.ErrorIs(t, err, booking.ErrAlreadyCancelled)
+	repo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_Cancel_AfterCutoff(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	userID := uuid.New()
+	b := &booking.Booking{ID: uuid.New(), UserID: userID, Status: booking.StatusConfirmed, EventDate: time.Now().Add(30 * time.Minute)}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	_, err := svc.Cancel(context.Background(), b.ID.String(), userID.String())
+	assert.ErrorIs(t, err, booking.ErrCancellationClosed)
+	repo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestBookingService_Cancel_RepoError(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	userID := uuid.New()
+	b := &booking.Booking{ID: uuid.New(), EventID: uuid.New(), UserID: userID, Status: booking.StatusCreated, EventDate: time.Now().Add(24 * time.Hour)}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	repo.On("CancelBooking", b.ID.String(), b.EventID.String()).Return(nil, errors.New("db error"))
+	_, err := svc.Cancel(context.Background(), b.ID.String(), userID.String())
+	assert.Error(t, err)
+}
+
+func TestBookingService_Create_EventCancelled(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 10, Name: "Test", Status: event.StatusCancelled})
+	repo.On("GetEvent", eventID).Return(ev, nil)
+	b, err := svc.Create(context.Background(), eventID, uuid.New().String(), "", flag(true), flag(true), 1)
+	assert.ErrorIs(t, err, event.ErrCancelled)
+	assert.Nil(t, b)
+}
+
+func TestBookingService_Cancel_PromotesWaitlist(t *testing.T) {
+	repo := new(mockBookingRepo)
+	notifier := new(mockNotifier)
+	svc := NewBookingService(repo, notifier, &config.BookingConfig{CancelCutoff: time.Hour}, &config.EmailVerificationConfig{})
+	userID := uuid.New()
+	b := &booking.Booking{ID: uuid.New(), EventID: uuid.New(), UserID: userID, Status: booking.StatusCreated, EventDate: time.Now().Add(24 * time.Hour)}
+	hold := &booking.Booking{
+		ID: uuid.New(), EventID: b.EventID, UserID: uuid.New(), EventName: "Test", Count: 1,
+		Status: booking.StatusCreated, ExpiredAt: time.Now().Add(15 * time.Minute),
+		EmailNotification: true, EmailRecepient: "next@example.com",
+	}
+	free := &booking.Booking{ID: uuid.New(), EventID: b.EventID, Status: booking.StatusConfirmed, EventName: "Test", Count: 1}
+	repo.On("GetBooking", b.ID.String()).Return(b, nil)
+	repo.On("CancelBooking", b.ID.String(), b.EventID.String()).Return([]*booking.Booking{hold, free}, nil)
+	notifier.On("Notify", notification.KindBookingCancelled, b).Return()
+	notifier.On("Notify", notification.KindBookingPromoted, hold).Return()
+	notifier.On("Notify", notification.KindBookingPromoted, free).Return()
+
+	_, err := svc.Cancel(context.Background(), b.ID.String(), userID.String())
+	assert.NoError(t, err)
+	notifier.AssertExpectations(t)
+}
+
+func TestBookingService_JoinWaitlist_Success(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 0, MaxCountPeople: 10, Status: event.StatusActive})
+	repo.On("GetEvent", eventID).Return(ev, nil)
+	repo.On("JoinWaitlist", mock.Anything).Return(nil)
+
+	e, err := svc.JoinWaitlist(context.Background(), eventID, uuid.New().String(), "", flag(true), flag(false), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, waitlist.StatusWaiting, e.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestBookingService_JoinWaitlist_SeatsAvailable(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 3, MaxCountPeople: 10, Status: event.StatusActive})
+	repo.On("GetEvent", eventID).Return(ev, nil)
+
+	_, err := svc.JoinWaitlist(context.Background(), eventID, uuid.New().String(), "", flag(false), flag(false), 2)
+	assert.ErrorIs(t, err, waitlist.ErrSeatsAvailable)
+	repo.AssertNotCalled(t, "JoinWaitlist", mock.Anything)
+}
+
+func TestBookingService_JoinWaitlist_ExceedsCapacity(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 0, MaxCountPeople: 2, Status: event.StatusActive})
+	repo.On("GetEvent", eventID).Return(ev, nil)
+
+	_, err := svc.JoinWaitlist(context.Background(), eventID, uuid.New().String(), "", flag(false), flag(false), 3)
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+}
+
+func TestBookingService_JoinWaitlist_BookingTooLarge(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	ev := withTier(&event.Event{ID: uuid.New(), FreePlaces: 0, MaxCountPeople: 10, Status: event.StatusActive, Limits: event.Limits{MaxSeatsPerBooking: 2}})
+	repo.On("GetEvent", eventID).Return(ev, nil)
+
+	_, err := svc.JoinWaitlist(context.Background(), eventID, uuid.New().String(), "", flag(false), flag(false), 3)
+	assert.ErrorIs(t, err, event.ErrBookingTooLarge)
+	repo.AssertNotCalled(t, "JoinWaitlist", mock.Anything)
+}
+
+func TestBookingService_JoinWaitlist_EventCancelled(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID := uuid.New().String()
+	repo.On("GetEvent", eventID).Return(&event.Event{Status: event.StatusCancelled}, nil)
+
+	_, err := svc.JoinWaitlist(context.Background(), eventID, uuid.New().String(), "", flag(false), flag(false), 1)
+	assert.ErrorIs(t, err, event.ErrCancelled)
+}
+
+func TestBookingService_RequiresVerifiedEmail(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := NewBookingService(repo, quietNotifier(), &config.BookingConfig{CancelCutoff: time.Hour},
+		&config.EmailVerificationConfig{Policy: config.EmailPolicyBookings})
+	eventID := uuid.New().String()
+	unverified := &user.User{ID: uuid.New(), Email: "typo@exmaple.com"}
+	verified := &user.User{ID: uuid.New(), Email: "test@example.com", EmailVerified: true}
+	repo.On("GetEvent", eventID).Return(withTier(&event.Event{ID: uuid.New(), Name: "Test", FreePlaces: 0, MaxCountPeople: 10, BookingTTL: 1 * time.Minute}), nil)
+	repo.On("GetUserByUUID", unverified.ID.String()).Return(unverified, nil)
+	repo.On("GetUserByUUID", verified.ID.String()).Return(verified, nil)
+	repo.On("JoinWaitlist", mock.Anything).Return(nil)
+
+	_, err := svc.JoinWaitlist(context.Background(), eventID, unverified.ID.String(), "", flag(false), flag(true), 1)
+	assert.ErrorIs(t, err, user.ErrEmailNotVerified)
+	_, err = svc.JoinWaitlist(context.Background(), eventID, verified.ID.String(), "", flag(false), flag(true), 1)
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "JoinWaitlist", 1)
+
+	freeEventID := uuid.New().String()
+	repo.On("GetEvent", freeEventID).Return(withTier(&event.Event{ID: uuid.New(), Name: "Test", FreePlaces: 5, BookingTTL: 1 * time.Minute}), nil)
+	_, err = svc.Create(context.Background(), freeEventID, unverified.ID.String(), "", flag(false), flag(true), 1)
+	assert.ErrorIs(t, err, user.ErrEmailNotVerified)
+	repo.AssertNotCalled(t, "CreateBooking", mock.Anything)
+}
+
+func TestBookingService_LeaveWaitlist(t *testing.T) {
+	repo := new(mockBookingRepo)
+	svc := newTestBookingService(repo)
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	repo.On("LeaveWaitlist", eventID, userID).Return(waitlist.ErrNotFound)
+
+	assert.ErrorIs(t, svc.LeaveWaitlist(context.Background(), eventID, userID), waitlist.ErrNotFound)
+	assert.Error(t, svc.LeaveWaitlist(context.Background(), "bad", userID))
 }
@@ -2,40 +2,56 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 	"unicode/utf8"
 
+	"eventbooker/internal/apperr"
 	"eventbooker/internal/config"
 	"eventbooker/internal/domain/event"
+	"eventbooker/internal/notification"
 
 	"github.com/google/uuid"
 	wbzlog "github.com/wb-go/wbf/zlog"
 )
 
+var errPastDate = apperr.Validation("invalid_date", "event date must be in the future")
+
 // EventRepository defines the storage operations needed by EventService.
 type EventRepository interface {
 	CreateEvent(ctx context.Context, e *event.Event) error
 	GetEvent(ctx context.Context, eventID string) (*event.Event, error)
+	ListEvents(ctx context.Context, f event.Filter) (*event.Page, error)
+	UpdateEvent(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error)
+	CancelEvent(ctx context.Context, eventID, userID string) (*event.Event, error)
+}
+
+// EventPublisher announces changes of events to external subscribers.
+type EventPublisher interface {
+	EventUpdated(e *event.Event)
 }
 
 // EventService handles event business logic.
 type EventService struct {
-	repo EventRepository
-	cfg  *config.EventConfig
+	repo      EventRepository
+	notifier  Notifier
+	publisher EventPublisher
+	cfg       *config.EventConfig
 }
 
 // NewEventService creates a new EventService.
-func NewEventService(repo EventRepository, cfg *config.EventConfig) *EventService {
+func NewEventService(repo EventRepository, notifier Notifier, publisher EventPublisher, cfg *config.EventConfig) *EventService {
 	return &EventService{
-		repo: repo,
-		cfg:  cfg,
+		repo:      repo,
+		notifier:  notifier,
+		publisher: publisher,
+		cfg:       cfg,
 	}
 }
 
-// Create creates a new event.
-func (s *EventService) Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error) {
+// Create creates a new event. Without ticket types the event gets a single
+// standard tier built from bookingTTL, maxCountPeople and price.
+func (s *EventService) Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
 	if err := s.validateName(name); err != nil {
 		wbzlog.Logger.Debug().Err(err)
 		return nil, err
@@ -48,23 +64,28 @@ func (s *EventService) Create(ctx context.Context, userID, name, description str
 
 	if date.Before(time.Now()) {
 		wbzlog.Logger.Debug().Msg("date should be in the future")
-		return nil, fmt.Errorf("event date must be in the future")
-	}
-
-	if price == 0 {
-		bookingTTL = 0
+		return nil, errPastDate
 	}
 
-	if bookingTTL < 0 {
-		return nil, errors.New("booking TTL must be >= 0")
+	if len(ticketTypes) == 0 {
+		ticketTypes = []event.TicketType{{
+			Name:           event.DefaultTicketTypeName,
+			Price:          price,
+			MaxCountPeople: maxCountPeople,
+			BookingTTL:     bookingTTL,
+		}}
 	}
 
-	ev, err := event.New(userID, name, description, date, bookingTTL, maxCountPeople, price)
+	ev, err := event.NewWithTicketTypes(userID, name, description, date, ticketTypes)
 	if err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("create event error (domain level)")
 		return nil, err
 	}
 
+	if err = ev.SetLimits(limits); err != nil {
+		return nil, err
+	}
+
 	if err = s.repo.CreateEvent(ctx, ev); err != nil {
 		return nil, err
 	}
@@ -76,26 +97,124 @@ func (s *EventService) Create(ctx context.Context, userID, name, description str
 func (s *EventService) Get(ctx context.Context, eventID string) (*event.Event, error) {
 	if _, err := uuid.Parse(eventID); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msgf("invalid event_id: %s", eventID)
-		return nil, err
+		return nil, invalidID("event_id", err)
 	}
 
 	return s.repo.GetEvent(ctx, eventID)
 }
 
+// Update edits an event on behalf of its creator.
+func (s *EventService) Update(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+	if _, err := uuid.Parse(eventID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msgf("invalid event_id: %s", eventID)
+		return nil, invalidID("event_id", err)
+	}
+
+	if u.Name != nil {
+		if err := s.validateName(*u.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.Description != nil {
+		if err := s.validateDescription(*u.Description); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.Date != nil && u.Date.Before(time.Now()) {
+		return nil, errPastDate
+	}
+
+	ev, err := s.repo.UpdateEvent(ctx, eventID, userID, u)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publisher.EventUpdated(ev)
+	return ev, nil
+}
+
+// Cancel cancels an event on behalf of its creator and notifies every attendee.
+func (s *EventService) Cancel(ctx context.Context, eventID, userID string) (*event.Event, error) {
+	if _, err := uuid.Parse(eventID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msgf("invalid event_id: %s", eventID)
+		return nil, invalidID("event_id", err)
+	}
+
+	ev, err := s.repo.CancelEvent(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range ev.Bookings {
+		s.notifier.Notify(notification.KindEventCancelled, b)
+	}
+	s.publisher.EventUpdated(ev)
+
+	return ev, nil
+}
+
+// List returns a page of the event catalogue.
+func (s *EventService) List(ctx context.Context, f event.Filter) (*event.Page, error) {
+	if f.Sort == "" {
+		f.Sort = event.SortDateAsc
+	}
+	if !f.Sort.Valid() {
+		wbzlog.Logger.Debug().Msgf("invalid sort: %s", f.Sort)
+		return nil, apperr.Validation("invalid_sort", fmt.Sprintf("unknown sort order %q", f.Sort))
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = s.cfg.ListDefaultLimit
+	}
+	if s.cfg.ListMaxLimit > 0 && f.Limit > s.cfg.ListMaxLimit {
+		f.Limit = s.cfg.ListMaxLimit
+	}
+	if f.Limit <= 0 {
+		return nil, apperr.Validation("invalid_limit", "limit must be bigger than 0")
+	}
+
+	if !f.DateFrom.IsZero() && !f.DateTo.IsZero() && f.DateTo.Before(f.DateFrom) {
+		return nil, apperr.Validation("invalid_date_range", "date_to must not be before date_from")
+	}
+
+	if (f.PriceMin != nil && *f.PriceMin < 0) || (f.PriceMax != nil && *f.PriceMax < 0) {
+		return nil, apperr.Validation("invalid_price_range", "price range must be bigger or equal 0")
+	}
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMax < *f.PriceMin {
+		return nil, apperr.Validation("invalid_price_range", "price_max must not be less than price_min")
+	}
+
+	if f.CreatorID != "" {
+		if _, err := uuid.Parse(f.CreatorID); err != nil {
+			wbzlog.Logger.Debug().Err(err).Msgf("invalid creator_id: %s", f.CreatorID)
+			return nil, invalidID("creator_id", err)
+		}
+	}
+
+	return s.repo.ListEvents(ctx, f)
+}
+
 func (s *EventService) validateName(name string) error {
 	l := utf8.RuneCountInString(name)
 	if name == "" || l < s.cfg.NameMinLength || l > s.cfg.NameMaxLength {
-		return fmt.Errorf("name must be between %d and %d characters", s.cfg.NameMinLength, s.cfg.NameMaxLength)
+		return apperr.Validation("invalid_name", fmt.Sprintf("name must be between %d and %d characters", s.cfg.NameMinLength, s.cfg.NameMaxLength))
 	}
 	return nil
 }
 
 func (s *EventService) validateDescription(descr string) error {
 	if s.cfg.DescriptionRequired && descr == "" {
-		return errors.New("description required")
+		return apperr.Validation("invalid_description", "description required")
 	}
 	if utf8.RuneCountInString(descr) > s.cfg.DescriptionMaxLength {
-		return fmt.Errorf("description must be shorter than %d characters", s.cfg.DescriptionMaxLength)
+		return apperr.Validation("invalid_description", fmt.Sprintf("description must be shorter than %d characters", s.cfg.DescriptionMaxLength))
 	}
 	return nil
 }
+
+// invalidID reports a malformed UUID in the named request field.
+func invalidID(field string, err error) error {
+	return apperr.Wrap(apperr.ErrValidation, "invalid_id", "invalid "+field, err)
+}
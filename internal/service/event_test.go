@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
+	"eventbooker/internal/notification"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -24,82 +26,134 @@ func (m *mockEventRepo) GetEvent(ctx context.Context, id string) (*event.Event,
 	return args.Get(0).(*event.Event), args.Error(1)
 }
 
+func (m *mockEventRepo) ListEvents(ctx context.Context, f event.Filter) (*event.Page, error) {
+	args := m.Called(f)
+	return args.Get(0).(*event.Page), args.Error(1)
+}
+
+func (m *mockEventRepo) UpdateEvent(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+	args := m.Called(eventID, userID, u)
+	return args.Get(0).(*event.Event), args.Error(1)
+}
+func (m *mockEventRepo) CancelEvent(ctx context.Context, eventID, userID string) (*event.Event, error) {
+	args := m.Called(eventID, userID)
+	return args.Get(0).(*event.Event), args.Error(1)
+}
+
+type mockPublisher struct{ mock.Mock }
+
+func (m *mockPublisher) EventUpdated(e *event.Event) {
+	m.Called(e)
+}
+
 func defaultEventCfg() *config.EventConfig {
 	return &config.EventConfig{
 		NameMinLength:        3,
 		NameMaxLength:        20,
 		DescriptionRequired:  true,
 		DescriptionMaxLength: 100,
+		ListDefaultLimit:     20,
+		ListMaxLimit:         100,
 	}
 }
 
 func TestEventService_Create_Success(t *testing.T) {
 	repo := new(mockEventRepo)
 	cfg := defaultEventCfg()
-	svc := NewEventService(repo, cfg)
+	svc := NewEventService(repo, nil, nil, cfg)
 	repo.On("CreateEvent", mock.Anything).Return(nil)
-	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid description", time.Now().Add(24*time.Hour), 60, 100, 10)
+	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid description", time.Now().Add(24*time.Hour), 60, 100, 10, nil, event.Limits{})
 	assert.NoError(t, err)
 	assert.NotNil(t, e)
 	repo.AssertExpectations(t)
 }
 
 func TestEventService_Create_NameInvalid(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
-	_, err := svc.Create(context.Background(), uuid.New().String(), "x", "desc", time.Now().Add(24*time.Hour), 10, 10, 10)
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.Create(context.Background(), uuid.New().String(), "x", "desc", time.Now().Add(24*time.Hour), 10, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 }
 
 func TestEventService_Create_DescriptionRequired(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
-	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "", time.Now().Add(24*time.Hour), 10, 10, 10)
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "", time.Now().Add(24*time.Hour), 10, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 }
 
 func TestEventService_Create_DescriptionTooLong(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
 	longDescr := ""
 	for i := 0; i < 200; i++ {
 		longDescr += "a"
 	}
-	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", longDescr, time.Now().Add(24*time.Hour), 10, 10, 10)
+	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", longDescr, time.Now().Add(24*time.Hour), 10, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 }
 
 func TestEventService_Create_DateInPast(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
-	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(-1*time.Hour), 10, 10, 10)
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(-1*time.Hour), 10, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 }
 
 func TestEventService_Create_FreeEventTTLForcedZero(t *testing.T) {
 	repo := new(mockEventRepo)
-	svc := NewEventService(repo, defaultEventCfg())
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
 	repo.On("CreateEvent", mock.Anything).Return(nil)
-	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 99, 10, 0)
+	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 99, 10, 0, nil, event.Limits{})
 	assert.NoError(t, err)
-	assert.Equal(t, 0, e.BookingTTL)
+	assert.Zero(t, e.BookingTTL)
 	repo.AssertExpectations(t)
 }
 
 func TestEventService_Create_InvalidTTL(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
-	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), -5, 10, 10)
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), -5, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 }
 
+func TestEventService_Create_TicketTypes(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	repo.On("CreateEvent", mock.Anything).Return(nil)
+	types := []event.TicketType{
+		{Name: "standard", Price: 10, MaxCountPeople: 50, BookingTTL: 15 * time.Minute},
+		{Name: "vip", Price: 50, MaxCountPeople: 5, BookingTTL: 30 * time.Minute},
+	}
+	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 0, 0, 0, types, event.Limits{})
+	assert.NoError(t, err)
+	assert.Len(t, e.TicketTypes, 2)
+	assert.Equal(t, 55, e.MaxCountPeople)
+	assert.Equal(t, float64(10), e.Price)
+	repo.AssertExpectations(t)
+}
+
+func TestEventService_Create_Limits(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	repo.On("CreateEvent", mock.Anything).Return(nil)
+	limits := event.Limits{MaxSeatsPerBooking: 2, MaxBookingsPerUser: 1, MaxSeatsPerUser: 2}
+	e, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 0, 10, 0, nil, limits)
+	assert.NoError(t, err)
+	assert.Equal(t, limits, e.Limits)
+
+	_, err = svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 0, 10, 0, nil, event.Limits{MaxSeatsPerBooking: -1})
+	assert.ErrorIs(t, err, event.ErrInvalidLimits)
+	repo.AssertNumberOfCalls(t, "CreateEvent", 1)
+}
+
 func TestEventService_Create_RepoError(t *testing.T) {
 	repo := new(mockEventRepo)
-	svc := NewEventService(repo, defaultEventCfg())
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
 	repo.On("CreateEvent", mock.Anything).Return(errors.New("db_error"))
-	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 10, 10, 10)
+	_, err := svc.Create(context.Background(), uuid.New().String(), "Valid Name", "Valid desc", time.Now().Add(24*time.Hour), 10, 10, 10, nil, event.Limits{})
 	assert.Error(t, err)
 	repo.AssertExpectations(t)
 }
 
 func TestEventService_Get_Success(t *testing.T) {
 	repo := new(mockEventRepo)
-	svc := NewEventService(repo, defaultEventCfg())
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
 	eventID := uuid.New().String()
 	ev := &event.Event{ID: uuid.MustParse(eventID)}
 	repo.On("GetEvent", eventID).Return(ev, nil)
@@ -110,22 +164,144 @@ func TestEventService_Get_Success(t *testing.T) {
 }
 
 func TestEventService_Get_InvalidUUID(t *testing.T) {
-	svc := NewEventService(new(mockEventRepo), defaultEventCfg())
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
 	_, err := svc.Get(context.Background(), "invalid-uuid")
 	assert.Error(t, err)
 }
 
 func TestEventService_Get_RepoError(t *testing.T) {
 	repo := new(mockEventRepo)
-	svc := NewEventService(repo, defaultEventCfg())
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
 	id := uuid.New().String()
 	repo.On("GetEvent", id).Return(&event.Event{}, errors.New("db error"))
 	_, err := svc.Get(context.Background(), id)
 	assert.Error(t, err)
 }
 
+func TestEventService_List_Defaults(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	page := &event.Page{Events: []*event.Event{{ID: uuid.New()}}}
+	repo.On("ListEvents", event.Filter{Sort: event.SortDateAsc, Limit: 20}).Return(page, nil)
+	result, err := svc.List(context.Background(), event.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, page, result)
+	repo.AssertExpectations(t)
+}
+
+func TestEventService_List_LimitCapped(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	repo.On("ListEvents", mock.MatchedBy(func(f event.Filter) bool { return f.Limit == 100 })).Return(&event.Page{}, nil)
+	_, err := svc.List(context.Background(), event.Filter{Limit: 1000})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestEventService_List_InvalidSort(t *testing.T) {
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.List(context.Background(), event.Filter{Sort: "name"})
+	assert.Error(t, err)
+}
+
+func TestEventService_List_InvalidDateRange(t *testing.T) {
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	now := time.Now()
+	_, err := svc.List(context.Background(), event.Filter{DateFrom: now, DateTo: now.Add(-time.Hour)})
+	assert.Error(t, err)
+}
+
+func TestEventService_List_InvalidPriceRange(t *testing.T) {
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	lo, hi, neg := 100.0, 10.0, -1.0
+	_, err := svc.List(context.Background(), event.Filter{PriceMin: &lo, PriceMax: &hi})
+	assert.Error(t, err)
+	_, err = svc.List(context.Background(), event.Filter{PriceMin: &neg})
+	assert.Error(t, err)
+}
+
+func TestEventService_List_InvalidCreator(t *testing.T) {
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	_, err := svc.List(context.Background(), event.Filter{CreatorID: "invalid-uuid"})
+	assert.Error(t, err)
+}
+
+func TestEventService_Update_Success(t *testing.T) {
+	repo := new(mockEventRepo)
+	publisher := new(mockPublisher)
+	svc := NewEventService(repo, nil, publisher, defaultEventCfg())
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	name := "Renamed"
+	u := event.Update{Name: &name}
+	ev := &event.Event{Name: name}
+	repo.On("UpdateEvent", eventID, userID, u).Return(ev, nil)
+	publisher.On("EventUpdated", ev).Return()
+	result, err := svc.Update(context.Background(), eventID, userID, u)
+	assert.NoError(t, err)
+	assert.Equal(t, ev, result)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestEventService_Update_Validation(t *testing.T) {
+	svc := NewEventService(new(mockEventRepo), nil, nil, defaultEventCfg())
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	short, empty, past := "x", "", time.Now().Add(-time.Hour)
+
+	_, err := svc.Update(context.Background(), "invalid-uuid", userID, event.Update{})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), eventID, userID, event.Update{Name: &short})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), eventID, userID, event.Update{Description: &empty})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), eventID, userID, event.Update{Date: &past})
+	assert.Error(t, err)
+}
+
+func TestEventService_Update_RepoError(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	repo.On("UpdateEvent", eventID, userID, event.Update{}).Return((*event.Event)(nil), event.ErrCapacityBelowBooked)
+	_, err := svc.Update(context.Background(), eventID, userID, event.Update{})
+	assert.ErrorIs(t, err, event.ErrCapacityBelowBooked)
+}
+
+func TestEventService_Cancel_NotifiesAttendees(t *testing.T) {
+	repo := new(mockEventRepo)
+	notifier := new(mockNotifier)
+	publisher := new(mockPublisher)
+	svc := NewEventService(repo, notifier, publisher, defaultEventCfg())
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	ev := &event.Event{Status: event.StatusCancelled, Bookings: []*booking.Booking{
+		{EventName: "Gig", Count: 1, EmailNotification: true, EmailRecepient: "a@example.com"},
+		{EventName: "Gig", Count: 2, TelegramNotification: true, TelegramRecepient: "42"},
+		{EventName: "Gig", Count: 3},
+	}}
+	repo.On("CancelEvent", eventID, userID).Return(ev, nil)
+	for _, b := range ev.Bookings {
+		notifier.On("Notify", notification.KindEventCancelled, b).Return()
+	}
+	publisher.On("EventUpdated", ev).Return()
+
+	result, err := svc.Cancel(context.Background(), eventID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, ev, result)
+	notifier.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestEventService_Cancel_RepoError(t *testing.T) {
+	repo := new(mockEventRepo)
+	svc := NewEventService(repo, nil, nil, defaultEventCfg())
+	eventID, userID := uuid.New().String(), uuid.New().String()
+	repo.On("CancelEvent", eventID, userID).Return((*event.Event)(nil), event.ErrNotCreator)
+	_, err := svc.Cancel(context.Background(), eventID, userID)
+	assert.ErrorIs(t, err, event.ErrNotCreator)
+}
+
 func TestEventService_validateName(t *testing.T) {
-	svc := NewEventService(nil, defaultEventCfg())
+	svc := NewEventService(nil, nil, nil, defaultEventCfg())
 	assert.Error(t, svc.validateName(""))
 	assert.Error(t, svc.validateName("ab"))
 	assert.Error(t, svc.validateName("aaaaaaaaaaaaaaaaaaaaa"))
@@ -133,7 +309,7 @@ func TestEventService_validateName(t *testing.T) {
 }
 
 func TestEventService_validateDescription(t *testing.T) {
-	svc := NewEventService(nil, defaultEventCfg())
+	svc := NewEventService(nil, nil, nil, defaultEventCfg())
 	assert.Error(t, svc.validateDescription(""))
 	long := ""
 	for i := 0; i < 200; i++ {
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// DeliveryRepository defines the storage operations needed by NotificationService.
+type DeliveryRepository interface {
+	ListDeliveries(ctx context.Context, f notification.DeliveryFilter) ([]*notification.Delivery, error)
+	RequeueDelivery(ctx context.Context, id string) (*notification.Delivery, error)
+}
+
+// NotificationService lets admins inspect the notification delivery log.
+type NotificationService struct {
+	repo DeliveryRepository
+}
+
+// NewNotificationService creates a new NotificationService.
+func NewNotificationService(repo DeliveryRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// ListDeliveries returns logged deliveries, newest first. Without statuses and a
+// booking it lists the failures: deliveries waiting for a retry and abandoned ones.
+func (s *NotificationService) ListDeliveries(ctx context.Context, statuses []notification.DeliveryStatus, bookingID string, limit int) ([]*notification.Delivery, error) {
+	f := notification.DeliveryFilter{Statuses: statuses, Limit: limit}
+
+	for _, st := range statuses {
+		if !st.Valid() {
+			return nil, apperr.Validation("invalid_status", fmt.Sprintf("unknown delivery status %q", st))
+		}
+	}
+
+	if bookingID != "" {
+		id, err := uuid.Parse(bookingID)
+		if err != nil {
+			wbzlog.Logger.Debug().Err(err).Msg("invalid booking id")
+			return nil, invalidID("booking_id", err)
+		}
+		f.BookingID = id
+	} else if len(statuses) == 0 {
+		f.Statuses = []notification.DeliveryStatus{notification.DeliveryFailed, notification.DeliveryAbandoned}
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = defaultDeliveryLimit
+	}
+	f.Limit = min(f.Limit, maxDeliveryLimit)
+
+	return s.repo.ListDeliveries(ctx, f)
+}
+
+// Resend schedules an immediate retry of an undelivered notification.
+func (s *NotificationService) Resend(ctx context.Context, id string) (*notification.Delivery, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid delivery id")
+		return nil, invalidID("delivery_id", err)
+	}
+
+	return s.repo.RequeueDelivery(ctx, id)
+}
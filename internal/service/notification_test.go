@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockDeliveryRepo struct{ mock.Mock }
+
+func (m *mockDeliveryRepo) ListDeliveries(ctx context.Context, f notification.DeliveryFilter) ([]*notification.Delivery, error) {
+	args := m.Called(f)
+	return args.Get(0).([]*notification.Delivery), args.Error(1)
+}
+func (m *mockDeliveryRepo) RequeueDelivery(ctx context.Context, id string) (*notification.Delivery, error) {
+	args := m.Called(id)
+	d, _ := args.Get(0).(*notification.Delivery)
+	return d, args.Error(1)
+}
+
+func TestNotificationService_ListDeliveries_DefaultsToFailures(t *testing.T) {
+	repo := new(mockDeliveryRepo)
+	svc := NewNotificationService(repo)
+
+	repo.On("ListDeliveries", notification.DeliveryFilter{
+		Statuses: []notification.DeliveryStatus{notification.DeliveryFailed, notification.DeliveryAbandoned},
+		Limit:    defaultDeliveryLimit,
+	}).Return([]*notification.Delivery{}, nil)
+
+	_, err := svc.ListDeliveries(context.Background(), nil, "", 0)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationService_ListDeliveries_ByBooking(t *testing.T) {
+	repo := new(mockDeliveryRepo)
+	svc := NewNotificationService(repo)
+	bookingID := uuid.New()
+
+	repo.On("ListDeliveries", notification.DeliveryFilter{BookingID: bookingID, Limit: maxDeliveryLimit}).Return([]*notification.Delivery{}, nil)
+
+	_, err := svc.ListDeliveries(context.Background(), nil, bookingID.String(), 1000)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestNotificationService_ListDeliveries_InvalidStatus(t *testing.T) {
+	svc := NewNotificationService(new(mockDeliveryRepo))
+
+	_, err := svc.ListDeliveries(context.Background(), []notification.DeliveryStatus{"lost"}, "", 0)
+	assert.True(t, errors.Is(err, apperr.ErrValidation))
+}
+
+func TestNotificationService_Resend(t *testing.T) {
+	repo := new(mockDeliveryRepo)
+	svc := NewNotificationService(repo)
+	id := uuid.New()
+
+	repo.On("RequeueDelivery", id.String()).Return(&notification.Delivery{ID: id, Status: notification.DeliveryFailed}, nil)
+
+	d, err := svc.Resend(context.Background(), id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, notification.DeliveryFailed, d.Status)
+
+	_, err = svc.Resend(context.Background(), "not-a-uuid")
+	assert.True(t, errors.Is(err, apperr.ErrValidation))
+	repo.AssertExpectations(t)
+}
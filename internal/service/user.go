@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
+	"eventbooker/internal/apperr"
 	"eventbooker/internal/auth"
 	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/user"
+	"eventbooker/internal/notification"
 
+	"github.com/google/uuid"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,29 +26,78 @@ import (
 // UserRepository defines the storage operations needed by UserService.
 type UserRepository interface {
 	GetUser(ctx context.Context, login string) (*user.User, error)
+	GetUserByUUID(ctx context.Context, id string) (*user.User, error)
+	GetUserByTelegram(ctx context.Context, chatID string) (*user.User, error)
+	SaveTelegramLink(ctx context.Context, l *user.TelegramLink) error
+	LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error)
 	SaveUser(ctx context.Context, u *user.User) error
+	SetUserRole(ctx context.Context, id string, role user.Role) (*user.User, error)
+	SaveRefreshToken(ctx context.Context, t *user.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, id string, ttl time.Duration) (*user.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, id string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	SavePasswordReset(ctx context.Context, p *user.PasswordReset) error
+	LastPasswordResetAt(ctx context.Context, userID uuid.UUID) (time.Time, error)
+	ResetPassword(ctx context.Context, token string, password []byte) (*user.User, error)
+	SaveEmailVerification(ctx context.Context, v *user.EmailVerification) error
+	VerifyEmail(ctx context.Context, token string) (*user.User, error)
+	UpdateUser(ctx context.Context, u *user.User) (*user.User, error)
+	ChangePassword(ctx context.Context, id string, password []byte) error
+	DeleteUser(ctx context.Context, id string) (promoted, cancelled []*booking.Booking, err error)
 }
 
 // TokenProvider defines the JWT operations needed by UserService.
 type TokenProvider interface {
-	GenerateTokens(u *user.User) (*auth.Response, error)
+	GenerateTokens(u *user.User, rt *user.RefreshToken) (*auth.Response, error)
 	ValidateToken(tokenStr string) (*auth.Payload, error)
-	RefreshTokens(refreshToken string) (*auth.Response, error)
+	ValidateRefreshToken(refreshToken string) (*auth.Payload, error)
 }
 
+// Mailer sends account emails to users.
+type Mailer interface {
+	SendPasswordReset(u *user.User, r *user.PasswordReset) error
+	SendEmailVerification(u *user.User, v *user.EmailVerification) error
+}
+
+// defaultTelegramLinkTTL is used when telegram.link_ttl is not set.
+const defaultTelegramLinkTTL = 15 * time.Minute
+
+// defaultRefreshTokenTTL is used when jwt.jwt_exp_refresh_token is not set.
+const defaultRefreshTokenTTL = 24 * time.Hour
+
+// defaultPasswordResetTTL is used when password_reset.ttl is not set.
+const defaultPasswordResetTTL = 30 * time.Minute
+
+// defaultPasswordResetCooldown is used when password_reset.cooldown is not set.
+const defaultPasswordResetCooldown = 5 * time.Minute
+
+// defaultEmailVerificationTTL is used when email_verification.ttl is not set.
+const defaultEmailVerificationTTL = 24 * time.Hour
+
+var errTelegramUnavailable = apperr.Conflict("telegram_unavailable", "telegram bot is not configured")
+
 // UserService handles user business logic.
 type UserService struct {
-	repo UserRepository
-	jwt  TokenProvider
-	cfg  *config.AppConfig
+	repo     UserRepository
+	jwt      TokenProvider
+	mailer   Mailer
+	notifier Notifier
+	cfg      *config.AppConfig
+
+	// background tracks emails sent after the request has been answered:
+	// password resets and the verification links of new and changed addresses.
+	background sync.WaitGroup
 }
 
-// NewUserService creates a new UserService.
-func NewUserService(repo UserRepository, jwt TokenProvider, cfg *config.AppConfig) *UserService {
+// NewUserService creates a new UserService. The notifier tells waitlisted users
+// about seats released by a deleted account.
+func NewUserService(repo UserRepository, jwt TokenProvider, mailer Mailer, notifier Notifier, cfg *config.AppConfig) *UserService {
 	return &UserService{
-		repo: repo,
-		jwt:  jwt,
-		cfg:  cfg,
+		repo:     repo,
+		jwt:      jwt,
+		mailer:   mailer,
+		notifier: notifier,
+		cfg:      cfg,
 	}
 }
 
@@ -50,24 +105,33 @@ func NewUserService(repo UserRepository, jwt TokenProvider, cfg *config.AppConfi
 func (s *UserService) Login(ctx context.Context, login, password string) (*auth.Response, error) {
 	if login == "" || password == "" {
 		wbzlog.Logger.Debug().Msg("login or password cannot be empty")
-		return nil, errors.New("login or password cannot be empty")
+		return nil, apperr.Validation("invalid_credentials", "login or password cannot be empty")
 	}
 
 	u, err := s.repo.GetUser(ctx, login)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, user.ErrInvalidCredentials
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if err = bcrypt.CompareHashAndPassword(u.Password, []byte(password)); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("invalid password")
+		return nil, user.ErrInvalidCredentials
+	}
+
+	rt := user.NewRefreshToken(u.ID, s.refreshTokenTTL())
+	if err = s.repo.SaveRefreshToken(ctx, rt); err != nil {
 		return nil, err
 	}
 
-	return s.jwt.GenerateTokens(u)
+	return s.jwt.GenerateTokens(u, rt)
 }
 
-// Register creates a new user account.
-func (s *UserService) Register(ctx context.Context, login, password, email, telegram string) (*user.User, error) {
+// Register creates a new user account. An empty locale leaves notifications in the
+// default locale.
+func (s *UserService) Register(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error) {
 	if err := s.validateLogin(login); err != nil {
 		wbzlog.Logger.Debug().Err(err).Msg("invalid login")
 		return nil, err
@@ -78,9 +142,12 @@ func (s *UserService) Register(ctx context.Context, login, password, email, tele
 		return nil, err
 	}
 
-	if err := s.validateTelegram(telegram); err != nil {
-		wbzlog.Logger.Debug().Err(err).Msg("invalid telegram")
-		return nil, err
+	// The chat can also be linked later through the bot.
+	if telegram != "" {
+		if err := s.validateTelegram(telegram); err != nil {
+			wbzlog.Logger.Debug().Err(err).Msg("invalid telegram")
+			return nil, err
+		}
 	}
 
 	if err := s.validateEmail(email); err != nil {
@@ -88,18 +155,24 @@ func (s *UserService) Register(ctx context.Context, login, password, email, tele
 		return nil, err
 	}
 
+	locale, err := s.validateLocale(locale)
+	if err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid locale")
+		return nil, err
+	}
+
 	existing, err := s.repo.GetUser(ctx, login)
-	if err != nil && err.Error() != "user not found" {
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
 		wbzlog.Logger.Error().Err(err).Msg("cannot check existing user")
 		return nil, err
 	}
 
 	if existing != nil {
 		wbzlog.Logger.Debug().Msg("user with this login already exists")
-		return nil, errors.New("user with this login already exists")
+		return nil, user.ErrAlreadyExists
 	}
 
-	u, err := user.New(login, password, email, telegram)
+	u, err := user.New(login, password, email, telegram, locale)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("cannot create new user")
 		return nil, err
@@ -109,12 +182,484 @@ func (s *UserService) Register(ctx context.Context, login, password, email, tele
 		return nil, err
 	}
 
+	// The account works without it; the user can ask for another email.
+	if err = s.sendEmailVerification(ctx, u); err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot save email verification of user %s", u.ID)
+	}
+
+	return u, nil
+}
+
+// Get returns the user with the given id.
+func (s *UserService) Get(ctx context.Context, userID string) (*user.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	return s.repo.GetUserByUUID(ctx, userID)
+}
+
+// Update changes the profile of the user. Fields left nil keep their value and an
+// empty Telegram chat ID unlinks the chat. A new email has to be verified again,
+// so a verification link is sent to it.
+func (s *UserService) Update(ctx context.Context, userID string, upd user.Update) (*user.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	if upd.Email != nil {
+		if err := s.validateEmail(*upd.Email); err != nil {
+			wbzlog.Logger.Debug().Err(err).Msg("invalid email")
+			return nil, err
+		}
+	}
+
+	if upd.Telegram != nil && *upd.Telegram != "" {
+		if err := s.validateTelegram(*upd.Telegram); err != nil {
+			wbzlog.Logger.Debug().Err(err).Msg("invalid telegram")
+			return nil, err
+		}
+	}
+
+	if upd.Locale != nil {
+		locale, err := s.validateLocale(*upd.Locale)
+		if err != nil {
+			wbzlog.Logger.Debug().Err(err).Msg("invalid locale")
+			return nil, err
+		}
+		upd.Locale = &locale
+	}
+
+	u, err := s.repo.GetUserByUUID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	emailChanged := u.Apply(upd)
+	if u, err = s.repo.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if emailChanged {
+		// The profile is saved either way; the user can ask for another email.
+		if err = s.sendEmailVerification(ctx, u); err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("cannot save email verification of user %s", u.ID)
+		}
+	}
+
+	wbzlog.Logger.Info().Msgf("profile of user %s updated", u.ID)
+	return u, nil
+}
+
+// ChangePassword replaces the password of the user after checking the current one.
+// Every session of the user is revoked, the one making the request included.
+func (s *UserService) ChangePassword(ctx context.Context, userID, current, password string) error {
+	u, err := s.checkPassword(ctx, userID, current)
+	if err != nil {
+		return err
+	}
+
+	if err = s.validatePassword(password); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid password")
+		return err
+	}
+
+	hash, err := user.HashPassword(password)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot hash password")
+		return err
+	}
+
+	if err = s.repo.ChangePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	wbzlog.Logger.Info().Msgf("password of user %s changed, sessions revoked", u.ID)
+	return nil
+}
+
+// Delete removes the account after checking its password. Upcoming events of the
+// user are cancelled and their attendees notified; past events stay without a
+// creator. Upcoming bookings are cancelled and their seats go to the waitlist;
+// everything else of the user is deleted with the account.
+func (s *UserService) Delete(ctx context.Context, userID, password string) error {
+	u, err := s.checkPassword(ctx, userID, password)
+	if err != nil {
+		return err
+	}
+
+	promoted, cancelled, err := s.repo.DeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range cancelled {
+		s.notifier.Notify(notification.KindEventCancelled, b)
+	}
+	for _, p := range promoted {
+		s.notifier.Notify(notification.KindBookingPromoted, p)
+	}
+
+	wbzlog.Logger.Info().Msgf("user %s deleted, %d bookings of their events cancelled, %d waitlisted bookings promoted", u.ID, len(cancelled), len(promoted))
+	return nil
+}
+
+// checkPassword loads the user and makes sure the password is theirs.
+func (s *UserService) checkPassword(ctx context.Context, userID, password string) (*user.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	u, err := s.repo.GetUserByUUID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = bcrypt.CompareHashAndPassword(u.Password, []byte(password)); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("wrong current password")
+		return nil, user.ErrWrongPassword
+	}
+
 	return u, nil
 }
 
-// RefreshTokens refreshes JWT tokens.
-func (s *UserService) RefreshTokens(refreshToken string) (*auth.Response, error) {
-	return s.jwt.RefreshTokens(refreshToken)
+// GetByTelegram returns the user who registered the Telegram chat ID.
+func (s *UserService) GetByTelegram(ctx context.Context, chatID string) (*user.User, error) {
+	if err := s.validateTelegram(chatID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUserByTelegram(ctx, chatID)
+}
+
+// CreateTelegramLink issues a one-time token for linking a Telegram chat to the
+// user, replacing any earlier one. The user opens the returned deep link and the
+// bot receives the token with /start.
+func (s *UserService) CreateTelegramLink(ctx context.Context, userID string) (*user.TelegramLink, error) {
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	bot := s.cfg.Telegram.BotUsername
+	if bot == "" {
+		return nil, errTelegramUnavailable
+	}
+
+	ttl := s.cfg.Telegram.LinkTTL
+	if ttl <= 0 {
+		ttl = defaultTelegramLinkTTL
+	}
+
+	l := user.NewTelegramLink(uID, ttl)
+	if err = s.repo.SaveTelegramLink(ctx, l); err != nil {
+		return nil, err
+	}
+	l.URL = "https://t.me/" + bot + "?start=" + l.Token
+
+	return l, nil
+}
+
+// LinkTelegram binds the chat the token was sent from to the owner of the token.
+func (s *UserService) LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error) {
+	if token == "" {
+		return nil, user.ErrInvalidLinkToken
+	}
+	if err := s.validateTelegram(chatID); err != nil {
+		return nil, err
+	}
+
+	u, err := s.repo.LinkTelegram(ctx, token, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	wbzlog.Logger.Info().Msgf("telegram chat linked to user %s", u.ID)
+	return u, nil
+}
+
+// RefreshTokens issues a new token pair for a valid refresh token. Refresh tokens
+// are single-use: presenting one a second time revokes its whole session, since
+// either the client or an attacker holds a stolen copy. The user is reloaded, so
+// the new access token carries the current role.
+func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string) (*auth.Response, error) {
+	payload, err := s.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.repo.GetUserByUUID(ctx, payload.UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		wbzlog.Logger.Debug().Msgf("refresh token of deleted user %s", payload.UserID)
+		return nil, auth.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := s.repo.RotateRefreshToken(ctx, payload.TokenID, s.refreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jwt.GenerateTokens(u, next)
+}
+
+// Logout ends the session the refresh token belongs to, so neither it nor any
+// token issued from it can be refreshed again. Access tokens stay valid until
+// they expire. An expired refresh token is accepted: its session has ended anyway.
+func (s *UserService) Logout(ctx context.Context, refreshToken string) error {
+	payload, err := s.jwt.ValidateRefreshToken(refreshToken)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RevokeRefreshTokenFamily(ctx, payload.TokenID)
+}
+
+// LogoutAll ends every session of the user, for example after a password leak.
+func (s *UserService) LogoutAll(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return invalidID("user_id", err)
+	}
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	wbzlog.Logger.Info().Msgf("all sessions of user %s revoked", userID)
+	return nil
+}
+
+// ForgotPassword emails a one-time password reset token to the user with the login.
+// The account is looked up and emailed in the background, so the response takes the
+// same time and reports success for unknown logins and accounts without an email
+// too: it does not tell which accounts exist.
+func (s *UserService) ForgotPassword(ctx context.Context, login string) error {
+	if login == "" {
+		return apperr.Validation("invalid_login", "login cannot be empty")
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.sendPasswordReset(context.WithoutCancel(ctx), login)
+	}()
+	return nil
+}
+
+// sendPasswordReset emails a reset token to the user with the login, at most once
+// per password_reset.cooldown so the address cannot be flooded. Failures are only
+// logged: nobody waits for the outcome.
+func (s *UserService) sendPasswordReset(ctx context.Context, login string) {
+	u, err := s.repo.GetUser(ctx, login)
+	if errors.Is(err, user.ErrNotFound) {
+		wbzlog.Logger.Debug().Msgf("password reset for unknown login %q", login)
+		return
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot look up login %q for password reset", login)
+		return
+	}
+	if u.Email == "" {
+		wbzlog.Logger.Debug().Msgf("password reset for user %s without email", u.ID)
+		return
+	}
+
+	cooldown := s.cfg.PasswordReset.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultPasswordResetCooldown
+	}
+	last, err := s.repo.LastPasswordResetAt(ctx, u.ID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot check password resets of user %s", u.ID)
+		return
+	}
+	if time.Since(last) < cooldown {
+		wbzlog.Logger.Info().Msgf("password reset for user %s throttled, last one at %s", u.ID, last)
+		return
+	}
+
+	ttl := s.cfg.PasswordReset.TTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	r := user.NewPasswordReset(u.ID, ttl)
+	if err = s.repo.SavePasswordReset(ctx, r); err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot save password reset of user %s", u.ID)
+		return
+	}
+
+	if err = s.mailer.SendPasswordReset(u, r); err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot send password reset email to user %s", u.ID)
+		return
+	}
+
+	wbzlog.Logger.Info().Msgf("password reset requested for user %s", u.ID)
+}
+
+// Close waits for the emails still being sent in the background.
+func (s *UserService) Close() error {
+	s.background.Wait()
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token. The token is used up and
+// every session of the user is revoked.
+func (s *UserService) ResetPassword(ctx context.Context, token, password string) error {
+	if token == "" {
+		return user.ErrInvalidResetToken
+	}
+
+	if err := s.validatePassword(password); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid password")
+		return err
+	}
+
+	hash, err := user.HashPassword(password)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("cannot hash password")
+		return err
+	}
+
+	u, err := s.repo.ResetPassword(ctx, token, hash)
+	if err != nil {
+		return err
+	}
+
+	wbzlog.Logger.Info().Msgf("password of user %s reset, sessions revoked", u.ID)
+	return nil
+}
+
+// VerifyEmail marks the email of the token owner as verified. The token is used up.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	if token == "" {
+		return nil, user.ErrInvalidVerificationToken
+	}
+
+	u, err := s.repo.VerifyEmail(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	wbzlog.Logger.Info().Msgf("email of user %s verified", u.ID)
+	return u, nil
+}
+
+// ResendVerification emails a new verification link to the user, invalidating the
+// earlier one.
+func (s *UserService) ResendVerification(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return invalidID("user_id", err)
+	}
+
+	u, err := s.repo.GetUserByUUID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return user.ErrNoEmail
+	}
+	if u.EmailVerified {
+		return user.ErrEmailAlreadyVerified
+	}
+
+	// The user asked for this email, so they are told when it cannot be sent.
+	v, err := s.saveEmailVerification(ctx, u)
+	if err != nil {
+		return err
+	}
+	return s.mailer.SendEmailVerification(u, v)
+}
+
+// sendEmailVerification stores a verification token for the current email of the
+// user and emails the link to it in the background, so a slow mail server does not
+// hold up the request. Mail failures are only logged: the user can ask for another
+// email.
+func (s *UserService) sendEmailVerification(ctx context.Context, u *user.User) error {
+	v, err := s.saveEmailVerification(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	recipient := *u
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		if err := s.mailer.SendEmailVerification(&recipient, v); err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("cannot send verification email to user %s", recipient.ID)
+		}
+	}()
+	return nil
+}
+
+// saveEmailVerification stores a new verification token for the current email of
+// the user, replacing the earlier one.
+func (s *UserService) saveEmailVerification(ctx context.Context, u *user.User) (*user.EmailVerification, error) {
+	ttl := s.cfg.EmailVerification.TTL
+	if ttl <= 0 {
+		ttl = defaultEmailVerificationTTL
+	}
+
+	v := user.NewEmailVerification(u, ttl)
+	if err := s.repo.SaveEmailVerification(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *UserService) refreshTokenTTL() time.Duration {
+	if s.cfg.JWT.ExpRefreshToken <= 0 {
+		return defaultRefreshTokenTTL
+	}
+	return time.Duration(s.cfg.JWT.ExpRefreshToken) * time.Hour
+}
+
+// SetRole changes the role of a user. It takes effect when the user refreshes
+// their tokens or logs in again.
+func (s *UserService) SetRole(ctx context.Context, userID string, role user.Role) (*user.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+	if !role.Valid() {
+		return nil, user.ErrInvalidRole
+	}
+
+	u, err := s.repo.SetUserRole(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	wbzlog.Logger.Info().Msgf("role of user %s set to %s", u.ID, u.Role)
+	return u, nil
+}
+
+// PromoteAdmins gives the admin role to the listed users, so admins configured in
+// admin.user_ids before roles were stored with users keep their access. Unknown
+// users are skipped.
+func (s *UserService) PromoteAdmins(ctx context.Context, userIDs []string) error {
+	for _, id := range userIDs {
+		_, err := s.SetRole(ctx, id, user.RoleAdmin)
+		if errors.Is(err, user.ErrNotFound) {
+			wbzlog.Logger.Warn().Msgf("admin %s from admin.user_ids not found", id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("promote admin %s: %w", id, err)
+		}
+	}
+	return nil
 }
 
 // ValidateToken validates a JWT token.
@@ -125,13 +670,13 @@ func (s *UserService) ValidateToken(tokenStr string) (*auth.Payload, error) {
 func (s *UserService) validateLogin(login string) error {
 	l := utf8.RuneCountInString(login)
 	if l < s.cfg.User.MinLength || l > s.cfg.User.MaxLength {
-		return fmt.Errorf("login length must be between %d and %d characters", s.cfg.User.MinLength, s.cfg.User.MaxLength)
+		return apperr.Validation("invalid_login", fmt.Sprintf("login length must be between %d and %d characters", s.cfg.User.MinLength, s.cfg.User.MaxLength))
 	}
 
 	escapedChars := regexp.QuoteMeta(s.cfg.User.AllowedCharacters)
 	loginRegexp := regexp.MustCompile(`^[` + escapedChars + `]+$`)
 	if !loginRegexp.MatchString(login) {
-		return errors.New("login contains invalid characters")
+		return apperr.Validation("invalid_login", "login contains invalid characters")
 	}
 
 	return nil
@@ -142,11 +687,11 @@ func (s *UserService) validatePassword(password string) error {
 	l := utf8.RuneCountInString(password)
 
 	if l < cfg.MinLength || l > cfg.MaxLength {
-		return fmt.Errorf("password length must be %d–%d characters", cfg.MinLength, cfg.MaxLength)
+		return apperr.Validation("invalid_password", fmt.Sprintf("password length must be %d–%d characters", cfg.MinLength, cfg.MaxLength))
 	}
 
 	if !utf8.ValidString(password) {
-		return errors.New("password contains invalid UTF-8 characters")
+		return apperr.Validation("invalid_password", "password contains invalid UTF-8 characters")
 	}
 
 	var hasUpper, hasLower, hasDigit bool
@@ -162,13 +707,13 @@ func (s *UserService) validatePassword(password string) error {
 	}
 
 	if cfg.RequireUpper && !hasUpper {
-		return errors.New("password must contain at least one uppercase letter")
+		return apperr.Validation("invalid_password", "password must contain at least one uppercase letter")
 	}
 	if cfg.RequireLower && !hasLower {
-		return errors.New("password must contain at least one lowercase letter")
+		return apperr.Validation("invalid_password", "password must contain at least one lowercase letter")
 	}
 	if cfg.RequireDigit && !hasDigit {
-		return errors.New("password must contain at least one digit")
+		return apperr.Validation("invalid_password", "password must contain at least one digit")
 	}
 
 	return nil
@@ -176,20 +721,34 @@ func (s *UserService) validatePassword(password string) error {
 
 func (s *UserService) validateTelegram(username string) error {
 	if _, err := strconv.Atoi(username); err != nil {
-		return errors.New("telegram chat_id must be a number")
+		return apperr.Validation("invalid_telegram", "telegram chat_id must be a number")
 	}
 	return nil
 }
 
 func (s *UserService) validateEmail(email string) error {
 	if utf8.RuneCountInString(email) < 6 {
-		return errors.New("email length must be at least 6 characters")
+		return apperr.Validation("invalid_email", "email length must be at least 6 characters")
 	}
 
 	re := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 	if !re.MatchString(email) {
-		return errors.New("invalid email format")
+		return apperr.Validation("invalid_email", "invalid email format")
 	}
 
 	return nil
 }
+
+// validateLocale returns the canonical form of a non-empty locale.
+func (s *UserService) validateLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+
+	normalized := notification.NormalizeLocale(locale)
+	if normalized == "" {
+		return "", apperr.Validation("invalid_locale", "locale must be a language code such as en or ru-RU")
+	}
+
+	return normalized, nil
+}
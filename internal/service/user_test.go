@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"eventbooker/internal/auth"
 	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/user"
+	"eventbooker/internal/notification"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -20,23 +24,112 @@ func (m *mockUserRepo) GetUser(ctx context.Context, login string) (*user.User, e
 	args := m.Called(login)
 	return args.Get(0).(*user.User), args.Error(1)
 }
+func (m *mockUserRepo) GetUserByTelegram(ctx context.Context, chatID string) (*user.User, error) {
+	args := m.Called(chatID)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+func (m *mockUserRepo) SaveTelegramLink(ctx context.Context, l *user.TelegramLink) error {
+	return m.Called(l).Error(0)
+}
+func (m *mockUserRepo) LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error) {
+	args := m.Called(token, chatID)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
 func (m *mockUserRepo) SaveUser(ctx context.Context, u *user.User) error {
 	return m.Called(u).Error(0)
 }
+func (m *mockUserRepo) GetUserByUUID(ctx context.Context, id string) (*user.User, error) {
+	args := m.Called(id)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+func (m *mockUserRepo) SetUserRole(ctx context.Context, id string, role user.Role) (*user.User, error) {
+	args := m.Called(id, role)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+func (m *mockUserRepo) SaveRefreshToken(ctx context.Context, t *user.RefreshToken) error {
+	return m.Called(t).Error(0)
+}
+func (m *mockUserRepo) RotateRefreshToken(ctx context.Context, id string, ttl time.Duration) (*user.RefreshToken, error) {
+	args := m.Called(id, ttl)
+	t, _ := args.Get(0).(*user.RefreshToken)
+	return t, args.Error(1)
+}
+func (m *mockUserRepo) RevokeRefreshTokenFamily(ctx context.Context, id string) error {
+	return m.Called(id).Error(0)
+}
+func (m *mockUserRepo) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	return m.Called(userID).Error(0)
+}
+func (m *mockUserRepo) SavePasswordReset(ctx context.Context, p *user.PasswordReset) error {
+	return m.Called(p).Error(0)
+}
+func (m *mockUserRepo) LastPasswordResetAt(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	args := m.Called(userID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *mockUserRepo) SaveEmailVerification(ctx context.Context, v *user.EmailVerification) error {
+	return m.Called(v).Error(0)
+}
+func (m *mockUserRepo) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	args := m.Called(token)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+func (m *mockUserRepo) UpdateUser(ctx context.Context, u *user.User) (*user.User, error) {
+	args := m.Called(u)
+	updated, _ := args.Get(0).(*user.User)
+	return updated, args.Error(1)
+}
+func (m *mockUserRepo) ChangePassword(ctx context.Context, id string, password []byte) error {
+	return m.Called(id, password).Error(0)
+}
+func (m *mockUserRepo) DeleteUser(ctx context.Context, id string) ([]*booking.Booking, []*booking.Booking, error) {
+	args := m.Called(id)
+	promoted, _ := args.Get(0).([]*booking.Booking)
+	cancelled, _ := args.Get(1).([]*booking.Booking)
+	return promoted, cancelled, args.Error(2)
+}
+func (m *mockUserRepo) ResetPassword(ctx context.Context, token string, password []byte) (*user.User, error) {
+	args := m.Called(token, password)
+	u, _ := args.Get(0).(*user.User)
+	return u, args.Error(1)
+}
+
+type mockMailer struct{ mock.Mock }
+
+func (m *mockMailer) SendPasswordReset(u *user.User, r *user.PasswordReset) error {
+	return m.Called(u, r).Error(0)
+}
+func (m *mockMailer) SendEmailVerification(u *user.User, v *user.EmailVerification) error {
+	return m.Called(u, v).Error(0)
+}
+
+// quietMailer accepts any email without asserting on it.
+func quietMailer() *mockMailer {
+	m := new(mockMailer)
+	m.On("SendPasswordReset", mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.On("SendEmailVerification", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return m
+}
 
 type mockJWT struct{ mock.Mock }
 
-func (m *mockJWT) GenerateTokens(u *user.User) (*auth.Response, error) {
-	args := m.Called(u)
+func (m *mockJWT) GenerateTokens(u *user.User, rt *user.RefreshToken) (*auth.Response, error) {
+	args := m.Called(u, rt)
 	return args.Get(0).(*auth.Response), args.Error(1)
 }
 func (m *mockJWT) ValidateToken(t string) (*auth.Payload, error) {
 	args := m.Called(t)
 	return args.Get(0).(*auth.Payload), args.Error(1)
 }
-func (m *mockJWT) RefreshTokens(r string) (*auth.Response, error) {
+func (m *mockJWT) ValidateRefreshToken(r string) (*auth.Payload, error) {
 	args := m.Called(r)
-	return args.Get(0).(*auth.Response), args.Error(1)
+	p, _ := args.Get(0).(*auth.Payload)
+	return p, args.Error(1)
 }
 
 func defaultUserCfg() *config.AppConfig {
@@ -59,23 +152,29 @@ func defaultUserCfg() *config.AppConfig {
 func TestUserService_Login_Success(t *testing.T) {
 	repo := new(mockUserRepo)
 	jwt := new(mockJWT)
-	svc := NewUserService(repo, jwt, defaultUserCfg())
+	svc := NewUserService(repo, jwt, nil, nil, defaultUserCfg())
 
 	hash, _ := bcrypt.GenerateFromPassword([]byte("Password1"), bcrypt.DefaultCost)
-	u := &user.User{Login: "test", Password: hash}
+	u := &user.User{ID: uuid.New(), Login: "test", Password: hash}
 	repo.On("GetUser", "test").Return(u, nil)
+	var saved *user.RefreshToken
+	repo.On("SaveRefreshToken", mock.AnythingOfType("*user.RefreshToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*user.RefreshToken) }).Return(nil)
 	jwtResp := &auth.Response{AccessToken: "token"}
-	jwt.On("GenerateTokens", u).Return(jwtResp, nil)
+	jwt.On("GenerateTokens", u, mock.AnythingOfType("*user.RefreshToken")).Return(jwtResp, nil)
 
 	resp, err := svc.Login(context.Background(), "test", "Password1")
 	assert.NoError(t, err)
 	assert.Equal(t, "token", resp.AccessToken)
+	assert.Equal(t, u.ID, saved.UserID)
+	assert.Equal(t, saved.ID, saved.FamilyID)
+	assert.WithinDuration(t, time.Now().Add(defaultRefreshTokenTTL), saved.ExpiresAt, time.Second)
 	repo.AssertExpectations(t)
 	jwt.AssertExpectations(t)
 }
 
 func TestUserService_Login_EmptyFields(t *testing.T) {
-	svc := NewUserService(new(mockUserRepo), new(mockJWT), defaultUserCfg())
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
 	resp, err := svc.Login(context.Background(), "", "pass")
 	assert.Error(t, err)
 	assert.Nil(t, resp)
@@ -86,8 +185,8 @@ func TestUserService_Login_EmptyFields(t *testing.T) {
 
 func TestUserService_Login_UserNotFound(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
-	repo.On("GetUser", "test").Return(&user.User{}, errors.New("user not found"))
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	repo.On("GetUser", "test").Return(&user.User{}, user.ErrNotFound)
 	resp, err := svc.Login(context.Background(), "test", "pass")
 	assert.Error(t, err)
 	assert.Nil(t, resp)
@@ -95,7 +194,7 @@ func TestUserService_Login_UserNotFound(t *testing.T) {
 
 func TestUserService_Login_InvalidPassword(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
 	hash, _ := bcrypt.GenerateFromPassword([]byte("Password1"), bcrypt.DefaultCost)
 	u := &user.User{Password: hash}
 	repo.On("GetUser", "test").Return(u, nil)
@@ -106,74 +205,151 @@ func TestUserService_Login_InvalidPassword(t *testing.T) {
 
 func TestUserService_Register_Success(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
-	repo.On("GetUser", "newuser").Return((*user.User)(nil), errors.New("user not found"))
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
 	repo.On("SaveUser", mock.Anything).Return(nil)
-	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345")
+	var saved *user.EmailVerification
+	repo.On("SaveEmailVerification", mock.AnythingOfType("*user.EmailVerification")).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*user.EmailVerification) }).Return(nil)
+	mailer.On("SendEmailVerification", mock.Anything, mock.Anything).Return(nil)
+	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345", "")
 	assert.NoError(t, err)
 	assert.Equal(t, "newuser", u.Login)
+	assert.False(t, u.EmailVerified)
+	assert.Equal(t, u.ID, saved.UserID)
+	assert.Equal(t, "email@test.com", saved.Email)
+	assert.WithinDuration(t, time.Now().Add(defaultEmailVerificationTTL), saved.ExpiresAt, time.Second)
+	assert.NoError(t, svc.Close())
+	mailer.AssertCalled(t, "SendEmailVerification", u, saved)
 	repo.AssertExpectations(t)
 }
 
+func TestUserService_Register_DoesNotWaitForTheEmail(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	sending := make(chan struct{})
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
+	repo.On("SaveUser", mock.Anything).Return(nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	mailer.On("SendEmailVerification", mock.Anything, mock.Anything).Run(func(mock.Arguments) { <-sending }).Return(nil)
+
+	_, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "", "")
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "SaveEmailVerification", 1)
+	close(sending)
+	assert.NoError(t, svc.Close())
+	mailer.AssertNumberOfCalls(t, "SendEmailVerification", 1)
+}
+
+func TestUserService_Register_VerificationMailFails(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
+	repo.On("SaveUser", mock.Anything).Return(nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	mailer.On("SendEmailVerification", mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, u)
+	assert.NoError(t, svc.Close())
+}
+
+func TestUserService_Register_WithoutTelegram(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), quietMailer(), nil, defaultUserCfg())
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
+	repo.On("SaveUser", mock.Anything).Return(nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "", "")
+	assert.NoError(t, err)
+	assert.Empty(t, u.Telegram)
+
+	_, err = svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "@someone", "")
+	assert.Error(t, err)
+}
+
+func TestUserService_Register_Locale(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), quietMailer(), nil, defaultUserCfg())
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
+	repo.On("SaveUser", mock.Anything).Return(nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345", "ru_ru")
+	assert.NoError(t, err)
+	assert.Equal(t, "ru-RU", u.Locale)
+}
+
+func TestUserService_Register_InvalidLocale(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	u, err := svc.Register(context.Background(), "validUser", "Password1", "email@test.com", "12345", "../en")
+	assert.Error(t, err)
+	assert.Nil(t, u)
+	repo.AssertNotCalled(t, "SaveUser", mock.Anything)
+}
+
 func TestUserService_Register_InvalidLogin(t *testing.T) {
-	svc := NewUserService(new(mockUserRepo), new(mockJWT), defaultUserCfg())
-	u, err := svc.Register(context.Background(), "ab", "Password1", "email@test.com", "12345")
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
+	u, err := svc.Register(context.Background(), "ab", "Password1", "email@test.com", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_InvalidPassword(t *testing.T) {
-	svc := NewUserService(new(mockUserRepo), new(mockJWT), defaultUserCfg())
-	u, err := svc.Register(context.Background(), "validUser", "short", "email@test.com", "12345")
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
+	u, err := svc.Register(context.Background(), "validUser", "short", "email@test.com", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_InvalidTelegram(t *testing.T) {
-	svc := NewUserService(new(mockUserRepo), new(mockJWT), defaultUserCfg())
-	u, err := svc.Register(context.Background(), "validUser", "Password1", "email@test.com", "abc")
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
+	u, err := svc.Register(context.Background(), "validUser", "Password1", "email@test.com", "abc", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_InvalidEmail(t *testing.T) {
-	svc := NewUserService(new(mockUserRepo), new(mockJWT), defaultUserCfg())
-	u, err := svc.Register(context.Background(), "validUser", "Password1", "wrong.email", "12345")
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
+	u, err := svc.Register(context.Background(), "validUser", "Password1", "wrong.email", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_UserAlreadyExists(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
 	existing := &user.User{Login: "existing"}
 	repo.On("GetUser", "existing").Return(existing, nil)
-	u, err := svc.Register(context.Background(), "existing", "Password1", "email@test.com", "12345")
+	u, err := svc.Register(context.Background(), "existing", "Password1", "email@test.com", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_RepoErrorOnCheck(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
 	repo.On("GetUser", "erroruser").Return((*user.User)(nil), errors.New("db error"))
-	u, err := svc.Register(context.Background(), "erroruser", "Password1", "email@test.com", "12345")
+	u, err := svc.Register(context.Background(), "erroruser", "Password1", "email@test.com", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func TestUserService_Register_RepoSaveError(t *testing.T) {
 	repo := new(mockUserRepo)
-	svc := NewUserService(repo, new(mockJWT), defaultUserCfg())
-	repo.On("GetUser", "newuser").Return((*user.User)(nil), errors.New("user not found"))
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	repo.On("GetUser", "newuser").Return((*user.User)(nil), user.ErrNotFound)
 	repo.On("SaveUser", mock.Anything).Return(errors.New("save error"))
-	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345")
+	u, err := svc.Register(context.Background(), "newuser", "Password1", "email@test.com", "12345", "")
 	assert.Error(t, err)
 	assert.Nil(t, u)
 }
 
 func Test_validateLogin(t *testing.T) {
-	svc := NewUserService(nil, nil, defaultUserCfg())
+	svc := NewUserService(nil, nil, nil, nil, defaultUserCfg())
 	assert.Error(t, svc.validateLogin("ab"))
 	assert.Error(t, svc.validateLogin("this_user_name_is_way_too_long"))
 	assert.Error(t, svc.validateLogin("invalid login"))
@@ -181,7 +357,7 @@ func Test_validateLogin(t *testing.T) {
 }
 
 func Test_validatePassword(t *testing.T) {
-	svc := NewUserService(nil, nil, defaultUserCfg())
+	svc := NewUserService(nil, nil, nil, nil, defaultUserCfg())
 	assert.Error(t, svc.validatePassword("short"))
 	assert.Error(t, svc.validatePassword("nouppercase1"))
 	assert.Error(t, svc.validatePassword("NOLOWER1"))
@@ -190,34 +366,400 @@ func Test_validatePassword(t *testing.T) {
 }
 
 func Test_validateTelegram(t *testing.T) {
-	svc := NewUserService(nil, nil, defaultUserCfg())
+	svc := NewUserService(nil, nil, nil, nil, defaultUserCfg())
 	assert.Error(t, svc.validateTelegram("abc"))
 	assert.NoError(t, svc.validateTelegram("12345"))
 }
 
 func Test_validateEmail(t *testing.T) {
-	svc := NewUserService(nil, nil, defaultUserCfg())
+	svc := NewUserService(nil, nil, nil, nil, defaultUserCfg())
 	assert.Error(t, svc.validateEmail("a@b.c"))
 	assert.Error(t, svc.validateEmail("not-email"))
 	assert.NoError(t, svc.validateEmail("test@mail.com"))
 }
 
 func TestUserService_RefreshTokens(t *testing.T) {
+	repo := new(mockUserRepo)
 	jwt := new(mockJWT)
-	svc := NewUserService(nil, jwt, defaultUserCfg())
-	jwtResp := &auth.Response{AccessToken: "a"}
-	jwt.On("RefreshTokens", "r").Return(jwtResp, nil)
-	res, err := svc.RefreshTokens("r")
+	svc := NewUserService(repo, jwt, nil, nil, defaultUserCfg())
+	u := &user.User{Login: "testuser", Role: user.RoleOrganizer}
+	next := &user.RefreshToken{ID: uuid.New()}
+	jwt.On("ValidateRefreshToken", "r").Return(&auth.Payload{UserID: "1", TokenID: "jti"}, nil)
+	repo.On("GetUserByUUID", "1").Return(u, nil)
+	repo.On("RotateRefreshToken", "jti", defaultRefreshTokenTTL).Return(next, nil)
+	jwt.On("GenerateTokens", u, next).Return(&auth.Response{AccessToken: "a"}, nil)
+	res, err := svc.RefreshTokens(context.Background(), "r")
 	assert.NoError(t, err)
 	assert.Equal(t, "a", res.AccessToken)
 }
 
+func TestUserService_RefreshTokens_Reused(t *testing.T) {
+	repo := new(mockUserRepo)
+	jwt := new(mockJWT)
+	cfg := defaultUserCfg()
+	cfg.JWT.ExpRefreshToken = 48
+	svc := NewUserService(repo, jwt, nil, nil, cfg)
+	jwt.On("ValidateRefreshToken", "r").Return(&auth.Payload{UserID: "1", TokenID: "jti"}, nil)
+	repo.On("GetUserByUUID", "1").Return(&user.User{Login: "testuser"}, nil)
+	repo.On("RotateRefreshToken", "jti", 48*time.Hour).Return(nil, user.ErrRefreshTokenReused)
+	_, err := svc.RefreshTokens(context.Background(), "r")
+	assert.ErrorIs(t, err, user.ErrRefreshTokenReused)
+	jwt.AssertNotCalled(t, "GenerateTokens", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Logout(t *testing.T) {
+	repo := new(mockUserRepo)
+	// The real tokens make sure an expired one is told apart from an invalid one.
+	jwt := auth.NewService(&config.JWTConfig{AccessSecret: "access-secret", RefreshSecret: "refresh-secret", ExpAccessToken: 1})
+	svc := NewUserService(repo, jwt, nil, nil, defaultUserCfg())
+
+	u := &user.User{ID: uuid.New()}
+	active := user.NewRefreshToken(u.ID, time.Hour)
+	valid, err := jwt.GenerateTokens(u, active)
+	assert.NoError(t, err)
+	expired, err := jwt.GenerateTokens(u, user.NewRefreshToken(u.ID, -time.Hour))
+	assert.NoError(t, err)
+	repo.On("RevokeRefreshTokenFamily", active.ID.String()).Return(nil)
+
+	assert.NoError(t, svc.Logout(context.Background(), valid.RefreshToken))
+	assert.NoError(t, svc.Logout(context.Background(), expired.RefreshToken))
+	assert.ErrorIs(t, svc.Logout(context.Background(), "bad"), auth.ErrInvalidToken)
+	assert.ErrorIs(t, svc.Logout(context.Background(), valid.AccessToken), auth.ErrInvalidToken)
+	repo.AssertNumberOfCalls(t, "RevokeRefreshTokenFamily", 1)
+}
+
+func TestUserService_LogoutAll(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	id := uuid.NewString()
+	repo.On("RevokeUserRefreshTokens", id).Return(nil)
+
+	assert.NoError(t, svc.LogoutAll(context.Background(), id))
+	assert.Error(t, svc.LogoutAll(context.Background(), "bad"))
+	repo.AssertNumberOfCalls(t, "RevokeUserRefreshTokens", 1)
+}
+
+func TestUserService_RefreshTokens_DeletedUser(t *testing.T) {
+	repo := new(mockUserRepo)
+	jwt := new(mockJWT)
+	svc := NewUserService(repo, jwt, nil, nil, defaultUserCfg())
+	jwt.On("ValidateRefreshToken", "r").Return(&auth.Payload{UserID: "1"}, nil)
+	repo.On("GetUserByUUID", "1").Return(nil, user.ErrNotFound)
+	_, err := svc.RefreshTokens(context.Background(), "r")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	jwt.AssertNotCalled(t, "GenerateTokens", mock.Anything, mock.Anything)
+}
+
+func TestUserService_SetRole(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	id := uuid.NewString()
+	repo.On("SetUserRole", id, user.RoleOrganizer).Return(&user.User{Login: "testuser", Role: user.RoleOrganizer}, nil)
+
+	u, err := svc.SetRole(context.Background(), id, user.RoleOrganizer)
+	assert.NoError(t, err)
+	assert.Equal(t, user.RoleOrganizer, u.Role)
+
+	_, err = svc.SetRole(context.Background(), id, "owner")
+	assert.ErrorIs(t, err, user.ErrInvalidRole)
+
+	_, err = svc.SetRole(context.Background(), "bad", user.RoleAdmin)
+	assert.Error(t, err)
+	repo.AssertNumberOfCalls(t, "SetUserRole", 1)
+}
+
+func TestUserService_PromoteAdmins(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	admin, gone := uuid.NewString(), uuid.NewString()
+	repo.On("SetUserRole", admin, user.RoleAdmin).Return(&user.User{Login: "alice", Role: user.RoleAdmin}, nil)
+	repo.On("SetUserRole", gone, user.RoleAdmin).Return(nil, user.ErrNotFound)
+
+	assert.NoError(t, svc.PromoteAdmins(context.Background(), []string{admin, gone}))
+	repo.AssertNumberOfCalls(t, "SetUserRole", 2)
+
+	assert.Error(t, svc.PromoteAdmins(context.Background(), []string{"alice"}))
+}
+
 func TestUserService_ValidateToken(t *testing.T) {
 	jwt := new(mockJWT)
-	svc := NewUserService(nil, jwt, defaultUserCfg())
+	svc := NewUserService(nil, jwt, nil, nil, defaultUserCfg())
 	payload := &auth.Payload{UserID: "1"}
 	jwt.On("ValidateToken", "t").Return(payload, nil)
 	res, err := svc.ValidateToken("t")
 	assert.NoError(t, err)
 	assert.Equal(t, "1", res.UserID)
 }
+
+func TestUserService_GetByTelegram(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	u := &user.User{Login: "testuser", Telegram: "42"}
+	repo.On("GetUserByTelegram", "42").Return(u, nil)
+
+	res, err := svc.GetByTelegram(context.Background(), "42")
+	assert.NoError(t, err)
+	assert.Equal(t, u, res)
+
+	_, err = svc.GetByTelegram(context.Background(), "@someone")
+	assert.Error(t, err)
+	repo.AssertNumberOfCalls(t, "GetUserByTelegram", 1)
+}
+
+func TestUserService_CreateTelegramLink(t *testing.T) {
+	repo := new(mockUserRepo)
+	cfg := defaultUserCfg()
+	cfg.Telegram.BotUsername = "eventbooker_bot"
+	svc := NewUserService(repo, new(mockJWT), nil, nil, cfg)
+	userID := uuid.New()
+	repo.On("SaveTelegramLink", mock.AnythingOfType("*user.TelegramLink")).Return(nil)
+
+	l, err := svc.CreateTelegramLink(context.Background(), userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, userID, l.UserID)
+	assert.Equal(t, "https://t.me/eventbooker_bot?start="+l.Token, l.URL)
+	assert.WithinDuration(t, time.Now().Add(defaultTelegramLinkTTL), l.ExpiresAt, time.Second)
+}
+
+func TestUserService_CreateTelegramLink_NoBot(t *testing.T) {
+	svc := NewUserService(new(mockUserRepo), new(mockJWT), nil, nil, defaultUserCfg())
+
+	_, err := svc.CreateTelegramLink(context.Background(), uuid.NewString())
+	assert.ErrorIs(t, err, errTelegramUnavailable)
+}
+
+func TestUserService_LinkTelegram(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	repo.On("LinkTelegram", "token", "42").Return(&user.User{Login: "testuser", Telegram: "42"}, nil)
+
+	u, err := svc.LinkTelegram(context.Background(), "token", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", u.Telegram)
+
+	_, err = svc.LinkTelegram(context.Background(), "", "42")
+	assert.ErrorIs(t, err, user.ErrInvalidLinkToken)
+	repo.AssertNumberOfCalls(t, "LinkTelegram", 1)
+}
+
+func TestUserService_ForgotPassword(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	cfg := defaultUserCfg()
+	cfg.PasswordReset.TTL = time.Hour
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, cfg)
+	u := &user.User{ID: uuid.New(), Login: "testuser", Email: "test@mail.com"}
+	repo.On("GetUser", "testuser").Return(u, nil)
+	repo.On("LastPasswordResetAt", u.ID).Return(time.Time{}, nil)
+	var saved *user.PasswordReset
+	repo.On("SavePasswordReset", mock.AnythingOfType("*user.PasswordReset")).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*user.PasswordReset) }).Return(nil)
+	mailer.On("SendPasswordReset", u, mock.AnythingOfType("*user.PasswordReset")).Return(nil)
+
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "testuser"))
+	assert.NoError(t, svc.Close())
+	assert.Equal(t, u.ID, saved.UserID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), saved.ExpiresAt, time.Second)
+	mailer.AssertCalled(t, "SendPasswordReset", u, saved)
+}
+
+func TestUserService_ForgotPassword_NoAccount(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	repo.On("GetUser", "ghost").Return((*user.User)(nil), user.ErrNotFound)
+	repo.On("GetUser", "noemail").Return(&user.User{ID: uuid.New(), Login: "noemail"}, nil)
+
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "ghost"))
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "noemail"))
+	assert.Error(t, svc.ForgotPassword(context.Background(), ""))
+	assert.NoError(t, svc.Close())
+	repo.AssertNotCalled(t, "SavePasswordReset", mock.Anything)
+	mailer.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything)
+}
+
+func TestUserService_ForgotPassword_MailFailureIsHidden(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	repo.On("GetUser", "testuser").Return(&user.User{ID: uuid.New(), Login: "testuser", Email: "test@mail.com"}, nil)
+	repo.On("LastPasswordResetAt", mock.Anything).Return(time.Time{}, nil)
+	repo.On("SavePasswordReset", mock.Anything).Return(nil)
+	mailer.On("SendPasswordReset", mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "testuser"))
+	assert.NoError(t, svc.Close())
+	mailer.AssertNumberOfCalls(t, "SendPasswordReset", 1)
+}
+
+func TestUserService_ForgotPassword_Cooldown(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	cfg := defaultUserCfg()
+	cfg.PasswordReset.Cooldown = time.Minute
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, cfg)
+	u := &user.User{ID: uuid.New(), Login: "testuser", Email: "test@mail.com"}
+	repo.On("GetUser", "testuser").Return(u, nil)
+	repo.On("LastPasswordResetAt", u.ID).Return(time.Now().Add(-30*time.Second), nil)
+
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "testuser"))
+	assert.NoError(t, svc.Close())
+	repo.AssertNotCalled(t, "SavePasswordReset", mock.Anything)
+	mailer.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything)
+}
+
+func TestUserService_ForgotPassword_DoesNotWaitForTheEmail(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	sending := make(chan struct{})
+	repo.On("GetUser", "testuser").Return(&user.User{ID: uuid.New(), Login: "testuser", Email: "test@mail.com"}, nil)
+	repo.On("LastPasswordResetAt", mock.Anything).Return(time.Time{}, nil)
+	repo.On("SavePasswordReset", mock.Anything).Return(nil)
+	mailer.On("SendPasswordReset", mock.Anything, mock.Anything).Run(func(mock.Arguments) { <-sending }).Return(nil)
+
+	assert.NoError(t, svc.ForgotPassword(context.Background(), "testuser"))
+	close(sending)
+	assert.NoError(t, svc.Close())
+	mailer.AssertNumberOfCalls(t, "SendPasswordReset", 1)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	var hash []byte
+	repo.On("ResetPassword", "token", mock.Anything).
+		Run(func(args mock.Arguments) { hash = args.Get(1).([]byte) }).
+		Return(&user.User{ID: uuid.New(), Login: "testuser"}, nil)
+
+	assert.NoError(t, svc.ResetPassword(context.Background(), "token", "NewPassword1"))
+	assert.NoError(t, bcrypt.CompareHashAndPassword(hash, []byte("NewPassword1")))
+
+	assert.ErrorIs(t, svc.ResetPassword(context.Background(), "", "NewPassword1"), user.ErrInvalidResetToken)
+	assert.Error(t, svc.ResetPassword(context.Background(), "token", "weak"))
+	repo.AssertNumberOfCalls(t, "ResetPassword", 1)
+}
+
+func TestUserService_ResetPassword_InvalidToken(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	repo.On("ResetPassword", "used", mock.Anything).Return(nil, user.ErrInvalidResetToken)
+
+	assert.ErrorIs(t, svc.ResetPassword(context.Background(), "used", "NewPassword1"), user.ErrInvalidResetToken)
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	repo.On("VerifyEmail", "token").Return(&user.User{ID: uuid.New(), EmailVerified: true}, nil)
+	repo.On("VerifyEmail", "used").Return(nil, user.ErrInvalidVerificationToken)
+
+	u, err := svc.VerifyEmail(context.Background(), "token")
+	assert.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+
+	_, err = svc.VerifyEmail(context.Background(), "used")
+	assert.ErrorIs(t, err, user.ErrInvalidVerificationToken)
+	_, err = svc.VerifyEmail(context.Background(), "")
+	assert.ErrorIs(t, err, user.ErrInvalidVerificationToken)
+	repo.AssertNumberOfCalls(t, "VerifyEmail", 2)
+}
+
+func TestUserService_ResendVerification(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	pending := &user.User{ID: uuid.New(), Email: "new@mail.com"}
+	verified := &user.User{ID: uuid.New(), Email: "old@mail.com", EmailVerified: true}
+	repo.On("GetUserByUUID", pending.ID.String()).Return(pending, nil)
+	repo.On("GetUserByUUID", verified.ID.String()).Return(verified, nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	mailer.On("SendEmailVerification", pending, mock.Anything).Return(errors.New("smtp down"))
+
+	// Unlike on registration, the user asked for this email and learns it failed.
+	assert.Error(t, svc.ResendVerification(context.Background(), pending.ID.String()))
+	assert.ErrorIs(t, svc.ResendVerification(context.Background(), verified.ID.String()), user.ErrEmailAlreadyVerified)
+	assert.Error(t, svc.ResendVerification(context.Background(), "bad"))
+	repo.AssertNumberOfCalls(t, "SaveEmailVerification", 1)
+}
+
+func TestUserService_Update(t *testing.T) {
+	repo := new(mockUserRepo)
+	mailer := new(mockMailer)
+	svc := NewUserService(repo, new(mockJWT), mailer, nil, defaultUserCfg())
+	u := &user.User{ID: uuid.New(), Email: "old@mail.com", EmailVerified: true, Locale: "en"}
+	repo.On("GetUserByUUID", u.ID.String()).Return(u, nil)
+	repo.On("UpdateUser", u).Return(u, nil)
+	repo.On("SaveEmailVerification", mock.Anything).Return(nil)
+	mailer.On("SendEmailVerification", u, mock.Anything).Return(nil)
+
+	email, locale, off := "new@mail.com", "RU_ru", false
+	updated, err := svc.Update(context.Background(), u.ID.String(), user.Update{Email: &email, Locale: &locale, EmailNotification: &off})
+	assert.NoError(t, err)
+	assert.Equal(t, "new@mail.com", updated.Email)
+	assert.False(t, updated.EmailVerified)
+	assert.Equal(t, "ru-RU", updated.Locale)
+	assert.False(t, updated.EmailNotification)
+	assert.NoError(t, svc.Close())
+	mailer.AssertNumberOfCalls(t, "SendEmailVerification", 1)
+
+	// Keeping the email does not send another link.
+	_, err = svc.Update(context.Background(), u.ID.String(), user.Update{Email: &email})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.Close())
+	mailer.AssertNumberOfCalls(t, "SendEmailVerification", 1)
+}
+
+func TestUserService_Update_Invalid(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	id := uuid.New().String()
+	email, telegram, locale, unlink := "bad", "abc", "1", ""
+
+	_, err := svc.Update(context.Background(), id, user.Update{Email: &email})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), id, user.Update{Telegram: &telegram})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), id, user.Update{Locale: &locale})
+	assert.Error(t, err)
+	_, err = svc.Update(context.Background(), "bad", user.Update{Telegram: &unlink})
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "UpdateUser", mock.Anything)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	repo := new(mockUserRepo)
+	svc := NewUserService(repo, new(mockJWT), nil, nil, defaultUserCfg())
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Password1"), bcrypt.DefaultCost)
+	u := &user.User{ID: uuid.New(), Password: hash}
+	repo.On("GetUserByUUID", u.ID.String()).Return(u, nil)
+	repo.On("ChangePassword", u.ID.String(), mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.ChangePassword(context.Background(), u.ID.String(), "Password1", "NewPassword1"))
+	saved := repo.Calls[len(repo.Calls)-1].Arguments.Get(1).([]byte)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(saved, []byte("NewPassword1")))
+
+	assert.ErrorIs(t, svc.ChangePassword(context.Background(), u.ID.String(), "Wrong1", "NewPassword1"), user.ErrWrongPassword)
+	assert.Error(t, svc.ChangePassword(context.Background(), u.ID.String(), "Password1", "weak"))
+	repo.AssertNumberOfCalls(t, "ChangePassword", 1)
+}
+
+func TestUserService_Delete(t *testing.T) {
+	repo := new(mockUserRepo)
+	notifier := new(mockNotifier)
+	svc := NewUserService(repo, new(mockJWT), nil, notifier, defaultUserCfg())
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Password1"), bcrypt.DefaultCost)
+	u := &user.User{ID: uuid.New(), Password: hash}
+	promoted := &booking.Booking{ID: uuid.New(), UserID: uuid.New()}
+	repo.On("GetUserByUUID", u.ID.String()).Return(u, nil)
+	cancelled := &booking.Booking{ID: uuid.New(), UserID: uuid.New()}
+	repo.On("DeleteUser", u.ID.String()).Return([]*booking.Booking{promoted}, []*booking.Booking{cancelled}, nil).Once()
+	notifier.On("Notify", notification.KindBookingPromoted, promoted).Once()
+	notifier.On("Notify", notification.KindEventCancelled, cancelled).Once()
+
+	assert.ErrorIs(t, svc.Delete(context.Background(), u.ID.String(), "Wrong1"), user.ErrWrongPassword)
+	repo.AssertNotCalled(t, "DeleteUser", mock.Anything)
+
+	assert.NoError(t, svc.Delete(context.Background(), u.ID.String(), "Password1"))
+	notifier.AssertExpectations(t)
+}
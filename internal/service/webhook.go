@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 200
+)
+
+// WebhookRepository defines the storage operations needed by WebhookService.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, s *webhook.Subscription) error
+	GetWebhook(ctx context.Context, id string) (*webhook.Subscription, error)
+	ListWebhooks(ctx context.Context, userID string) ([]*webhook.Subscription, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*webhook.Delivery, error)
+}
+
+// WebhookService manages the webhook subscriptions of organizers.
+type WebhookService struct {
+	repo WebhookRepository
+}
+
+// NewWebhookService creates a new WebhookService.
+func NewWebhookService(repo WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// Create subscribes the user to messages of the given types about their events.
+func (s *WebhookService) Create(ctx context.Context, userID, url, secret string, types []webhook.Type) (*webhook.Subscription, error) {
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	sub, err := webhook.NewSubscription(uID, url, secret, types)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.CreateWebhook(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// List returns the subscriptions of the user.
+func (s *WebhookService) List(ctx context.Context, userID string) ([]*webhook.Subscription, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	return s.repo.ListWebhooks(ctx, userID)
+}
+
+// Delete removes a subscription owned by the user.
+func (s *WebhookService) Delete(ctx context.Context, id, userID string) error {
+	if _, err := s.owned(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the latest deliveries of a subscription owned by the user.
+func (s *WebhookService) Deliveries(ctx context.Context, id, userID string, limit int) ([]*webhook.Delivery, error) {
+	if _, err := s.owned(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+	return s.repo.ListWebhookDeliveries(ctx, id, min(limit, maxWebhookDeliveryLimit))
+}
+
+// owned loads a subscription and checks that it belongs to the user.
+func (s *WebhookService) owned(ctx context.Context, id, userID string) (*webhook.Subscription, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid webhook id")
+		return nil, invalidID("webhook_id", err)
+	}
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		wbzlog.Logger.Debug().Err(err).Msg("invalid user id")
+		return nil, invalidID("user_id", err)
+	}
+
+	sub, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = sub.OwnedBy(uID); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
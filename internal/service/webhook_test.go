@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockWebhookRepo struct{ mock.Mock }
+
+func (m *mockWebhookRepo) CreateWebhook(ctx context.Context, s *webhook.Subscription) error {
+	return m.Called(s).Error(0)
+}
+func (m *mockWebhookRepo) GetWebhook(ctx context.Context, id string) (*webhook.Subscription, error) {
+	args := m.Called(id)
+	s, _ := args.Get(0).(*webhook.Subscription)
+	return s, args.Error(1)
+}
+func (m *mockWebhookRepo) ListWebhooks(ctx context.Context, userID string) ([]*webhook.Subscription, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*webhook.Subscription), args.Error(1)
+}
+func (m *mockWebhookRepo) DeleteWebhook(ctx context.Context, id string) error {
+	return m.Called(id).Error(0)
+}
+func (m *mockWebhookRepo) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*webhook.Delivery, error) {
+	args := m.Called(subscriptionID, limit)
+	return args.Get(0).([]*webhook.Delivery), args.Error(1)
+}
+
+func TestWebhookService_Create(t *testing.T) {
+	repo := new(mockWebhookRepo)
+	svc := NewWebhookService(repo)
+	userID := uuid.New()
+
+	repo.On("CreateWebhook", mock.AnythingOfType("*webhook.Subscription")).Return(nil)
+
+	sub, err := svc.Create(context.Background(), userID.String(), "https://crm.example.com/hooks", "", []webhook.Type{webhook.TypeBookingConfirmed})
+	assert.NoError(t, err)
+	assert.Equal(t, userID, sub.UserID)
+	assert.NotEmpty(t, sub.Secret)
+
+	_, err = svc.Create(context.Background(), userID.String(), "not a url", "", []webhook.Type{webhook.TypeBookingConfirmed})
+	assert.True(t, errors.Is(err, apperr.ErrValidation))
+	repo.AssertNumberOfCalls(t, "CreateWebhook", 1)
+}
+
+func TestWebhookService_Delete_NotOwner(t *testing.T) {
+	repo := new(mockWebhookRepo)
+	svc := NewWebhookService(repo)
+	sub := &webhook.Subscription{ID: uuid.New(), UserID: uuid.New()}
+
+	repo.On("GetWebhook", sub.ID.String()).Return(sub, nil)
+
+	err := svc.Delete(context.Background(), sub.ID.String(), uuid.NewString())
+	assert.ErrorIs(t, err, webhook.ErrNotOwner)
+	repo.AssertNotCalled(t, "DeleteWebhook", mock.Anything)
+}
+
+func TestWebhookService_Deliveries_CapsLimit(t *testing.T) {
+	repo := new(mockWebhookRepo)
+	svc := NewWebhookService(repo)
+	sub := &webhook.Subscription{ID: uuid.New(), UserID: uuid.New()}
+
+	repo.On("GetWebhook", sub.ID.String()).Return(sub, nil)
+	repo.On("ListWebhookDeliveries", sub.ID.String(), maxWebhookDeliveryLimit).Return([]*webhook.Delivery{}, nil)
+
+	_, err := svc.Deliveries(context.Background(), sub.ID.String(), sub.UserID.String(), 1000)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
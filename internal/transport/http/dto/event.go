@@ -1,33 +1,111 @@
 package dto
 
-// CreateEventRequest is the request body for creating an event.
+// CreateEventRequest is the request body for creating an event. When TicketTypes is
+// empty, the booking TTL, MaxCountPeople and Price describe a single standard tier.
+// The TTL is given in minutes by BookingTTL or in seconds by BookingTTLSeconds,
+// which takes precedence.
 type CreateEventRequest struct {
-	Name           string  `json:"name" binding:"required"`
-	Description    string  `json:"description" binding:"required"`
-	Date           string  `json:"date" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
-	BookingTTL     int     `json:"booking_ttl" binding:"required,min=1"`
-	MaxCountPeople int     `json:"max_count_people" binding:"required,min=1"`
-	Price          float64 `json:"price" binding:"required,min=0"`
+	Name              string              `json:"name" binding:"required"`
+	Description       string              `json:"description" binding:"required"`
+	Date              string              `json:"date" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	BookingTTL        int                 `json:"booking_ttl" binding:"omitempty,min=1"`
+	BookingTTLSeconds int                 `json:"booking_ttl_seconds" binding:"omitempty,min=1"`
+	MaxCountPeople    int                 `json:"max_count_people" binding:"omitempty,min=1"`
+	Price             float64             `json:"price" binding:"omitempty,min=0"`
+	TicketTypes       []TicketTypeRequest `json:"ticket_types" binding:"omitempty,dive"`
+	BookingLimits
 }
 
-// EventResponse is the response body for an event.
+// BookingLimits restrict how much of an event a single user can hold. Zero means no limit.
+type BookingLimits struct {
+	MaxSeatsPerBooking int `json:"max_seats_per_booking" binding:"min=0"`
+	MaxBookingsPerUser int `json:"max_bookings_per_user" binding:"min=0"`
+	MaxSeatsPerUser    int `json:"max_seats_per_user" binding:"min=0"`
+}
+
+// TicketTypeRequest describes a tier of seats when creating an event. The TTL is
+// given in minutes or, taking precedence, in seconds.
+type TicketTypeRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Price             float64 `json:"price" binding:"min=0"`
+	MaxCountPeople    int     `json:"max_count_people" binding:"required,min=1"`
+	BookingTTL        int     `json:"booking_ttl" binding:"min=0"`
+	BookingTTLSeconds int     `json:"booking_ttl_seconds" binding:"min=0"`
+}
+
+// TicketTypeResponse is the response body for a tier of seats. BookingTTL is the
+// TTL in whole minutes, rounded down; BookingTTLSeconds is exact.
+type TicketTypeResponse struct {
+	ID                string  `json:"id"`
+	Name              string  `json:"name"`
+	Price             float64 `json:"price"`
+	MaxCountPeople    int     `json:"max_count_people"`
+	FreePlaces        int     `json:"free_places"`
+	BookingTTL        int     `json:"booking_ttl"`
+	BookingTTLSeconds int     `json:"booking_ttl_seconds"`
+}
+
+// UpdateEventRequest is the request body for editing an event. Omitted fields are
+// left unchanged. BookingTTLSeconds takes precedence over BookingTTL in minutes.
+type UpdateEventRequest struct {
+	Name              *string  `json:"name"`
+	Description       *string  `json:"description"`
+	Date              *string  `json:"date" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	BookingTTL        *int     `json:"booking_ttl" binding:"omitempty,min=1"`
+	BookingTTLSeconds *int     `json:"booking_ttl_seconds" binding:"omitempty,min=1"`
+	MaxCountPeople    *int     `json:"max_count_people" binding:"omitempty,min=1"`
+	Price             *float64 `json:"price" binding:"omitempty,min=0"`
+
+	MaxSeatsPerBooking *int `json:"max_seats_per_booking" binding:"omitempty,min=0"`
+	MaxBookingsPerUser *int `json:"max_bookings_per_user" binding:"omitempty,min=0"`
+	MaxSeatsPerUser    *int `json:"max_seats_per_user" binding:"omitempty,min=0"`
+}
+
+// EventResponse is the response body for an event. BookingTTL is the TTL in whole
+// minutes, rounded down; BookingTTLSeconds is exact.
 type EventResponse struct {
-	ID               string            `json:"id"`
-	Name             string            `json:"name"`
-	Description      string            `json:"description"`
-	Date             string            `json:"date"`
-	BookingTTL       int               `json:"booking_ttl"`
-	MaxCountPeople   int               `json:"max_count_people"`
-	FreePlaces       int               `json:"free_places,omitempty"`
-	Price            float64           `json:"price"`
+	ID                string               `json:"id"`
+	CreatorID         string               `json:"creator_id,omitempty"`
+	Status            string               `json:"status,omitempty"`
+	Name              string               `json:"name"`
+	Description       string               `json:"description"`
+	Date              string               `json:"date"`
+	BookingTTL        int                  `json:"booking_ttl"`
+	BookingTTLSeconds int                  `json:"booking_ttl_seconds"`
+	MaxCountPeople    int                  `json:"max_count_people"`
+	FreePlaces        int                  `json:"free_places,omitempty"`
+	Price             float64              `json:"price"`
+	TicketTypes       []TicketTypeResponse `json:"ticket_types,omitempty"`
+	BookingLimits
 	BookingResponses []BookingResponse `json:"bookings,omitempty"`
 }
 
+// ListEventsQuery holds the query parameters of the event catalogue.
+type ListEventsQuery struct {
+	DateFrom  string   `form:"date_from"`
+	DateTo    string   `form:"date_to"`
+	PriceMin  *float64 `form:"price_min"`
+	PriceMax  *float64 `form:"price_max"`
+	Available bool     `form:"available"`
+	CreatorID string   `form:"creator_id"`
+	Sort      string   `form:"sort"`
+	Cursor    string   `form:"cursor"`
+	Limit     int      `form:"limit" binding:"omitempty,min=1"`
+}
+
+// EventListResponse is the response body for a page of the event catalogue.
+type EventListResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
 // CreateBookingRequest is the request body for creating a booking.
+// Omitted notification channels take the defaults from the profile of the user.
 type CreateBookingRequest struct {
 	EventID              string `json:"event_id" binding:"required"`
-	TelegramNotification bool   `json:"telegram_notification"`
-	EmailNotification    bool   `json:"email_notification"`
+	TicketTypeID         string `json:"ticket_type_id"`
+	TelegramNotification *bool  `json:"telegram_notification"`
+	EmailNotification    *bool  `json:"email_notification"`
 	Count                int    `json:"count" binding:"required,min=1"`
 }
 
@@ -35,11 +113,43 @@ type CreateBookingRequest struct {
 type BookingResponse struct {
 	ID                   string  `json:"id"`
 	EventID              string  `json:"event_id"`
+	EventName            string  `json:"event_name,omitempty"`
+	EventDate            string  `json:"event_date,omitempty"`
+	TicketTypeID         string  `json:"ticket_type_id"`
+	TicketTypeName       string  `json:"ticket_type_name,omitempty"`
 	UserID               string  `json:"user_id"`
 	Status               string  `json:"status"`
 	TelegramNotification bool    `json:"telegram_notification"`
 	EmailNotification    bool    `json:"email_notification"`
 	Count                int     `json:"count"`
+	CreatedAt            string  `json:"created_at,omitempty"`
 	ExpiredAt            string  `json:"expired_at"`
 	Price                float64 `json:"price"`
 }
+
+// BookingListResponse is the response body for a list of bookings.
+type BookingListResponse struct {
+	Bookings []BookingResponse `json:"bookings"`
+}
+
+// JoinWaitlistRequest is the request body for joining an event waitlist.
+// Omitted notification channels take the defaults from the profile of the user.
+type JoinWaitlistRequest struct {
+	TicketTypeID         string `json:"ticket_type_id"`
+	TelegramNotification *bool  `json:"telegram_notification"`
+	EmailNotification    *bool  `json:"email_notification"`
+	Count                int    `json:"count" binding:"required,min=1"`
+}
+
+// WaitlistEntryResponse is the response body for a waitlist entry.
+type WaitlistEntryResponse struct {
+	ID                   string `json:"id"`
+	EventID              string `json:"event_id"`
+	TicketTypeID         string `json:"ticket_type_id"`
+	Status               string `json:"status"`
+	Position             int    `json:"position"`
+	Count                int    `json:"count"`
+	TelegramNotification bool   `json:"telegram_notification"`
+	EmailNotification    bool   `json:"email_notification"`
+	CreatedAt            string `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+// ListDeliveriesQuery holds the query parameters of the delivery log.
+type ListDeliveriesQuery struct {
+	Status    []string `form:"status"`
+	BookingID string   `form:"booking_id"`
+	Limit     int      `form:"limit" binding:"omitempty,min=1"`
+}
+
+// DeliveryResponse is the response body for a logged notification delivery.
+type DeliveryResponse struct {
+	ID            string `json:"id"`
+	BookingID     string `json:"booking_id"`
+	Kind          string `json:"kind"`
+	Channel       string `json:"channel"`
+	Recipient     string `json:"recipient"`
+	Subject       string `json:"subject,omitempty"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// DeliveryListResponse is the response body for a list of deliveries.
+type DeliveryListResponse struct {
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
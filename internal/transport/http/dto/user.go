@@ -12,6 +12,7 @@ type UserRegistrationRequest struct {
 	Password string `json:"password" binding:"required"`
 	Email    string `json:"email"`
 	Telegram string `json:"telegram"`
+	Locale   string `json:"locale"`
 }
 
 // TokenRefreshRequest is the request body for refreshing tokens.
@@ -19,12 +20,61 @@ type TokenRefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// LogoutRequest is the request body for ending a session.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ForgotPasswordRequest is the request body for asking a password reset email.
+type ForgotPasswordRequest struct {
+	Login string `json:"login" binding:"required"`
+}
+
+// ResetPasswordRequest is the request body for setting a new password with a reset token.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 // UserResponse is the response body for a user.
 type UserResponse struct {
 	ID       string `json:"id"`
 	Login    string `json:"login"`
 	Email    string `json:"email"`
 	Telegram string `json:"telegram"`
+	Locale   string `json:"locale"`
+	Role     string `json:"role"`
+	// EmailVerified is false until the link from the verification email is opened.
+	EmailVerified bool `json:"email_verified"`
+	// Default notification channels of bookings that do not choose them.
+	EmailNotification    bool `json:"email_notification"`
+	TelegramNotification bool `json:"telegram_notification"`
+}
+
+// UpdateProfileRequest is the request body for editing the profile of the current
+// user. Omitted fields are left unchanged and an empty telegram unlinks the chat.
+type UpdateProfileRequest struct {
+	Email                *string `json:"email"`
+	Telegram             *string `json:"telegram"`
+	Locale               *string `json:"locale"`
+	EmailNotification    *bool   `json:"email_notification"`
+	TelegramNotification *bool   `json:"telegram_notification"`
+}
+
+// ChangePasswordRequest is the request body for changing the password of the current user.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest is the request body for deleting the account of the current user.
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SetRoleRequest is the request body for changing the role of a user.
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required" enums:"attendee,organizer,admin"`
 }
 
 // JWTResponse is the response body containing JWT tokens.
@@ -32,3 +82,10 @@ type JWTResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+// TelegramLinkResponse is the response body with a Telegram account linking link.
+type TelegramLinkResponse struct {
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+// CreateWebhookRequest is the request body for subscribing to webhooks.
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Secret signs the requests; a random one is generated when it is empty.
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types" binding:"required"`
+}
+
+// WebhookResponse is the response body for a webhook subscription. The secret is
+// only returned when the subscription is created.
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// WebhookListResponse is the response body for a list of webhook subscriptions.
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// WebhookDeliveriesQuery holds the query parameters of the delivery history.
+type WebhookDeliveriesQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+}
+
+// WebhookDeliveryResponse is the response body for one webhook delivery.
+type WebhookDeliveryResponse struct {
+	ID             string `json:"id"`
+	MessageID      string `json:"message_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryListResponse is the response body for the delivery history of a webhook.
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
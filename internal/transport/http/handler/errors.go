@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"eventbooker/internal/apperr"
+
+	wbgin "github.com/wb-go/wbf/ginext"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+var errNoUserInContext = apperr.Unauthorized("unauthorized", "user not found in context")
+
+// statusFor maps an application error kind to its HTTP status code.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, apperr.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, apperr.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, apperr.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, apperr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperr.ErrConflict), errors.Is(err, apperr.ErrSoldOut):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err as a JSON body with a stable code. Unknown errors
+// are logged and hidden behind a generic 500 so internals never leak.
+func respondError(ctx *wbgin.Context, err error) {
+	status := statusFor(err)
+	if status == http.StatusInternalServerError {
+		wbzlog.Logger.Error().Err(err).Msgf("%s %s failed", ctx.Request.Method, ctx.Request.URL.Path)
+		ctx.JSON(status, wbgin.H{"error": "internal server error", "code": apperr.CodeInternal})
+		return
+	}
+
+	ctx.JSON(status, wbgin.H{"error": err.Error(), "code": apperr.Code(err)})
+}
+
+// respondBadRequest reports a malformed request body or query string.
+func respondBadRequest(ctx *wbgin.Context, message string) {
+	respondError(ctx, apperr.Validation("invalid_request", message))
+}
@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/transport/http/dto"
+	"eventbooker/internal/transport/http/handler"
+
+	"github.com/google/uuid"
+)
+
+func TestEventHandler_CreateBooking_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"sold out", event.ErrSoldOut, http.StatusConflict, "sold_out"},
+		{"event cancelled", event.ErrCancelled, http.StatusConflict, "event_cancelled"},
+		{"event not found", event.ErrNotFound, http.StatusNotFound, "event_not_found"},
+		{"invalid count", booking.ErrInvalidCount, http.StatusBadRequest, "invalid_count"},
+		{"forbidden", booking.ErrNotOwner, http.StatusForbidden, "booking_forbidden"},
+		{"internal", errors.New("connection refused"), http.StatusInternalServerError, "internal"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &mockBookingService{
+				CreateFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error) {
+					return nil, tc.err
+				},
+			}
+			h := handler.NewEventHandler(nil, mock)
+			req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1}
+			w := performRequest(h.CreateBooking, "POST", "/book", req, "user123")
+			if w.Code != tc.status {
+				t.Fatalf("expected %d, got %d", tc.status, w.Code)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body: %s", w.Body.String())
+			}
+			if body["code"] != tc.code {
+				t.Fatalf("expected code %q, got %q", tc.code, body["code"])
+			}
+			if tc.status == http.StatusInternalServerError && body["error"] == tc.err.Error() {
+				t.Fatal("internal error message leaked to the client")
+			}
+		})
+	}
+}
+
+func TestEventHandler_CreateBooking_InvalidJSONCode(t *testing.T) {
+	h := handler.NewEventHandler(nil, &mockBookingService{})
+	w := performRequest(h.CreateBooking, "POST", "/book", "bad", "user123")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	var body map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	if body["code"] != "invalid_request" {
+		t.Fatalf("expected invalid_request code, got %q", body["code"])
+	}
+}
@@ -3,25 +3,36 @@ package handler
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/waitlist"
 	"eventbooker/internal/transport/http/dto"
 
+	"github.com/google/uuid"
 	wbgin "github.com/wb-go/wbf/ginext"
 )
 
 // EventServicer defines the event service interface used by EventHandler.
 type EventServicer interface {
-	Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error)
+	Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error)
 	Get(ctx context.Context, eventID string) (*event.Event, error)
+	List(ctx context.Context, f event.Filter) (*event.Page, error)
+	Update(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error)
+	Cancel(ctx context.Context, eventID, userID string) (*event.Event, error)
 }
 
 // BookingServicer defines the booking service interface used by EventHandler.
 type BookingServicer interface {
-	Create(ctx context.Context, eventID, userID string, telegramNotification, emailNotification bool, count int) (*booking.Booking, error)
-	Confirm(ctx context.Context, id string) error
+	Create(ctx context.Context, eventID, userID, ticketTypeID string, telegramNotification, emailNotification *bool, count int) (*booking.Booking, error)
+	Confirm(ctx context.Context, id, userID string) error
+	Get(ctx context.Context, id, userID string) (*booking.Booking, error)
+	List(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error)
+	Cancel(ctx context.Context, id, userID string) (*booking.Booking, error)
+	JoinWaitlist(ctx context.Context, eventID, userID, ticketTypeID string, telegramNotification, emailNotification *bool, count int) (*waitlist.Entry, error)
+	LeaveWaitlist(ctx context.Context, eventID, userID string) error
 }
 
 // EventHandler handles HTTP requests for events and bookings.
@@ -40,7 +51,8 @@ func NewEventHandler(events EventServicer, bookings BookingServicer) *EventHandl
 
 // CreateEvent godoc
 // @Summary      Create a new event
-// @Description  Create a new event for the authenticated user
+// @Description  Create a new event for the authenticated user. Pass ticket_types to sell several tiers with their own price, quota and TTL; otherwise the booking TTL, max_count_people and price define a single standard tier. The TTL is given in minutes by booking_ttl or in seconds by booking_ttl_seconds, which takes precedence.
+// @Description  max_seats_per_booking, max_bookings_per_user and max_seats_per_user limit what one user can hold; 0 means no limit
 // @Tags         events
 // @Accept       json
 // @Produce      json
@@ -54,36 +66,55 @@ func NewEventHandler(events EventServicer, bookings BookingServicer) *EventHandl
 func (h *EventHandler) CreateEvent(ctx *wbgin.Context) {
 	var req dto.CreateEventRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondBadRequest(ctx, err.Error())
 		return
 	}
 
 	userID, exists := ctx.Get("userId")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, wbgin.H{"error": "user not found in context"})
+		respondError(ctx, errNoUserInContext)
 		return
 	}
 
 	eventDate, err := time.Parse(time.RFC3339, req.Date)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "invalid date format"})
+		respondBadRequest(ctx, "invalid date format")
 		return
 	}
 
-	ev, err := h.events.Create(ctx.Request.Context(), userID.(string), req.Name, req.Description, eventDate, req.BookingTTL, req.MaxCountPeople, req.Price)
+	ticketTypes := make([]event.TicketType, 0, len(req.TicketTypes))
+	for _, t := range req.TicketTypes {
+		ticketTypes = append(ticketTypes, event.TicketType{
+			Name:           t.Name,
+			Price:          t.Price,
+			MaxCountPeople: t.MaxCountPeople,
+			BookingTTL:     bookingTTL(t.BookingTTL, t.BookingTTLSeconds),
+		})
+	}
+
+	limits := event.Limits{
+		MaxSeatsPerBooking: req.MaxSeatsPerBooking,
+		MaxBookingsPerUser: req.MaxBookingsPerUser,
+		MaxSeatsPerUser:    req.MaxSeatsPerUser,
+	}
+
+	ev, err := h.events.Create(ctx.Request.Context(), userID.(string), req.Name, req.Description, eventDate, bookingTTL(req.BookingTTL, req.BookingTTLSeconds), req.MaxCountPeople, req.Price, ticketTypes, limits)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, dto.EventResponse{
-		ID:             ev.ID.String(),
-		Name:           ev.Name,
-		Description:    ev.Description,
-		Date:           ev.Date.Format(time.RFC3339),
-		BookingTTL:     ev.BookingTTL,
-		MaxCountPeople: ev.MaxCountPeople,
-		Price:          ev.Price,
+		ID:                ev.ID.String(),
+		Name:              ev.Name,
+		Description:       ev.Description,
+		Date:              ev.Date.Format(time.RFC3339),
+		BookingTTL:        int(ev.BookingTTL / time.Minute),
+		BookingTTLSeconds: int(ev.BookingTTL / time.Second),
+		MaxCountPeople:    ev.MaxCountPeople,
+		Price:             ev.Price,
+		TicketTypes:       newTicketTypeResponses(ev.TicketTypes),
+		BookingLimits:     newBookingLimits(ev.Limits),
 	})
 }
 
@@ -95,6 +126,8 @@ func (h *EventHandler) CreateEvent(ctx *wbgin.Context) {
 // @Produce      json
 // @Param        id    path      string  true  "Event ID"
 // @Success      200   {object}  dto.EventResponse
+// @Failure      400   {object}  map[string]string  "Invalid event id"
+// @Failure      404   {object}  map[string]string  "Event not found"
 // @Failure      500   {object}  map[string]string  "Internal server error"
 // @Security     ApiKeyAuth
 // @Router       /events/{id} [get]
@@ -103,101 +136,555 @@ func (h *EventHandler) GetEvent(ctx *wbgin.Context) {
 
 	ev, err := h.events.Get(ctx.Request.Context(), eventID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
 	var bookingResponses []dto.BookingResponse
 	for _, b := range ev.Bookings {
-		bookingResponses = append(bookingResponses, dto.BookingResponse{
-			ID:                   b.ID.String(),
-			EventID:              b.EventID.String(),
-			UserID:               b.UserID.String(),
-			Status:               string(b.Status),
-			TelegramNotification: b.TelegramNotification,
-			EmailNotification:    b.EmailNotification,
-			Count:                b.Count,
-			ExpiredAt:            b.ExpiredAt.Format(time.RFC3339),
-			Price:                b.Price,
-		})
+		bookingResponses = append(bookingResponses, newBookingResponse(b))
 	}
 
 	ctx.JSON(http.StatusOK, dto.EventResponse{
-		ID:               ev.ID.String(),
-		Name:             ev.Name,
-		Description:      ev.Description,
-		Date:             ev.Date.Format(time.RFC3339),
-		BookingTTL:       ev.BookingTTL,
-		MaxCountPeople:   ev.MaxCountPeople,
-		FreePlaces:       ev.FreePlaces,
-		Price:            ev.Price,
-		BookingResponses: bookingResponses,
+		ID:                ev.ID.String(),
+		CreatorID:         creatorID(ev),
+		Status:            string(ev.Status),
+		Name:              ev.Name,
+		Description:       ev.Description,
+		Date:              ev.Date.Format(time.RFC3339),
+		BookingTTL:        int(ev.BookingTTL / time.Minute),
+		BookingTTLSeconds: int(ev.BookingTTL / time.Second),
+		MaxCountPeople:    ev.MaxCountPeople,
+		FreePlaces:        ev.FreePlaces,
+		Price:             ev.Price,
+		TicketTypes:       newTicketTypeResponses(ev.TicketTypes),
+		BookingLimits:     newBookingLimits(ev.Limits),
+		BookingResponses:  bookingResponses,
 	})
 }
 
+// UpdateEvent godoc
+// @Summary      Update an event
+// @Description  Edit an event of the authenticated organizer; capacity cannot drop below booked seats
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                  true  "Event ID"
+// @Param        body  body      dto.UpdateEventRequest  true  "Changed fields"
+// @Success      200   {object}  dto.EventResponse
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      403   {object}  map[string]string  "Not the event creator"
+// @Failure      404   {object}  map[string]string  "Event not found"
+// @Failure      409   {object}  map[string]string  "Event cancelled or capacity below booked seats"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /events/{id} [patch]
+func (h *EventHandler) UpdateEvent(ctx *wbgin.Context) {
+	eventID, _ := ctx.Params.Get("id")
+
+	var req dto.UpdateEventRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	u := event.Update{
+		Name:           req.Name,
+		Description:    req.Description,
+		BookingTTL:     bookingTTLUpdate(req.BookingTTL, req.BookingTTLSeconds),
+		MaxCountPeople: req.MaxCountPeople,
+		Price:          req.Price,
+
+		MaxSeatsPerBooking: req.MaxSeatsPerBooking,
+		MaxBookingsPerUser: req.MaxBookingsPerUser,
+		MaxSeatsPerUser:    req.MaxSeatsPerUser,
+	}
+
+	if req.Date != nil {
+		eventDate, err := time.Parse(time.RFC3339, *req.Date)
+		if err != nil {
+			respondBadRequest(ctx, "invalid date format")
+			return
+		}
+		u.Date = &eventDate
+	}
+
+	ev, err := h.events.Update(ctx.Request.Context(), eventID, userID.(string), u)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEventResponse(ev))
+}
+
+// CancelEvent godoc
+// @Summary      Cancel an event
+// @Description  Cancel an event of the authenticated organizer, cancel its active bookings and notify attendees
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {object}  dto.EventResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      400  {object}  map[string]string  "Invalid event id"
+// @Failure      403  {object}  map[string]string  "Not the event creator"
+// @Failure      404  {object}  map[string]string  "Event not found"
+// @Failure      409  {object}  map[string]string  "Event already cancelled"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /events/{id} [delete]
+func (h *EventHandler) CancelEvent(ctx *wbgin.Context) {
+	eventID, _ := ctx.Params.Get("id")
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	ev, err := h.events.Cancel(ctx.Request.Context(), eventID, userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEventResponse(ev))
+}
+
+// ListEvents godoc
+// @Summary      List events
+// @Description  Browse the event catalogue with cursor pagination, filters and sorting
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        date_from   query     string   false  "Earliest event date (RFC3339)"
+// @Param        date_to     query     string   false  "Latest event date (RFC3339)"
+// @Param        price_min   query     number   false  "Minimum price"
+// @Param        price_max   query     number   false  "Maximum price"
+// @Param        available   query     boolean  false  "Only events with free places"
+// @Param        creator_id  query     string   false  "Creator ID"
+// @Param        sort        query     string   false  "Sort order"  Enums(date_asc, date_desc, price_asc, price_desc)
+// @Param        cursor      query     string   false  "Cursor from the previous page"
+// @Param        limit       query     int      false  "Page size"
+// @Success      200         {object}  dto.EventListResponse
+// @Failure      400         {object}  map[string]string  "Invalid request"
+// @Failure      500         {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /events [get]
+func (h *EventHandler) ListEvents(ctx *wbgin.Context) {
+	var q dto.ListEventsQuery
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	filter := event.Filter{
+		PriceMin:      q.PriceMin,
+		PriceMax:      q.PriceMax,
+		OnlyAvailable: q.Available,
+		CreatorID:     q.CreatorID,
+		Sort:          event.Sort(q.Sort),
+		Cursor:        q.Cursor,
+		Limit:         q.Limit,
+	}
+
+	if q.DateFrom != "" {
+		dateFrom, err := time.Parse(time.RFC3339, q.DateFrom)
+		if err != nil {
+			respondBadRequest(ctx, "invalid date_from format")
+			return
+		}
+		filter.DateFrom = dateFrom
+	}
+
+	if q.DateTo != "" {
+		dateTo, err := time.Parse(time.RFC3339, q.DateTo)
+		if err != nil {
+			respondBadRequest(ctx, "invalid date_to format")
+			return
+		}
+		filter.DateTo = dateTo
+	}
+
+	page, err := h.events.List(ctx.Request.Context(), filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := dto.EventListResponse{
+		Events:     make([]dto.EventResponse, 0, len(page.Events)),
+		NextCursor: page.NextCursor,
+	}
+	for _, ev := range page.Events {
+		resp.Events = append(resp.Events, newEventResponse(ev))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
 // CreateBooking godoc
 // @Summary      Create a booking for an event
-// @Description  Book a number of seats for the authenticated user
+// @Description  Book a number of seats of a ticket type for the authenticated user. ticket_type_id may be omitted for single-tier events.
+// @Description  Retries with the same Idempotency-Key get the original response with the Idempotent-Replayed header
 // @Tags         bookings
 // @Accept       json
 // @Produce      json
-// @Param        body  body      dto.CreateBookingRequest  true  "Booking info"
+// @Param        body             body      dto.CreateBookingRequest  true   "Booking info"
+// @Param        Idempotency-Key  header    string                    false  "Client-chosen key making the request safe to retry"
 // @Success      200   {object}  dto.BookingResponse
 // @Failure      400   {object}  map[string]string  "Invalid request"
 // @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      404   {object}  map[string]string  "Event not found"
+// @Failure      409   {object}  map[string]string  "Event cancelled or sold out, booking limits of the user reached, or a request with the same key is in progress"
+// @Failure      422   {object}  map[string]string  "Idempotency key reused for a different request"
 // @Failure      500   {object}  map[string]string  "Internal server error"
 // @Security     ApiKeyAuth
 // @Router       /bookings [post]
 func (h *EventHandler) CreateBooking(ctx *wbgin.Context) {
 	var req dto.CreateBookingRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondBadRequest(ctx, err.Error())
 		return
 	}
 
 	userID, exists := ctx.Get("userId")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, wbgin.H{"error": "user not found in context"})
+		respondError(ctx, errNoUserInContext)
 		return
 	}
 
-	b, err := h.bookings.Create(ctx.Request.Context(), req.EventID, userID.(string), req.TelegramNotification, req.EmailNotification, req.Count)
+	b, err := h.bookings.Create(ctx.Request.Context(), req.EventID, userID.(string), req.TicketTypeID, req.TelegramNotification, req.EmailNotification, req.Count)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.BookingResponse{
-		ID:                   b.ID.String(),
-		EventID:              b.EventID.String(),
-		UserID:               b.UserID.String(),
-		Status:               string(b.Status),
-		TelegramNotification: b.TelegramNotification,
-		EmailNotification:    b.EmailNotification,
-		Count:                b.Count,
-		ExpiredAt:            b.ExpiredAt.Format(time.RFC3339),
-		Price:                b.Price,
-	})
+	ctx.JSON(http.StatusOK, newBookingResponse(b))
 }
 
 // ConfirmBooking godoc
 // @Summary      Confirm a booking
-// @Description  Confirm a previously created booking by booking ID
+// @Description  Confirm a pending, unexpired booking of the authenticated user by booking ID
 // @Tags         bookings
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string  true  "Booking ID"
 // @Success      200   {object}  map[string]string  "Booking confirmed"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      400   {object}  map[string]string  "Invalid booking id"
+// @Failure      403   {object}  map[string]string  "Booking belongs to another user"
+// @Failure      404   {object}  map[string]string  "Booking not found"
+// @Failure      409   {object}  map[string]string  "Booking already confirmed, cancelled or expired"
 // @Failure      500   {object}  map[string]string  "Internal server error"
 // @Security     ApiKeyAuth
 // @Router       /bookings/{id}/confirm [post]
 func (h *EventHandler) ConfirmBooking(ctx *wbgin.Context) {
 	bookingID, _ := ctx.Params.Get("id")
 
-	if err := h.bookings.Confirm(ctx.Request.Context(), bookingID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	if err := h.bookings.Confirm(ctx.Request.Context(), bookingID, userID.(string)); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, wbgin.H{"message": "booking confirmed"})
 }
+
+// ListBookings godoc
+// @Summary      List my bookings
+// @Description  List bookings of the authenticated user, newest first
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Param        status  query     []string  false  "Booking status filter"  Enums(created, confirmed, canceled)  collectionFormat(multi)
+// @Success      200     {object}  dto.BookingListResponse
+// @Failure      401     {object}  map[string]string  "Unauthorized"
+// @Failure      400     {object}  map[string]string  "Invalid status"
+// @Failure      500     {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /bookings [get]
+func (h *EventHandler) ListBookings(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	var statuses []booking.Status
+	for _, raw := range ctx.QueryArray("status") {
+		for _, st := range strings.Split(raw, ",") {
+			if st = strings.TrimSpace(st); st != "" {
+				statuses = append(statuses, booking.Status(st))
+			}
+		}
+	}
+
+	bookings, err := h.bookings.List(ctx.Request.Context(), userID.(string), statuses)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := dto.BookingListResponse{Bookings: make([]dto.BookingResponse, 0, len(bookings))}
+	for _, b := range bookings {
+		resp.Bookings = append(resp.Bookings, newBookingResponse(b))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GetBooking godoc
+// @Summary      Get booking by ID
+// @Description  Retrieve a booking of the authenticated user by booking ID
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  dto.BookingResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      400  {object}  map[string]string  "Invalid booking id"
+// @Failure      404  {object}  map[string]string  "Booking not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /bookings/{id} [get]
+func (h *EventHandler) GetBooking(ctx *wbgin.Context) {
+	bookingID, _ := ctx.Params.Get("id")
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	b, err := h.bookings.Get(ctx.Request.Context(), bookingID, userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBookingResponse(b))
+}
+
+// CancelBooking godoc
+// @Summary      Cancel a booking
+// @Description  Cancel a created or confirmed booking of the authenticated user and release its seats
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  dto.BookingResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      400  {object}  map[string]string  "Invalid booking id"
+// @Failure      404  {object}  map[string]string  "Booking not found"
+// @Failure      409  {object}  map[string]string  "Booking already cancelled or cancellation closed"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /bookings/{id}/cancel [post]
+func (h *EventHandler) CancelBooking(ctx *wbgin.Context) {
+	bookingID, _ := ctx.Params.Get("id")
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	b, err := h.bookings.Cancel(ctx.Request.Context(), bookingID, userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBookingResponse(b))
+}
+
+func newEventResponse(ev *event.Event) dto.EventResponse {
+	return dto.EventResponse{
+		ID:                ev.ID.String(),
+		CreatorID:         creatorID(ev),
+		Status:            string(ev.Status),
+		Name:              ev.Name,
+		Description:       ev.Description,
+		Date:              ev.Date.Format(time.RFC3339),
+		BookingTTL:        int(ev.BookingTTL / time.Minute),
+		BookingTTLSeconds: int(ev.BookingTTL / time.Second),
+		MaxCountPeople:    ev.MaxCountPeople,
+		FreePlaces:        ev.FreePlaces,
+		Price:             ev.Price,
+		TicketTypes:       newTicketTypeResponses(ev.TicketTypes),
+		BookingLimits:     newBookingLimits(ev.Limits),
+	}
+}
+
+// creatorID returns the ID of the creator of ev, or "" when the creator deleted
+// their account.
+func creatorID(ev *event.Event) string {
+	if ev.CreatorID == uuid.Nil {
+		return ""
+	}
+	return ev.CreatorID.String()
+}
+
+// bookingTTL returns the booking TTL given in seconds or, without them, in minutes.
+func bookingTTL(minutes, seconds int) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// bookingTTLUpdate is bookingTTL for an edit, where nil leaves the TTL unchanged.
+func bookingTTLUpdate(minutes, seconds *int) *time.Duration {
+	var ttl time.Duration
+	switch {
+	case seconds != nil:
+		ttl = time.Duration(*seconds) * time.Second
+	case minutes != nil:
+		ttl = time.Duration(*minutes) * time.Minute
+	default:
+		return nil
+	}
+	return &ttl
+}
+
+func newBookingLimits(l event.Limits) dto.BookingLimits {
+	return dto.BookingLimits{
+		MaxSeatsPerBooking: l.MaxSeatsPerBooking,
+		MaxBookingsPerUser: l.MaxBookingsPerUser,
+		MaxSeatsPerUser:    l.MaxSeatsPerUser,
+	}
+}
+
+func newTicketTypeResponses(types []*event.TicketType) []dto.TicketTypeResponse {
+	resp := make([]dto.TicketTypeResponse, 0, len(types))
+	for _, t := range types {
+		resp = append(resp, dto.TicketTypeResponse{
+			ID:                t.ID.String(),
+			Name:              t.Name,
+			Price:             t.Price,
+			MaxCountPeople:    t.MaxCountPeople,
+			FreePlaces:        t.FreePlaces,
+			BookingTTL:        int(t.BookingTTL / time.Minute),
+			BookingTTLSeconds: int(t.BookingTTL / time.Second),
+		})
+	}
+	return resp
+}
+
+// JoinWaitlist godoc
+// @Summary      Join an event waitlist
+// @Description  Queue the authenticated user for seats of a sold-out event. When seats are released, a hold booking is created and the user is notified
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                   true  "Event ID"
+// @Param        body  body      dto.JoinWaitlistRequest  true  "Waitlist info"
+// @Success      200   {object}  dto.WaitlistEntryResponse
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      404   {object}  map[string]string  "Event not found"
+// @Failure      409   {object}  map[string]string  "Event cancelled, seats available or already waitlisted"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /events/{id}/waitlist [post]
+func (h *EventHandler) JoinWaitlist(ctx *wbgin.Context) {
+	eventID, _ := ctx.Params.Get("id")
+
+	var req dto.JoinWaitlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	e, err := h.bookings.JoinWaitlist(ctx.Request.Context(), eventID, userID.(string), req.TicketTypeID, req.TelegramNotification, req.EmailNotification, req.Count)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.WaitlistEntryResponse{
+		ID:                   e.ID.String(),
+		EventID:              e.EventID.String(),
+		TicketTypeID:         e.TicketTypeID.String(),
+		Status:               string(e.Status),
+		Position:             e.Position,
+		Count:                e.Count,
+		TelegramNotification: e.TelegramNotification,
+		EmailNotification:    e.EmailNotification,
+		CreatedAt:            e.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// LeaveWaitlist godoc
+// @Summary      Leave an event waitlist
+// @Description  Remove the authenticated user from the waitlist of an event
+// @Tags         waitlist
+// @Produce      json
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {object}  map[string]string  "Left waitlist"
+// @Failure      400  {object}  map[string]string  "Invalid event id"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      404  {object}  map[string]string  "Not on the waitlist"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /events/{id}/waitlist [delete]
+func (h *EventHandler) LeaveWaitlist(ctx *wbgin.Context) {
+	eventID, _ := ctx.Params.Get("id")
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	if err := h.bookings.LeaveWaitlist(ctx.Request.Context(), eventID, userID.(string)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "left waitlist"})
+}
+
+func newBookingResponse(b *booking.Booking) dto.BookingResponse {
+	resp := dto.BookingResponse{
+		ID:                   b.ID.String(),
+		EventID:              b.EventID.String(),
+		EventName:            b.EventName,
+		TicketTypeID:         b.TicketTypeID.String(),
+		TicketTypeName:       b.TicketTypeName,
+		UserID:               b.UserID.String(),
+		Status:               string(b.Status),
+		TelegramNotification: b.TelegramNotification,
+		EmailNotification:    b.EmailNotification,
+		Count:                b.Count,
+		ExpiredAt:            b.ExpiredAt.Format(time.RFC3339),
+		Price:                b.Price,
+	}
+	if !b.EventDate.IsZero() {
+		resp.EventDate = b.EventDate.Format(time.RFC3339)
+	}
+	if !b.CreatedAt.IsZero() {
+		resp.CreatedAt = b.CreatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"eventbooker/internal/domain/booking"
 	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/waitlist"
 	"eventbooker/internal/transport/http/dto"
 	"eventbooker/internal/transport/http/handler"
 
@@ -20,27 +22,64 @@ import (
 )
 
 type mockEventService struct {
-	CreateFn func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error)
+	CreateFn func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error)
 	GetFn    func(ctx context.Context, eventID string) (*event.Event, error)
+	ListFn   func(ctx context.Context, f event.Filter) (*event.Page, error)
+	UpdateFn func(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error)
+	CancelFn func(ctx context.Context, eventID, userID string) (*event.Event, error)
 }
 
-func (m *mockEventService) Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error) {
-	return m.CreateFn(ctx, userID, name, description, date, bookingTTL, maxCountPeople, price)
+func (m *mockEventService) Create(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
+	return m.CreateFn(ctx, userID, name, description, date, bookingTTL, maxCountPeople, price, ticketTypes, limits)
 }
 func (m *mockEventService) Get(ctx context.Context, eventID string) (*event.Event, error) {
 	return m.GetFn(ctx, eventID)
 }
 
+func (m *mockEventService) List(ctx context.Context, f event.Filter) (*event.Page, error) {
+	return m.ListFn(ctx, f)
+}
+
+func (m *mockEventService) Update(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+	return m.UpdateFn(ctx, eventID, userID, u)
+}
+func (m *mockEventService) Cancel(ctx context.Context, eventID, userID string) (*event.Event, error) {
+	return m.CancelFn(ctx, eventID, userID)
+}
+
 type mockBookingService struct {
-	CreateFn  func(ctx context.Context, eventID, userID string, tg, email bool, count int) (*booking.Booking, error)
-	ConfirmFn func(ctx context.Context, id string) error
+	CreateFn  func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error)
+	ConfirmFn func(ctx context.Context, id, userID string) error
+	GetFn     func(ctx context.Context, id, userID string) (*booking.Booking, error)
+	ListFn    func(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error)
+	CancelFn  func(ctx context.Context, id, userID string) (*booking.Booking, error)
+	JoinFn    func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*waitlist.Entry, error)
+	LeaveFn   func(ctx context.Context, eventID, userID string) error
+}
+
+func (m *mockBookingService) Create(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error) {
+	return m.CreateFn(ctx, eventID, userID, ticketTypeID, tg, email, count)
+}
+func (m *mockBookingService) Confirm(ctx context.Context, id, userID string) error {
+	return m.ConfirmFn(ctx, id, userID)
 }
 
-func (m *mockBookingService) Create(ctx context.Context, eventID, userID string, tg, email bool, count int) (*booking.Booking, error) {
-	return m.CreateFn(ctx, eventID, userID, tg, email, count)
+func (m *mockBookingService) Get(ctx context.Context, id, userID string) (*booking.Booking, error) {
+	return m.GetFn(ctx, id, userID)
+}
+func (m *mockBookingService) List(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+	return m.ListFn(ctx, userID, statuses)
 }
-func (m *mockBookingService) Confirm(ctx context.Context, id string) error {
-	return m.ConfirmFn(ctx, id)
+
+func (m *mockBookingService) Cancel(ctx context.Context, id, userID string) (*booking.Booking, error) {
+	return m.CancelFn(ctx, id, userID)
+}
+
+func (m *mockBookingService) JoinWaitlist(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*waitlist.Entry, error) {
+	return m.JoinFn(ctx, eventID, userID, ticketTypeID, tg, email, count)
+}
+func (m *mockBookingService) LeaveWaitlist(ctx context.Context, eventID, userID string) error {
+	return m.LeaveFn(ctx, eventID, userID)
 }
 
 func performRequest(hf func(*gin.Context), method, path string, body any, userID string) *httptest.ResponseRecorder {
@@ -62,7 +101,7 @@ func performRequest(hf func(*gin.Context), method, path string, body any, userID
 
 func TestEventHandler_CreateEvent_Success(t *testing.T) {
 	mock := &mockEventService{
-		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error) {
+		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
 			return &event.Event{
 				ID: uuid.New(), Name: name, Description: description, Date: date,
 				MaxCountPeople: maxCountPeople, BookingTTL: bookingTTL, Price: price,
@@ -81,6 +120,110 @@ func TestEventHandler_CreateEvent_Success(t *testing.T) {
 	}
 }
 
+func TestEventHandler_CreateEvent_Limits(t *testing.T) {
+	mock := &mockEventService{
+		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
+			return &event.Event{ID: uuid.New(), Name: name, Date: date, MaxCountPeople: maxCountPeople, Limits: limits}, nil
+		},
+	}
+	h := handler.NewEventHandler(mock, nil)
+	req := dto.CreateEventRequest{
+		Name: "Test Event", Description: "desc",
+		Date:           time.Now().Add(time.Hour).Format(time.RFC3339),
+		MaxCountPeople: 10,
+		BookingLimits:  dto.BookingLimits{MaxSeatsPerBooking: 2, MaxBookingsPerUser: 1, MaxSeatsPerUser: 2},
+	}
+	w := performRequest(h.CreateEvent, "POST", "/events", req, "user-123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp dto.EventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.BookingLimits != req.BookingLimits {
+		t.Errorf("expected the limits to be passed and returned, got %+v", resp.BookingLimits)
+	}
+
+	req.MaxSeatsPerUser = -1
+	if w = performRequest(h.CreateEvent, "POST", "/events", req, "user-123"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative limit, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CreateEvent_TicketTypes(t *testing.T) {
+	var got []event.TicketType
+	mock := &mockEventService{
+		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
+			got = ticketTypes
+			ev, err := event.NewWithTicketTypes(uuid.New().String(), name, description, date, ticketTypes)
+			return ev, err
+		},
+	}
+	h := handler.NewEventHandler(mock, nil)
+	req := dto.CreateEventRequest{
+		Name: "Test Event", Description: "desc",
+		Date: time.Now().Add(time.Hour).Format(time.RFC3339),
+		TicketTypes: []dto.TicketTypeRequest{
+			{Name: "standard", Price: 10, MaxCountPeople: 50, BookingTTL: 15},
+			{Name: "vip", Price: 50, MaxCountPeople: 5, BookingTTL: 30},
+		},
+	}
+	w := performRequest(h.CreateEvent, "POST", "/events", req, "user-123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(got) != 2 || got[1].Name != "vip" || got[1].Price != 50 {
+		t.Fatalf("ticket types were not passed to the service: %+v", got)
+	}
+
+	var resp dto.EventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.TicketTypes) != 2 || resp.MaxCountPeople != 55 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestEventHandler_CreateEvent_BookingTTLSeconds(t *testing.T) {
+	var got time.Duration
+	var gotTiers []event.TicketType
+	mock := &mockEventService{
+		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
+			got, gotTiers = bookingTTL, ticketTypes
+			return &event.Event{ID: uuid.New(), Name: name, Date: date, BookingTTL: bookingTTL}, nil
+		},
+	}
+	h := handler.NewEventHandler(mock, nil)
+	req := dto.CreateEventRequest{
+		Name: "Test Event", Description: "desc",
+		Date:           time.Now().Add(time.Hour).Format(time.RFC3339),
+		MaxCountPeople: 10, BookingTTL: 5, BookingTTLSeconds: 90, Price: 100,
+	}
+	w := performRequest(h.CreateEvent, "POST", "/events", req, "user-123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got != 90*time.Second {
+		t.Errorf("expected seconds to take precedence over minutes, got %v", got)
+	}
+
+	var resp dto.EventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.BookingTTLSeconds != 90 || resp.BookingTTL != 1 {
+		t.Errorf("expected 90 seconds and 1 whole minute, got %d and %d", resp.BookingTTLSeconds, resp.BookingTTL)
+	}
+
+	req.TicketTypes = []dto.TicketTypeRequest{{Name: "vip", Price: 50, MaxCountPeople: 5, BookingTTLSeconds: 630}}
+	performRequest(h.CreateEvent, "POST", "/events", req, "user-123")
+	if len(gotTiers) != 1 || gotTiers[0].BookingTTL != 10*time.Minute+30*time.Second {
+		t.Errorf("expected a 10m30s tier, got %+v", gotTiers)
+	}
+}
+
 func TestEventHandler_CreateEvent_InvalidJSON(t *testing.T) {
 	h := handler.NewEventHandler(&mockEventService{}, nil)
 	w := performRequest(h.CreateEvent, "POST", "/events", "{bad json", "user-123")
@@ -116,7 +259,7 @@ func TestEventHandler_CreateEvent_InvalidDate(t *testing.T) {
 
 func TestEventHandler_CreateEvent_ServiceError(t *testing.T) {
 	mock := &mockEventService{
-		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL, maxCountPeople int, price float64) (*event.Event, error) {
+		CreateFn: func(ctx context.Context, userID, name, description string, date time.Time, bookingTTL time.Duration, maxCountPeople int, price float64, ticketTypes []event.TicketType, limits event.Limits) (*event.Event, error) {
 			return nil, errors.New("service error")
 		},
 	}
@@ -135,7 +278,7 @@ func TestEventHandler_CreateEvent_ServiceError(t *testing.T) {
 func TestEventHandler_GetEvent_Success(t *testing.T) {
 	ev := &event.Event{
 		ID: uuid.New(), Name: "Test", FreePlaces: 5, Date: time.Now(),
-		Bookings: []*booking.Booking{}, BookingTTL: 10, MaxCountPeople: 50,
+		Bookings: []*booking.Booking{}, BookingTTL: 10 * time.Minute, MaxCountPeople: 50,
 	}
 	mock := &mockEventService{GetFn: func(ctx context.Context, eventID string) (*event.Event, error) { return ev, nil }}
 	h := handler.NewEventHandler(mock, nil)
@@ -143,6 +286,10 @@ func TestEventHandler_GetEvent_Success(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+	// The creator of this event deleted their account.
+	if strings.Contains(w.Body.String(), "creator_id") {
+		t.Errorf("expected no creator_id, got %s", w.Body)
+	}
 }
 
 func TestEventHandler_GetEvent_Error(t *testing.T) {
@@ -162,7 +309,7 @@ func TestEventHandler_GetEvent_WithBookings(t *testing.T) {
 	}
 	ev := &event.Event{
 		ID: uuid.New(), Name: "Test Event", FreePlaces: 5, Date: time.Now(),
-		Bookings: []*booking.Booking{b}, BookingTTL: 10, MaxCountPeople: 50,
+		Bookings: []*booking.Booking{b}, BookingTTL: 10 * time.Minute, MaxCountPeople: 50,
 	}
 	mock := &mockEventService{GetFn: func(ctx context.Context, eventID string) (*event.Event, error) { return ev, nil }}
 	h := handler.NewEventHandler(mock, nil)
@@ -172,9 +319,131 @@ func TestEventHandler_GetEvent_WithBookings(t *testing.T) {
 	}
 }
 
+func TestEventHandler_UpdateEvent_Success(t *testing.T) {
+	var got event.Update
+	mock := &mockEventService{UpdateFn: func(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+		got = u
+		return &event.Event{ID: uuid.New(), Name: *u.Name, Date: time.Now(), MaxCountPeople: *u.MaxCountPeople}, nil
+	}}
+	h := handler.NewEventHandler(mock, nil)
+	body := map[string]any{"name": "Renamed", "max_count_people": 20, "date": time.Now().Add(time.Hour).Format(time.RFC3339)}
+	w := performRequest(h.UpdateEvent, "PATCH", "/events/1", body, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Name == nil || got.Date == nil || got.Price != nil || got.Description != nil || got.BookingTTL != nil {
+		t.Fatalf("update not bound from body: %+v", got)
+	}
+
+	performRequest(h.UpdateEvent, "PATCH", "/events/1", map[string]any{"name": "Renamed", "max_count_people": 20, "booking_ttl_seconds": 90}, "user")
+	if got.BookingTTL == nil || *got.BookingTTL != 90*time.Second {
+		t.Fatalf("expected a 90 second TTL, got %v", got.BookingTTL)
+	}
+	performRequest(h.UpdateEvent, "PATCH", "/events/1", map[string]any{"name": "Renamed", "max_count_people": 20, "booking_ttl": 10}, "user")
+	if got.BookingTTL == nil || *got.BookingTTL != 10*time.Minute {
+		t.Fatalf("expected a 10 minute TTL, got %v", got.BookingTTL)
+	}
+}
+
+func TestEventHandler_UpdateEvent_InvalidDate(t *testing.T) {
+	h := handler.NewEventHandler(&mockEventService{}, nil)
+	w := performRequest(h.UpdateEvent, "PATCH", "/events/1", map[string]any{"date": "tomorrow"}, "user")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_UpdateEvent_NoUser(t *testing.T) {
+	h := handler.NewEventHandler(&mockEventService{}, nil)
+	w := performRequest(h.UpdateEvent, "PATCH", "/events/1", map[string]any{"name": "Renamed"}, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_UpdateEvent_Error(t *testing.T) {
+	mock := &mockEventService{UpdateFn: func(ctx context.Context, eventID, userID string, u event.Update) (*event.Event, error) {
+		return nil, errors.New("service error")
+	}}
+	h := handler.NewEventHandler(mock, nil)
+	w := performRequest(h.UpdateEvent, "PATCH", "/events/1", map[string]any{"name": "Renamed"}, "user")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CancelEvent_Success(t *testing.T) {
+	mock := &mockEventService{CancelFn: func(ctx context.Context, eventID, userID string) (*event.Event, error) {
+		return &event.Event{ID: uuid.New(), Status: event.StatusCancelled, Date: time.Now()}, nil
+	}}
+	h := handler.NewEventHandler(mock, nil)
+	w := performRequest(h.CancelEvent, "DELETE", "/events/1", nil, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CancelEvent_Error(t *testing.T) {
+	mock := &mockEventService{CancelFn: func(ctx context.Context, eventID, userID string) (*event.Event, error) {
+		return nil, errors.New("service error")
+	}}
+	h := handler.NewEventHandler(mock, nil)
+	w := performRequest(h.CancelEvent, "DELETE", "/events/1", nil, "user")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_ListEvents_Success(t *testing.T) {
+	var got event.Filter
+	mock := &mockEventService{ListFn: func(ctx context.Context, f event.Filter) (*event.Page, error) {
+		got = f
+		return &event.Page{
+			Events:     []*event.Event{{ID: uuid.New(), Name: "Test", Date: time.Now(), FreePlaces: 3}},
+			NextCursor: "next",
+		}, nil
+	}}
+	h := handler.NewEventHandler(mock, nil)
+	w := performRequest(h.ListEvents, "GET", "/events?sort=price_desc&available=true&price_min=10&limit=5&date_from=2030-01-02T15:04:05Z", nil, "u1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Sort != event.SortPriceDesc || !got.OnlyAvailable || got.Limit != 5 || got.PriceMin == nil || *got.PriceMin != 10 || got.DateFrom.IsZero() {
+		t.Fatalf("filter not bound from query: %+v", got)
+	}
+	var resp dto.EventListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(resp.Events) != 1 || resp.NextCursor != "next" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestEventHandler_ListEvents_InvalidDate(t *testing.T) {
+	h := handler.NewEventHandler(&mockEventService{}, nil)
+	w := performRequest(h.ListEvents, "GET", "/events?date_to=tomorrow", nil, "u1")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_ListEvents_Error(t *testing.T) {
+	mock := &mockEventService{ListFn: func(ctx context.Context, f event.Filter) (*event.Page, error) { return nil, errors.New("db error") }}
+	h := handler.NewEventHandler(mock, nil)
+	w := performRequest(h.ListEvents, "GET", "/events", nil, "u1")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
 func TestEventHandler_CreateBooking_Success(t *testing.T) {
 	mock := &mockBookingService{
-		CreateFn: func(ctx context.Context, eventID, userID string, tg, email bool, count int) (*booking.Booking, error) {
+		CreateFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error) {
+			// An omitted channel is passed on as nil for the profile default.
+			if tg == nil || !*tg || email != nil {
+				t.Errorf("expected telegram=true and no email choice, got %v %v", tg, email)
+			}
 			return &booking.Booking{
 				ID: uuid.New(), EventID: uuid.New(), UserID: uuid.New(),
 				Status: booking.StatusCreated, Count: count,
@@ -183,11 +452,37 @@ func TestEventHandler_CreateBooking_Success(t *testing.T) {
 		},
 	}
 	h := handler.NewEventHandler(nil, mock)
-	req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1, TelegramNotification: true}
+	req := map[string]any{"event_id": uuid.New().String(), "count": 1, "telegram_notification": true}
+	w := performRequest(h.CreateBooking, "POST", "/book", req, "user123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CreateBooking_TicketType(t *testing.T) {
+	tierID := uuid.New()
+	mock := &mockBookingService{
+		CreateFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error) {
+			if ticketTypeID != tierID.String() {
+				t.Errorf("expected ticket type %s, got %q", tierID, ticketTypeID)
+			}
+			return &booking.Booking{ID: uuid.New(), TicketTypeID: tierID, TicketTypeName: "vip", Count: count}, nil
+		},
+	}
+	h := handler.NewEventHandler(nil, mock)
+	req := dto.CreateBookingRequest{EventID: uuid.New().String(), TicketTypeID: tierID.String(), Count: 1}
 	w := performRequest(h.CreateBooking, "POST", "/book", req, "user123")
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+
+	var resp dto.BookingResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.TicketTypeID != tierID.String() || resp.TicketTypeName != "vip" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
 }
 
 func TestEventHandler_CreateBooking_InvalidJSON(t *testing.T) {
@@ -200,7 +495,7 @@ func TestEventHandler_CreateBooking_InvalidJSON(t *testing.T) {
 
 func TestEventHandler_CreateBooking_NoUser(t *testing.T) {
 	h := handler.NewEventHandler(nil, &mockBookingService{})
-	req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1, TelegramNotification: true}
+	req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1}
 	w := performRequest(h.CreateBooking, "POST", "/book", req, "")
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("expected 401, got %d", w.Code)
@@ -209,12 +504,12 @@ func TestEventHandler_CreateBooking_NoUser(t *testing.T) {
 
 func TestEventHandler_CreateBooking_ServiceError(t *testing.T) {
 	mock := &mockBookingService{
-		CreateFn: func(ctx context.Context, eventID, userID string, tg, email bool, count int) (*booking.Booking, error) {
+		CreateFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*booking.Booking, error) {
 			return nil, errors.New("service error")
 		},
 	}
 	h := handler.NewEventHandler(nil, mock)
-	req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1, TelegramNotification: true}
+	req := dto.CreateBookingRequest{EventID: uuid.New().String(), Count: 1}
 	w := performRequest(h.CreateBooking, "POST", "/book", req, "user123")
 	if w.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500, got %d", w.Code)
@@ -222,7 +517,7 @@ func TestEventHandler_CreateBooking_ServiceError(t *testing.T) {
 }
 
 func TestEventHandler_ConfirmBooking_Success(t *testing.T) {
-	mock := &mockBookingService{ConfirmFn: func(ctx context.Context, id string) error { return nil }}
+	mock := &mockBookingService{ConfirmFn: func(ctx context.Context, id, userID string) error { return nil }}
 	h := handler.NewEventHandler(nil, mock)
 	w := performRequest(h.ConfirmBooking, "POST", "/confirm/12345", nil, "user")
 	if w.Code != http.StatusOK {
@@ -230,11 +525,161 @@ func TestEventHandler_ConfirmBooking_Success(t *testing.T) {
 	}
 }
 
+func TestEventHandler_ConfirmBooking_NoUser(t *testing.T) {
+	h := handler.NewEventHandler(nil, &mockBookingService{})
+	w := performRequest(h.ConfirmBooking, "POST", "/confirm/77", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
 func TestEventHandler_ConfirmBooking_Error(t *testing.T) {
-	mock := &mockBookingService{ConfirmFn: func(ctx context.Context, id string) error { return errors.New("failed") }}
+	mock := &mockBookingService{ConfirmFn: func(ctx context.Context, id, userID string) error { return errors.New("failed") }}
 	h := handler.NewEventHandler(nil, mock)
 	w := performRequest(h.ConfirmBooking, "POST", "/confirm/77", nil, "user")
 	if w.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500, got %d", w.Code)
 	}
 }
+
+func TestEventHandler_ListBookings_Success(t *testing.T) {
+	var got []booking.Status
+	mock := &mockBookingService{ListFn: func(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+		got = statuses
+		return []*booking.Booking{{
+			ID: uuid.New(), EventID: uuid.New(), UserID: uuid.New(), EventName: "Test",
+			EventDate: time.Now(), Status: booking.StatusConfirmed, Count: 1,
+		}}, nil
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.ListBookings, "GET", "/bookings?status=created,confirmed&status=canceled", nil, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 statuses, got %v", got)
+	}
+	var resp dto.BookingListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Bookings) != 1 || resp.Bookings[0].EventName != "Test" {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestEventHandler_ListBookings_NoUser(t *testing.T) {
+	h := handler.NewEventHandler(nil, &mockBookingService{})
+	w := performRequest(h.ListBookings, "GET", "/bookings", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_ListBookings_Error(t *testing.T) {
+	mock := &mockBookingService{ListFn: func(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+		return nil, errors.New("db error")
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.ListBookings, "GET", "/bookings", nil, "user")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_GetBooking_Success(t *testing.T) {
+	mock := &mockBookingService{GetFn: func(ctx context.Context, id, userID string) (*booking.Booking, error) {
+		return &booking.Booking{ID: uuid.New(), EventID: uuid.New(), UserID: uuid.New(), Status: booking.StatusCreated}, nil
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.GetBooking, "GET", "/bookings/1", nil, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_GetBooking_Error(t *testing.T) {
+	mock := &mockBookingService{GetFn: func(ctx context.Context, id, userID string) (*booking.Booking, error) {
+		return nil, errors.New("booking not found")
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.GetBooking, "GET", "/bookings/1", nil, "user")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CancelBooking_Success(t *testing.T) {
+	mock := &mockBookingService{CancelFn: func(ctx context.Context, id, userID string) (*booking.Booking, error) {
+		return &booking.Booking{ID: uuid.New(), EventID: uuid.New(), UserID: uuid.New(), Status: booking.StatusCancelled}, nil
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.CancelBooking, "POST", "/bookings/1/cancel", nil, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CancelBooking_NoUser(t *testing.T) {
+	h := handler.NewEventHandler(nil, &mockBookingService{})
+	w := performRequest(h.CancelBooking, "POST", "/bookings/1/cancel", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_CancelBooking_Error(t *testing.T) {
+	mock := &mockBookingService{CancelFn: func(ctx context.Context, id, userID string) (*booking.Booking, error) {
+		return nil, errors.New("booking can no longer be cancelled")
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.CancelBooking, "POST", "/bookings/1/cancel", nil, "user")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_JoinWaitlist_Success(t *testing.T) {
+	mock := &mockBookingService{JoinFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*waitlist.Entry, error) {
+		return &waitlist.Entry{ID: uuid.New(), EventID: uuid.New(), Status: waitlist.StatusWaiting, Position: 3, Count: count, CreatedAt: time.Now()}, nil
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.JoinWaitlist, "POST", "/events/1/waitlist", dto.JoinWaitlistRequest{Count: 2}, "user")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp dto.WaitlistEntryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Position != 3 || resp.Count != 2 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestEventHandler_JoinWaitlist_InvalidJSON(t *testing.T) {
+	h := handler.NewEventHandler(nil, &mockBookingService{})
+	w := performRequest(h.JoinWaitlist, "POST", "/events/1/waitlist", map[string]any{"count": 0}, "user")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_JoinWaitlist_SeatsAvailable(t *testing.T) {
+	mock := &mockBookingService{JoinFn: func(ctx context.Context, eventID, userID, ticketTypeID string, tg, email *bool, count int) (*waitlist.Entry, error) {
+		return nil, waitlist.ErrSeatsAvailable
+	}}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.JoinWaitlist, "POST", "/events/1/waitlist", dto.JoinWaitlistRequest{Count: 1}, "user")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestEventHandler_LeaveWaitlist(t *testing.T) {
+	mock := &mockBookingService{LeaveFn: func(ctx context.Context, eventID, userID string) error { return waitlist.ErrNotFound }}
+	h := handler.NewEventHandler(nil, mock)
+	w := performRequest(h.LeaveWaitlist, "DELETE", "/events/1/waitlist", nil, "user")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	w = performRequest(h.LeaveWaitlist, "DELETE", "/events/1/waitlist", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
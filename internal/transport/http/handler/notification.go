@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"eventbooker/internal/notification"
+	"eventbooker/internal/transport/http/dto"
+
+	wbgin "github.com/wb-go/wbf/ginext"
+)
+
+// NotificationServicer defines the notification service interface used by NotificationHandler.
+type NotificationServicer interface {
+	ListDeliveries(ctx context.Context, statuses []notification.DeliveryStatus, bookingID string, limit int) ([]*notification.Delivery, error)
+	Resend(ctx context.Context, id string) (*notification.Delivery, error)
+}
+
+// NotificationHandler handles the admin HTTP requests for the notification delivery log.
+type NotificationHandler struct {
+	notifications NotificationServicer
+}
+
+// NewNotificationHandler creates a new NotificationHandler.
+func NewNotificationHandler(notifications NotificationServicer) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+// ListDeliveries godoc
+// @Summary      List notification deliveries
+// @Description  List logged notification deliveries, newest first. Without status and booking_id only failed and abandoned deliveries are returned. Admins only
+// @Tags         admin
+// @Produce      json
+// @Param        status      query     []string  false  "Filter by status (repeat or comma-separate)"  collectionFormat(multi)  Enums(pending, sent, failed, abandoned)
+// @Param        booking_id  query     string    false  "Booking ID"
+// @Param        limit       query     int       false  "Maximum number of deliveries"
+// @Success      200         {object}  dto.DeliveryListResponse
+// @Failure      400         {object}  map[string]string  "Invalid request"
+// @Failure      401         {object}  map[string]string  "Unauthorized"
+// @Failure      403         {object}  map[string]string  "Not an admin"
+// @Failure      500         {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /admin/notifications [get]
+func (h *NotificationHandler) ListDeliveries(ctx *wbgin.Context) {
+	var q dto.ListDeliveriesQuery
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	var statuses []notification.DeliveryStatus
+	for _, raw := range q.Status {
+		for _, st := range strings.Split(raw, ",") {
+			if st = strings.TrimSpace(st); st != "" {
+				statuses = append(statuses, notification.DeliveryStatus(st))
+			}
+		}
+	}
+
+	deliveries, err := h.notifications.ListDeliveries(ctx.Request.Context(), statuses, q.BookingID, q.Limit)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := dto.DeliveryListResponse{Deliveries: make([]dto.DeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newDeliveryResponse(d))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ResendDelivery godoc
+// @Summary      Re-send a notification
+// @Description  Schedule an immediate retry of a failed or abandoned notification delivery. Admins only
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Delivery ID"
+// @Success      202  {object}  dto.DeliveryResponse
+// @Failure      400  {object}  map[string]string  "Invalid delivery id"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Not an admin"
+// @Failure      404  {object}  map[string]string  "Delivery not found"
+// @Failure      409  {object}  map[string]string  "Already delivered"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /admin/notifications/{id}/resend [post]
+func (h *NotificationHandler) ResendDelivery(ctx *wbgin.Context) {
+	d, err := h.notifications.Resend(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newDeliveryResponse(d))
+}
+
+func newDeliveryResponse(d *notification.Delivery) dto.DeliveryResponse {
+	resp := dto.DeliveryResponse{
+		ID:        d.ID.String(),
+		BookingID: d.BookingID.String(),
+		Kind:      string(d.Kind),
+		Channel:   string(d.Channel),
+		Recipient: d.Recipient,
+		Subject:   d.Content.Subject,
+		Status:    string(d.Status),
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		CreatedAt: d.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: d.UpdatedAt.Format(time.RFC3339),
+	}
+	if !d.NextAttemptAt.IsZero() {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,76 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"eventbooker/internal/notification"
+	"eventbooker/internal/transport/http/dto"
+	"eventbooker/internal/transport/http/handler"
+
+	"github.com/google/uuid"
+)
+
+type mockNotificationService struct {
+	ListDeliveriesFn func(ctx context.Context, statuses []notification.DeliveryStatus, bookingID string, limit int) ([]*notification.Delivery, error)
+	ResendFn         func(ctx context.Context, id string) (*notification.Delivery, error)
+}
+
+func (m *mockNotificationService) ListDeliveries(ctx context.Context, statuses []notification.DeliveryStatus, bookingID string, limit int) ([]*notification.Delivery, error) {
+	return m.ListDeliveriesFn(ctx, statuses, bookingID, limit)
+}
+func (m *mockNotificationService) Resend(ctx context.Context, id string) (*notification.Delivery, error) {
+	return m.ResendFn(ctx, id)
+}
+
+func TestNotificationHandler_ListDeliveries(t *testing.T) {
+	var gotStatuses []notification.DeliveryStatus
+	mock := &mockNotificationService{
+		ListDeliveriesFn: func(ctx context.Context, statuses []notification.DeliveryStatus, bookingID string, limit int) ([]*notification.Delivery, error) {
+			gotStatuses = statuses
+			return []*notification.Delivery{{
+				ID: uuid.New(), BookingID: uuid.New(), Kind: notification.KindBookingExpired, Channel: notification.ChannelEmail,
+				Recipient: "a@example.com", Content: notification.Content{Subject: "Booking Expired"},
+				Status: notification.DeliveryFailed, Attempts: 2, LastError: "smtp timeout",
+				NextAttemptAt: time.Now().Add(time.Minute), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+			}}, nil
+		},
+	}
+	h := handler.NewNotificationHandler(mock)
+
+	w := performRequest(h.ListDeliveries, "GET", "/admin/notifications?status=failed,abandoned", nil, "admin")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(gotStatuses) != 2 || gotStatuses[1] != notification.DeliveryAbandoned {
+		t.Errorf("expected comma-separated statuses, got %v", gotStatuses)
+	}
+
+	var resp dto.DeliveryListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(resp.Deliveries))
+	}
+	if d := resp.Deliveries[0]; d.Subject != "Booking Expired" || d.LastError != "smtp timeout" || d.NextAttemptAt == "" {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+}
+
+func TestNotificationHandler_ResendDelivery_AlreadySent(t *testing.T) {
+	mock := &mockNotificationService{
+		ResendFn: func(ctx context.Context, id string) (*notification.Delivery, error) {
+			return nil, notification.ErrDeliverySent
+		},
+	}
+	h := handler.NewNotificationHandler(mock)
+
+	w := performRequest(h.ResendDelivery, "POST", "/admin/notifications/x/resend", nil, "admin")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"eventbooker/internal/auth"
 	"eventbooker/internal/domain/user"
@@ -14,9 +15,21 @@ import (
 // UserServicer defines the user service interface used by UserHandler.
 type UserServicer interface {
 	Login(ctx context.Context, login, password string) (*auth.Response, error)
-	Register(ctx context.Context, login, password, email, telegram string) (*user.User, error)
-	RefreshTokens(refreshToken string) (*auth.Response, error)
+	Register(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*auth.Response, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) error
+	ForgotPassword(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	VerifyEmail(ctx context.Context, token string) (*user.User, error)
+	ResendVerification(ctx context.Context, userID string) error
 	ValidateToken(tokenStr string) (*auth.Payload, error)
+	CreateTelegramLink(ctx context.Context, userID string) (*user.TelegramLink, error)
+	SetRole(ctx context.Context, userID string, role user.Role) (*user.User, error)
+	Get(ctx context.Context, userID string) (*user.User, error)
+	Update(ctx context.Context, userID string, upd user.Update) (*user.User, error)
+	ChangePassword(ctx context.Context, userID, current, password string) error
+	Delete(ctx context.Context, userID, password string) error
 }
 
 // UserHandler handles HTTP requests for user operations.
@@ -38,27 +51,23 @@ func NewUserHandler(service UserServicer) *UserHandler {
 // @Param        body  body      dto.UserRegistrationRequest  true  "User registration info"
 // @Success      200   {object}  dto.UserResponse
 // @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      409   {object}  map[string]string  "User already exists"
 // @Failure      500   {object}  map[string]string  "Internal server error"
 // @Router       /users/register [post]
 func (h *UserHandler) RegisterUser(ctx *wbgin.Context) {
 	var req dto.UserRegistrationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondBadRequest(ctx, err.Error())
 		return
 	}
 
-	u, err := h.service.Register(ctx.Request.Context(), req.Login, req.Password, req.Email, req.Telegram)
+	u, err := h.service.Register(ctx.Request.Context(), req.Login, req.Password, req.Email, req.Telegram, req.Locale)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.UserResponse{
-		ID:       u.ID.String(),
-		Login:    u.Login,
-		Email:    u.Email,
-		Telegram: u.Telegram,
-	})
+	ctx.JSON(http.StatusOK, newUserResponse(u))
 }
 
 // LoginUser godoc
@@ -75,13 +84,13 @@ func (h *UserHandler) RegisterUser(ctx *wbgin.Context) {
 func (h *UserHandler) LoginUser(ctx *wbgin.Context) {
 	var req dto.UserLoginRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondBadRequest(ctx, err.Error())
 		return
 	}
 
 	jwtResp, err := h.service.Login(ctx.Request.Context(), req.Login, req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
@@ -93,7 +102,7 @@ func (h *UserHandler) LoginUser(ctx *wbgin.Context) {
 
 // RefreshToken godoc
 // @Summary      Refresh JWT token
-// @Description  Refresh access and refresh tokens using existing refresh token
+// @Description  Refresh access and refresh tokens using existing refresh token. A refresh token can be used once; using it again revokes the whole session
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -105,13 +114,13 @@ func (h *UserHandler) LoginUser(ctx *wbgin.Context) {
 func (h *UserHandler) RefreshToken(ctx *wbgin.Context) {
 	var req dto.TokenRefreshRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondBadRequest(ctx, err.Error())
 		return
 	}
 
-	jwtResp, err := h.service.RefreshTokens(req.RefreshToken)
+	jwtResp, err := h.service.RefreshTokens(ctx.Request.Context(), req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, wbgin.H{"error": err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
@@ -120,3 +129,369 @@ func (h *UserHandler) RefreshToken(ctx *wbgin.Context) {
 		RefreshToken: jwtResp.RefreshToken,
 	})
 }
+
+// Logout godoc
+// @Summary      Log out
+// @Description  End the session of the refresh token, so it can no longer be refreshed. The access token stays valid until it expires
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.LogoutRequest  true  "Refresh token of the session"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      401   {object}  map[string]string  "Invalid refresh token"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Router       /auth/logout [post]
+func (h *UserHandler) Logout(ctx *wbgin.Context) {
+	var req dto.LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	if err := h.service.Logout(ctx.Request.Context(), req.RefreshToken); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "logged out"})
+}
+
+// LogoutAll godoc
+// @Summary      Log out everywhere
+// @Description  End every session of the authenticated user. Access tokens already issued stay valid until they expire
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /auth/logout-all [post]
+func (h *UserHandler) LogoutAll(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	if err := h.service.LogoutAll(ctx.Request.Context(), userID.(string)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "logged out everywhere"})
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a one-time password reset token to the account with the login. The email is sent in the background, at most once per password_reset.cooldown, and the response is the same whether or not the account exists or has an email
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ForgotPasswordRequest  true  "Login of the account"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Router       /auth/password/forgot [post]
+func (h *UserHandler) ForgotPassword(ctx *wbgin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	if err := h.service.ForgotPassword(ctx.Request.Context(), req.Login); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "if the account has an email, a reset link was sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password with a token from the reset email. The token works once, and all sessions of the user are ended
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string  "Invalid or expired token, or the password does not meet the policy"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Router       /auth/password/reset [post]
+func (h *UserHandler) ResetPassword(ctx *wbgin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	if err := h.service.ResetPassword(ctx.Request.Context(), req.Token, req.Password); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "password changed, log in with the new password"})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email
+// @Description  Confirm the email address with the token from the verification email. This is the link in the email, so it is a GET. The token works once
+// @Tags         users
+// @Produce      json
+// @Param        token  query     string  true  "Verification token"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  map[string]string  "Invalid or expired token"
+// @Failure      500    {object}  map[string]string  "Internal server error"
+// @Router       /auth/verify [get]
+func (h *UserHandler) VerifyEmail(ctx *wbgin.Context) {
+	u, err := h.service.VerifyEmail(ctx.Request.Context(), ctx.Query("token"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "email " + u.Email + " verified"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Email a new verification link to the authenticated user. Earlier links stop working
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string  "The user has no email"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      409  {object}  map[string]string  "Email is already verified"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /auth/verify/resend [post]
+func (h *UserHandler) ResendVerification(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	if err := h.service.ResendVerification(ctx.Request.Context(), userID.(string)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "verification email sent"})
+}
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Return the profile of the authenticated user
+// @Tags         me
+// @Produce      json
+// @Success      200  {object}  dto.UserResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      404  {object}  map[string]string  "User not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /me [get]
+func (h *UserHandler) GetMe(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	u, err := h.service.Get(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(u))
+}
+
+// UpdateMe godoc
+// @Summary      Edit the current user
+// @Description  Change the email, Telegram chat ID, locale or default notification channels of the authenticated user. Omitted fields are left unchanged and an empty telegram unlinks the chat. A new email has to be verified again
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.UpdateProfileRequest  true  "Fields to change"
+// @Success      200   {object}  dto.UserResponse
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      404   {object}  map[string]string  "User not found"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /me [patch]
+func (h *UserHandler) UpdateMe(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	var req dto.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	u, err := h.service.Update(ctx.Request.Context(), userID.(string), user.Update{
+		Email:                req.Email,
+		Telegram:             req.Telegram,
+		Locale:               req.Locale,
+		EmailNotification:    req.EmailNotification,
+		TelegramNotification: req.TelegramNotification,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(u))
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Set a new password for the authenticated user after checking the current one. All sessions of the user are ended, so they log in again with the new password
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ChangePasswordRequest  true  "Current and new password"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string  "Wrong current password, or the new one does not meet the policy"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /me/password [post]
+func (h *UserHandler) ChangePassword(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	if err := h.service.ChangePassword(ctx.Request.Context(), userID.(string), req.CurrentPassword, req.NewPassword); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "password changed, log in with the new password"})
+}
+
+// DeleteMe godoc
+// @Summary      Delete the current user
+// @Description  Delete the account of the authenticated user after checking the password. Upcoming events of the user are cancelled and their attendees notified; past events stay without a creator. Upcoming bookings are cancelled and their seats go to the waitlist; sessions, notifications and waitlist entries are removed
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.DeleteAccountRequest  true  "Password of the account"
+// @Success      200   {object}  map[string]string
+// @Failure      400   {object}  map[string]string  "Wrong password"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /me [delete]
+func (h *UserHandler) DeleteMe(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	var req dto.DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), userID.(string), req.Password); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "account deleted"})
+}
+
+// CreateTelegramLink godoc
+// @Summary      Link Telegram
+// @Description  Issue a one-time deep link to the bot. Opening it and pressing Start links the Telegram chat to the authenticated user, so notifications and bot commands work without entering a chat ID. A new link replaces the previous one
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  dto.TelegramLinkResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      409  {object}  map[string]string  "Telegram bot is not configured"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /telegram/link [post]
+func (h *UserHandler) CreateTelegramLink(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	l, err := h.service.CreateTelegramLink(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.TelegramLinkResponse{
+		Token:     l.Token,
+		URL:       l.URL,
+		ExpiresAt: l.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// SetUserRole godoc
+// @Summary      Change the role of a user
+// @Description  Make a user an attendee, an organizer who can publish events, or an admin. The new role applies once the user refreshes their tokens. Admins only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string              true  "User ID"
+// @Param        body  body      dto.SetRoleRequest  true  "New role"
+// @Success      200   {object}  dto.UserResponse
+// @Failure      400   {object}  map[string]string  "Invalid user id or role"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      403   {object}  map[string]string  "Not an admin"
+// @Failure      404   {object}  map[string]string  "User not found"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{id}/role [put]
+func (h *UserHandler) SetUserRole(ctx *wbgin.Context) {
+	var req dto.SetRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	u, err := h.service.SetRole(ctx.Request.Context(), ctx.Param("id"), user.Role(req.Role))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(u))
+}
+
+func newUserResponse(u *user.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:       u.ID.String(),
+		Login:    u.Login,
+		Email:    u.Email,
+		Telegram: u.Telegram,
+		Locale:   u.Locale,
+		Role:     string(u.Role),
+
+		EmailVerified:        u.EmailVerified,
+		EmailNotification:    u.EmailNotification,
+		TelegramNotification: u.TelegramNotification,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"eventbooker/internal/auth"
 	"eventbooker/internal/domain/user"
@@ -20,23 +21,71 @@ import (
 
 type mockUserService struct {
 	LoginFn         func(ctx context.Context, login, password string) (*auth.Response, error)
-	RegisterFn      func(ctx context.Context, login, password, email, telegram string) (*user.User, error)
-	RefreshTokensFn func(tokenStr string) (*auth.Response, error)
+	RegisterFn      func(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error)
+	RefreshTokensFn func(ctx context.Context, tokenStr string) (*auth.Response, error)
 	ValidateTokenFn func(tokenStr string) (*auth.Payload, error)
+	TelegramLinkFn  func(ctx context.Context, userID string) (*user.TelegramLink, error)
+	SetRoleFn       func(ctx context.Context, userID string, role user.Role) (*user.User, error)
+	LogoutFn        func(ctx context.Context, tokenStr string) error
+	LogoutAllFn     func(ctx context.Context, userID string) error
+	ForgotFn        func(ctx context.Context, login string) error
+	ResetFn         func(ctx context.Context, token, password string) error
+	VerifyFn        func(ctx context.Context, token string) (*user.User, error)
+	ResendFn        func(ctx context.Context, userID string) error
+	GetFn           func(ctx context.Context, userID string) (*user.User, error)
+	UpdateFn        func(ctx context.Context, userID string, upd user.Update) (*user.User, error)
+	ChangeFn        func(ctx context.Context, userID, current, password string) error
+	DeleteFn        func(ctx context.Context, userID, password string) error
 }
 
 func (m *mockUserService) Login(ctx context.Context, login, password string) (*auth.Response, error) {
 	return m.LoginFn(ctx, login, password)
 }
-func (m *mockUserService) Register(ctx context.Context, login, password, email, telegram string) (*user.User, error) {
-	return m.RegisterFn(ctx, login, password, email, telegram)
+func (m *mockUserService) Register(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error) {
+	return m.RegisterFn(ctx, login, password, email, telegram, locale)
 }
-func (m *mockUserService) RefreshTokens(tokenStr string) (*auth.Response, error) {
-	return m.RefreshTokensFn(tokenStr)
+func (m *mockUserService) RefreshTokens(ctx context.Context, tokenStr string) (*auth.Response, error) {
+	return m.RefreshTokensFn(ctx, tokenStr)
+}
+func (m *mockUserService) Logout(ctx context.Context, tokenStr string) error {
+	return m.LogoutFn(ctx, tokenStr)
+}
+func (m *mockUserService) LogoutAll(ctx context.Context, userID string) error {
+	return m.LogoutAllFn(ctx, userID)
+}
+func (m *mockUserService) ForgotPassword(ctx context.Context, login string) error {
+	return m.ForgotFn(ctx, login)
+}
+func (m *mockUserService) ResetPassword(ctx context.Context, token, password string) error {
+	return m.ResetFn(ctx, token, password)
+}
+func (m *mockUserService) VerifyEmail(ctx context.Context, token string) (*user.User, error) {
+	return m.VerifyFn(ctx, token)
+}
+func (m *mockUserService) ResendVerification(ctx context.Context, userID string) error {
+	return m.ResendFn(ctx, userID)
 }
 func (m *mockUserService) ValidateToken(tokenStr string) (*auth.Payload, error) {
 	return m.ValidateTokenFn(tokenStr)
 }
+func (m *mockUserService) CreateTelegramLink(ctx context.Context, userID string) (*user.TelegramLink, error) {
+	return m.TelegramLinkFn(ctx, userID)
+}
+func (m *mockUserService) SetRole(ctx context.Context, userID string, role user.Role) (*user.User, error) {
+	return m.SetRoleFn(ctx, userID, role)
+}
+func (m *mockUserService) Get(ctx context.Context, userID string) (*user.User, error) {
+	return m.GetFn(ctx, userID)
+}
+func (m *mockUserService) Update(ctx context.Context, userID string, upd user.Update) (*user.User, error) {
+	return m.UpdateFn(ctx, userID, upd)
+}
+func (m *mockUserService) ChangePassword(ctx context.Context, userID, current, password string) error {
+	return m.ChangeFn(ctx, userID, current, password)
+}
+func (m *mockUserService) Delete(ctx context.Context, userID, password string) error {
+	return m.DeleteFn(ctx, userID, password)
+}
 
 func performRequestUser(hf func(*gin.Context), method, path string, body any) *httptest.ResponseRecorder {
 	var buf bytes.Buffer
@@ -54,16 +103,23 @@ func performRequestUser(hf func(*gin.Context), method, path string, body any) *h
 
 func TestUserHandler_RegisterUser_Success(t *testing.T) {
 	mock := &mockUserService{
-		RegisterFn: func(ctx context.Context, login, password, email, telegram string) (*user.User, error) {
-			return &user.User{ID: uuid.New(), Login: login, Email: email, Telegram: telegram}, nil
+		RegisterFn: func(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error) {
+			return &user.User{ID: uuid.New(), Login: login, Email: email, Telegram: telegram, Locale: locale}, nil
 		},
 	}
 	h := handler.NewUserHandler(mock)
-	req := dto.UserRegistrationRequest{Login: "testuser", Password: "password123", Email: "test@test.com", Telegram: "tguser"}
+	req := dto.UserRegistrationRequest{Login: "testuser", Password: "password123", Email: "test@test.com", Telegram: "tguser", Locale: "ru"}
 	w := performRequestUser(h.RegisterUser, "POST", "/register", req)
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+	var resp dto.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Locale != "ru" {
+		t.Errorf("expected locale ru, got %q", resp.Locale)
+	}
 }
 
 func TestUserHandler_RegisterUser_InvalidJSON(t *testing.T) {
@@ -76,7 +132,7 @@ func TestUserHandler_RegisterUser_InvalidJSON(t *testing.T) {
 
 func TestUserHandler_RegisterUser_ServiceError(t *testing.T) {
 	mock := &mockUserService{
-		RegisterFn: func(ctx context.Context, login, password, email, telegram string) (*user.User, error) {
+		RegisterFn: func(ctx context.Context, login, password, email, telegram, locale string) (*user.User, error) {
 			return nil, errors.New("service error")
 		},
 	}
@@ -113,7 +169,7 @@ func TestUserHandler_LoginUser_InvalidJSON(t *testing.T) {
 func TestUserHandler_LoginUser_Unauthorized(t *testing.T) {
 	mock := &mockUserService{
 		LoginFn: func(ctx context.Context, login, password string) (*auth.Response, error) {
-			return nil, errors.New("invalid credentials")
+			return nil, user.ErrInvalidCredentials
 		},
 	}
 	h := handler.NewUserHandler(mock)
@@ -126,7 +182,7 @@ func TestUserHandler_LoginUser_Unauthorized(t *testing.T) {
 
 func TestUserHandler_RefreshToken_Success(t *testing.T) {
 	mock := &mockUserService{
-		RefreshTokensFn: func(tokenStr string) (*auth.Response, error) {
+		RefreshTokensFn: func(ctx context.Context, tokenStr string) (*auth.Response, error) {
 			return &auth.Response{AccessToken: "newaccess", RefreshToken: "newrefresh"}, nil
 		},
 	}
@@ -148,8 +204,8 @@ func TestUserHandler_RefreshToken_InvalidJSON(t *testing.T) {
 
 func TestUserHandler_RefreshToken_Unauthorized(t *testing.T) {
 	mock := &mockUserService{
-		RefreshTokensFn: func(tokenStr string) (*auth.Response, error) {
-			return nil, errors.New("invalid token")
+		RefreshTokensFn: func(ctx context.Context, tokenStr string) (*auth.Response, error) {
+			return nil, auth.ErrInvalidToken
 		},
 	}
 	h := handler.NewUserHandler(mock)
@@ -159,3 +215,309 @@ func TestUserHandler_RefreshToken_Unauthorized(t *testing.T) {
 		t.Fatalf("expected 401, got %d", w.Code)
 	}
 }
+
+func TestUserHandler_Logout(t *testing.T) {
+	mock := &mockUserService{
+		LogoutFn: func(ctx context.Context, tokenStr string) error {
+			if tokenStr != "refresh123" {
+				return auth.ErrInvalidToken
+			}
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	if w := performRequestUser(h.Logout, "POST", "/logout", dto.LogoutRequest{RefreshToken: "refresh123"}); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := performRequestUser(h.Logout, "POST", "/logout", dto.LogoutRequest{RefreshToken: "badtoken"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+	if w := performRequestUser(h.Logout, "POST", "/logout", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a token, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_LogoutAll(t *testing.T) {
+	var gotUserID string
+	mock := &mockUserService{
+		LogoutAllFn: func(ctx context.Context, userID string) error {
+			gotUserID = userID
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	w := performRequest(h.LogoutAll, "POST", "/logout-all", nil, "user-1")
+	if w.Code != http.StatusOK || gotUserID != "user-1" {
+		t.Errorf("expected 200 for user-1, got %d for %q", w.Code, gotUserID)
+	}
+
+	w = performRequest(h.LogoutAll, "POST", "/logout-all", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_ForgotPassword(t *testing.T) {
+	var gotLogin string
+	mock := &mockUserService{
+		ForgotFn: func(ctx context.Context, login string) error {
+			gotLogin = login
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	w := performRequestUser(h.ForgotPassword, "POST", "/password/forgot", dto.ForgotPasswordRequest{Login: "testuser"})
+	if w.Code != http.StatusOK || gotLogin != "testuser" {
+		t.Errorf("expected 200 for testuser, got %d for %q", w.Code, gotLogin)
+	}
+	if w = performRequestUser(h.ForgotPassword, "POST", "/password/forgot", map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a login, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_ResetPassword(t *testing.T) {
+	mock := &mockUserService{
+		ResetFn: func(ctx context.Context, token, password string) error {
+			if token != "tok" {
+				return user.ErrInvalidResetToken
+			}
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	w := performRequestUser(h.ResetPassword, "POST", "/password/reset", dto.ResetPasswordRequest{Token: "tok", Password: "NewPassword1"})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	w = performRequestUser(h.ResetPassword, "POST", "/password/reset", dto.ResetPasswordRequest{Token: "used", Password: "NewPassword1"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a used token, got %d", w.Code)
+	}
+	if w = performRequestUser(h.ResetPassword, "POST", "/password/reset", dto.ResetPasswordRequest{Token: "tok"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a password, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_VerifyEmail(t *testing.T) {
+	mock := &mockUserService{
+		VerifyFn: func(ctx context.Context, token string) (*user.User, error) {
+			if token != "tok" {
+				return nil, user.ErrInvalidVerificationToken
+			}
+			return &user.User{Email: "test@mail.com", EmailVerified: true}, nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	if w := performRequestUser(h.VerifyEmail, "GET", "/verify?token=tok", nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := performRequestUser(h.VerifyEmail, "GET", "/verify?token=used", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a used token, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_ResendVerification(t *testing.T) {
+	mock := &mockUserService{
+		ResendFn: func(ctx context.Context, userID string) error {
+			if userID == "verified" {
+				return user.ErrEmailAlreadyVerified
+			}
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	if w := performRequest(h.ResendVerification, "POST", "/verify/resend", nil, "user-1"); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := performRequest(h.ResendVerification, "POST", "/verify/resend", nil, "verified"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a verified email, got %d", w.Code)
+	}
+	if w := performRequest(h.ResendVerification, "POST", "/verify/resend", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_GetMe(t *testing.T) {
+	id := uuid.New()
+	mock := &mockUserService{
+		GetFn: func(ctx context.Context, userID string) (*user.User, error) {
+			if userID != id.String() {
+				return nil, user.ErrNotFound
+			}
+			return &user.User{ID: id, Login: "testuser", Email: "test@mail.com", EmailNotification: true}, nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	w := performRequest(h.GetMe, "GET", "/me", nil, id.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp dto.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Login != "testuser" || !resp.EmailNotification || resp.TelegramNotification {
+		t.Errorf("unexpected profile %+v", resp)
+	}
+
+	if w = performRequest(h.GetMe, "GET", "/me", nil, "deleted"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted user, got %d", w.Code)
+	}
+	if w = performRequest(h.GetMe, "GET", "/me", nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_UpdateMe(t *testing.T) {
+	var got user.Update
+	mock := &mockUserService{
+		UpdateFn: func(ctx context.Context, userID string, upd user.Update) (*user.User, error) {
+			got = upd
+			return &user.User{ID: uuid.New(), Telegram: *upd.Telegram}, nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	body := map[string]any{"telegram": "", "telegram_notification": false}
+	w := performRequest(h.UpdateMe, "PATCH", "/me", body, "user-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	// Only the fields in the body are changed.
+	if got.Telegram == nil || *got.Telegram != "" || got.TelegramNotification == nil || *got.TelegramNotification ||
+		got.Email != nil || got.Locale != nil || got.EmailNotification != nil {
+		t.Errorf("unexpected update %+v", got)
+	}
+
+	if w = performRequest(h.UpdateMe, "PATCH", "/me", nil, "user-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a body, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	mock := &mockUserService{
+		ChangeFn: func(ctx context.Context, userID, current, password string) error {
+			if current != "Password1" {
+				return user.ErrWrongPassword
+			}
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	req := dto.ChangePasswordRequest{CurrentPassword: "Password1", NewPassword: "NewPassword1"}
+	if w := performRequest(h.ChangePassword, "POST", "/me/password", req, "user-1"); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	req.CurrentPassword = "Wrong1"
+	if w := performRequest(h.ChangePassword, "POST", "/me/password", req, "user-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a wrong password, got %d", w.Code)
+	}
+	if w := performRequest(h.ChangePassword, "POST", "/me/password", dto.ChangePasswordRequest{CurrentPassword: "Password1"}, "user-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a new password, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_DeleteMe(t *testing.T) {
+	mock := &mockUserService{
+		DeleteFn: func(ctx context.Context, userID, password string) error {
+			if password != "Password1" {
+				return user.ErrWrongPassword
+			}
+			return nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	req := dto.DeleteAccountRequest{Password: "Password1"}
+	if w := performRequest(h.DeleteMe, "DELETE", "/me", req, "user-1"); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := performRequest(h.DeleteMe, "DELETE", "/me", dto.DeleteAccountRequest{Password: "Wrong1"}, "user-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a wrong password, got %d", w.Code)
+	}
+	if w := performRequest(h.DeleteMe, "DELETE", "/me", map[string]string{}, "user-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a password, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_CreateTelegramLink(t *testing.T) {
+	var gotUserID string
+	mock := &mockUserService{
+		TelegramLinkFn: func(ctx context.Context, userID string) (*user.TelegramLink, error) {
+			gotUserID = userID
+			return &user.TelegramLink{Token: "tok", URL: "https://t.me/bot?start=tok", ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+
+	w := performRequest(h.CreateTelegramLink, "POST", "/telegram/link", nil, "user-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp dto.TelegramLinkResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if gotUserID != "user-1" || resp.URL != "https://t.me/bot?start=tok" || resp.ExpiresAt == "" {
+		t.Errorf("unexpected response %+v for user %q", resp, gotUserID)
+	}
+
+	w = performRequest(h.CreateTelegramLink, "POST", "/telegram/link", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_SetUserRole(t *testing.T) {
+	id := uuid.New()
+	var gotRole user.Role
+	mock := &mockUserService{
+		SetRoleFn: func(ctx context.Context, userID string, role user.Role) (*user.User, error) {
+			if userID != id.String() {
+				return nil, user.ErrNotFound
+			}
+			if !role.Valid() {
+				return nil, user.ErrInvalidRole
+			}
+			gotRole = role
+			return &user.User{ID: id, Login: "testuser", Role: role}, nil
+		},
+	}
+	h := handler.NewUserHandler(mock)
+	setRole := func(userID string, body any) *httptest.ResponseRecorder {
+		return performRequestUser(func(c *gin.Context) {
+			c.Params = gin.Params{{Key: "id", Value: userID}}
+			h.SetUserRole(c)
+		}, "PUT", "/admin/users/"+userID+"/role", body)
+	}
+
+	w := setRole(id.String(), dto.SetRoleRequest{Role: "organizer"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp dto.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if gotRole != user.RoleOrganizer || resp.Role != "organizer" {
+		t.Errorf("unexpected response %+v for role %q", resp, gotRole)
+	}
+
+	if w = setRole(id.String(), dto.SetRoleRequest{Role: "owner"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown role, got %d", w.Code)
+	}
+	if w = setRole(id.String(), map[string]string{}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a role, got %d", w.Code)
+	}
+	if w = setRole(uuid.NewString(), dto.SetRoleRequest{Role: "admin"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown user, got %d", w.Code)
+	}
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"eventbooker/internal/transport/http/dto"
+	"eventbooker/internal/webhook"
+
+	wbgin "github.com/wb-go/wbf/ginext"
+)
+
+// WebhookServicer defines the webhook service interface used by WebhookHandler.
+type WebhookServicer interface {
+	Create(ctx context.Context, userID, url, secret string, types []webhook.Type) (*webhook.Subscription, error)
+	List(ctx context.Context, userID string) ([]*webhook.Subscription, error)
+	Delete(ctx context.Context, id, userID string) error
+	Deliveries(ctx context.Context, id, userID string, limit int) ([]*webhook.Delivery, error)
+}
+
+// WebhookHandler handles HTTP requests for webhook subscriptions.
+type WebhookHandler struct {
+	webhooks WebhookServicer
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(webhooks WebhookServicer) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// CreateWebhook godoc
+// @Summary      Subscribe to webhooks
+// @Description  Receive signed JSON POSTs about bookings and changes of the authenticated organizer's events. Event types: booking.created, booking.confirmed, booking.cancelled, booking.expired, event.updated. The URL must point to a public address. The secret is returned only here
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.CreateWebhookRequest  true  "Subscription"
+// @Success      200   {object}  dto.WebhookResponse
+// @Failure      400   {object}  map[string]string  "Invalid request"
+// @Failure      401   {object}  map[string]string  "Unauthorized"
+// @Failure      500   {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(ctx *wbgin.Context) {
+	var req dto.CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	types := make([]webhook.Type, 0, len(req.EventTypes))
+	for _, t := range req.EventTypes {
+		types = append(types, webhook.Type(t))
+	}
+
+	sub, err := h.webhooks.Create(ctx.Request.Context(), userID.(string), req.URL, req.Secret, types)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := newWebhookResponse(sub)
+	resp.Secret = sub.Secret
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  List the webhook subscriptions of the authenticated user
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {object}  dto.WebhookListResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	subs, err := h.webhooks.List(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := dto.WebhookListResponse{Webhooks: make([]dto.WebhookResponse, 0, len(subs))}
+	for _, s := range subs {
+		resp.Webhooks = append(resp.Webhooks, newWebhookResponse(s))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Delete a webhook subscription of the authenticated user together with its delivery history
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string  "Invalid webhook id"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Not the owner"
+// @Failure      404  {object}  map[string]string  "Webhook not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(ctx *wbgin.Context) {
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	if err := h.webhooks.Delete(ctx.Request.Context(), ctx.Param("id"), userID.(string)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wbgin.H{"message": "webhook deleted"})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      Webhook delivery history
+// @Description  List the latest deliveries of a webhook subscription of the authenticated user, newest first
+// @Tags         webhooks
+// @Produce      json
+// @Param        id     path      string  true   "Webhook ID"
+// @Param        limit  query     int     false  "Maximum number of deliveries"
+// @Success      200    {object}  dto.WebhookDeliveryListResponse
+// @Failure      400    {object}  map[string]string  "Invalid request"
+// @Failure      401    {object}  map[string]string  "Unauthorized"
+// @Failure      403    {object}  map[string]string  "Not the owner"
+// @Failure      404    {object}  map[string]string  "Webhook not found"
+// @Failure      500    {object}  map[string]string  "Internal server error"
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(ctx *wbgin.Context) {
+	var q dto.WebhookDeliveriesQuery
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		respondBadRequest(ctx, err.Error())
+		return
+	}
+
+	userID, exists := ctx.Get("userId")
+	if !exists {
+		respondError(ctx, errNoUserInContext)
+		return
+	}
+
+	deliveries, err := h.webhooks.Deliveries(ctx.Request.Context(), ctx.Param("id"), userID.(string), q.Limit)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	resp := dto.WebhookDeliveryListResponse{Deliveries: make([]dto.WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newWebhookDeliveryResponse(d))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func newWebhookResponse(s *webhook.Subscription) dto.WebhookResponse {
+	resp := dto.WebhookResponse{
+		ID:         s.ID.String(),
+		URL:        s.URL,
+		EventTypes: make([]string, 0, len(s.Types)),
+		CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+	}
+	for _, t := range s.Types {
+		resp.EventTypes = append(resp.EventTypes, string(t))
+	}
+	return resp
+}
+
+func newWebhookDeliveryResponse(d *webhook.Delivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             d.ID.String(),
+		MessageID:      d.MessageID.String(),
+		EventType:      string(d.Type),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if !d.NextAttemptAt.IsZero() {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if !d.DeliveredAt.IsZero() {
+		resp.DeliveredAt = d.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
+}
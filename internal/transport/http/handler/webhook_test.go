@@ -0,0 +1,87 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"eventbooker/internal/transport/http/dto"
+	"eventbooker/internal/transport/http/handler"
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+type mockWebhookService struct {
+	CreateFn     func(ctx context.Context, userID, url, secret string, types []webhook.Type) (*webhook.Subscription, error)
+	ListFn       func(ctx context.Context, userID string) ([]*webhook.Subscription, error)
+	DeleteFn     func(ctx context.Context, id, userID string) error
+	DeliveriesFn func(ctx context.Context, id, userID string, limit int) ([]*webhook.Delivery, error)
+}
+
+func (m *mockWebhookService) Create(ctx context.Context, userID, url, secret string, types []webhook.Type) (*webhook.Subscription, error) {
+	return m.CreateFn(ctx, userID, url, secret, types)
+}
+func (m *mockWebhookService) List(ctx context.Context, userID string) ([]*webhook.Subscription, error) {
+	return m.ListFn(ctx, userID)
+}
+func (m *mockWebhookService) Delete(ctx context.Context, id, userID string) error {
+	return m.DeleteFn(ctx, id, userID)
+}
+func (m *mockWebhookService) Deliveries(ctx context.Context, id, userID string, limit int) ([]*webhook.Delivery, error) {
+	return m.DeliveriesFn(ctx, id, userID, limit)
+}
+
+func TestWebhookHandler_CreateWebhook_ReturnsSecretOnce(t *testing.T) {
+	sub := &webhook.Subscription{
+		ID: uuid.New(), UserID: uuid.New(), URL: "https://crm.example.com/hooks", Secret: "whsec_abc",
+		Types: []webhook.Type{webhook.TypeBookingConfirmed}, CreatedAt: time.Now(),
+	}
+	mock := &mockWebhookService{
+		CreateFn: func(ctx context.Context, userID, url, secret string, types []webhook.Type) (*webhook.Subscription, error) {
+			return sub, nil
+		},
+		ListFn: func(ctx context.Context, userID string) ([]*webhook.Subscription, error) {
+			return []*webhook.Subscription{sub}, nil
+		},
+	}
+	h := handler.NewWebhookHandler(mock)
+
+	req := dto.CreateWebhookRequest{URL: sub.URL, EventTypes: []string{"booking.confirmed"}}
+	w := performRequest(h.CreateWebhook, "POST", "/webhooks", req, sub.UserID.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var created dto.WebhookResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Secret != sub.Secret {
+		t.Errorf("expected the secret in the create response, got %q", created.Secret)
+	}
+
+	w = performRequest(h.ListWebhooks, "GET", "/webhooks", nil, sub.UserID.String())
+	var list dto.WebhookListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Webhooks) != 1 || list.Webhooks[0].Secret != "" {
+		t.Errorf("expected the secret to be hidden when listing, got %+v", list.Webhooks)
+	}
+}
+
+func TestWebhookHandler_DeleteWebhook_NotOwner(t *testing.T) {
+	mock := &mockWebhookService{
+		DeleteFn: func(ctx context.Context, id, userID string) error {
+			return webhook.ErrNotOwner
+		},
+	}
+	h := handler.NewWebhookHandler(mock)
+
+	w := performRequest(h.DeleteWebhook, "DELETE", "/webhooks/x", nil, uuid.NewString())
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"errors"
+
 	"eventbooker/internal/auth"
 
 	wbgin "github.com/wb-go/wbf/ginext"
@@ -16,7 +18,7 @@ func Auth(validator TokenValidator) wbgin.HandlerFunc {
 	return func(c *wbgin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
-			c.AbortWithStatusJSON(401, wbgin.H{"error": "missing token"})
+			c.AbortWithStatusJSON(401, wbgin.H{"error": "missing token", "code": "missing_token"})
 			return
 		}
 
@@ -26,11 +28,16 @@ func Auth(validator TokenValidator) wbgin.HandlerFunc {
 
 		payload, err := validator.ValidateToken(token)
 		if err != nil {
-			c.AbortWithStatusJSON(401, wbgin.H{"error": "invalid token"})
+			code := "invalid_token"
+			if errors.Is(err, auth.ErrTokenExpired) {
+				code = "token_expired"
+			}
+			c.AbortWithStatusJSON(401, wbgin.H{"error": "invalid token", "code": code})
 			return
 		}
 
 		c.Set("userId", payload.UserID)
+		c.Set("role", payload.Role)
 		c.Next()
 	}
 }
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"eventbooker/internal/auth"
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/user"
+	"eventbooker/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.JWTConfig{AccessSecret: "access-secret", RefreshSecret: "refresh-secret", ExpAccessToken: 1}
+	expiredCfg := *cfg
+	expiredCfg.ExpAccessToken = -1
+
+	u := &user.User{ID: uuid.New(), Role: user.RoleAttendee}
+	valid, err := auth.NewService(cfg).GenerateTokens(u, user.NewRefreshToken(u.ID, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := auth.NewService(&expiredCfg).GenerateTokens(u, user.NewRefreshToken(u.ID, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/me", middleware.Auth(auth.NewService(cfg)), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		wantCode int
+		wantErr  string
+	}{
+		{"valid", "Bearer " + valid.AccessToken, http.StatusOK, ""},
+		{"missing", "", http.StatusUnauthorized, "missing_token"},
+		{"expired", "Bearer " + expired.AccessToken, http.StatusUnauthorized, "token_expired"},
+		{"garbage", "Bearer garbage", http.StatusUnauthorized, "invalid_token"},
+		{"refresh token", "Bearer " + valid.RefreshToken, http.StatusUnauthorized, "invalid_token"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.wantCode, w.Code)
+			continue
+		}
+		if tt.wantErr == "" {
+			if w.Body.String() != u.ID.String() {
+				t.Errorf("%s: expected user %s in the context, got %q", tt.name, u.ID, w.Body.String())
+			}
+			continue
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["code"] != tt.wantErr {
+			t.Errorf("%s: expected code %q, got %q", tt.name, tt.wantErr, body["code"])
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/config"
+	"eventbooker/internal/idempotency"
+
+	"github.com/gin-gonic/gin"
+	wbgin "github.com/wb-go/wbf/ginext"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	defaultIdempotencyLease = time.Minute
+)
+
+// IdempotencyStore keeps the idempotency keys of users with their responses.
+type IdempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, rec *idempotency.Record, lease time.Duration) (*idempotency.Record, error)
+	CompleteIdempotencyKey(ctx context.Context, userID, key string, statusCode int, body []byte) error
+	ExtendIdempotencyKey(ctx context.Context, userID, key string, lease time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error
+}
+
+// Idempotency returns a middleware that makes a handler safe to retry. The first
+// successful response to a request with an Idempotency-Key header is stored for the
+// user and replayed to retries with the same key; a retry with another body is
+// rejected. Failed requests are not stored, so they can be retried with the same
+// key. The lease of a claimed key is renewed while the handler runs; a successful
+// response that cannot be stored keeps the key claimed until it expires, so the
+// request is never handled twice. Requests without the header pass through. It
+// must run after Auth.
+func Idempotency(store IdempotencyStore, cfg *config.IdempotencyConfig) wbgin.HandlerFunc {
+	ttl, lease := cfg.TTL, cfg.Lease
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if lease <= 0 {
+		lease = defaultIdempotencyLease
+	}
+
+	return func(c *wbgin.Context) {
+		key := c.GetHeader(idempotency.Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, _ := c.Get("userId")
+		id, _ := userID.(string)
+		if id == "" {
+			c.AbortWithStatusJSON(401, wbgin.H{"error": "user not found in context", "code": "unauthorized"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortIdempotency(c, apperr.Validation("invalid_request", "cannot read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec, err := idempotency.New(id, key, idempotency.Hash(c.Request.Method, c.Request.URL.Path, body), ttl)
+		if err != nil {
+			abortIdempotency(c, err)
+			return
+		}
+
+		stored, err := store.ClaimIdempotencyKey(c.Request.Context(), rec, lease)
+		if err != nil {
+			abortIdempotency(c, err)
+			return
+		}
+		if stored != nil {
+			if err = stored.Match(rec.RequestHash); err != nil {
+				abortIdempotency(c, err)
+				return
+			}
+			c.Header(idempotency.ReplayedHeader, "true")
+			c.Data(stored.StatusCode, "application/json; charset=utf-8", stored.Body)
+			c.Abort()
+			return
+		}
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		w := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		stop := holdIdempotencyKey(ctx, store, id, key, lease)
+		succeeded := false
+		defer func() {
+			stop()
+			// Also runs when the handler panics, so the key is not held until the lease ends.
+			if succeeded {
+				return
+			}
+			if err := store.ReleaseIdempotencyKey(ctx, id, key); err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("cannot release idempotency key")
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status < 200 || status >= 300 {
+			return
+		}
+		succeeded = true
+		stop()
+		if err = store.CompleteIdempotencyKey(ctx, id, key, status, w.body.Bytes()); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("cannot store idempotent response")
+			// The request has taken effect, so retries must get 409 rather than run it again.
+			if err = store.ExtendIdempotencyKey(ctx, id, key, ttl); err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("cannot keep idempotency key without a stored response")
+			}
+		}
+	}
+}
+
+// holdIdempotencyKey renews the lease of a claimed key every third of the lease
+// until the returned function is called, so a retry cannot take the key over while
+// the handler is still running. The function waits for the last renewal to finish.
+func holdIdempotencyKey(ctx context.Context, store IdempotencyStore, userID, key string, lease time.Duration) func() {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := store.ExtendIdempotencyKey(ctx, userID, key, lease); err != nil {
+					wbzlog.Logger.Warn().Err(err).Msg("cannot renew idempotency key lease")
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// abortIdempotency writes an idempotency error. A key reused for another request is
+// reported as 422, as in the IETF Idempotency-Key draft.
+func abortIdempotency(c *wbgin.Context, err error) {
+	var status int
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, apperr.ErrValidation):
+		status = http.StatusBadRequest
+	case errors.Is(err, apperr.ErrConflict):
+		status = http.StatusConflict
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, wbgin.H{"error": "internal server error", "code": apperr.CodeInternal})
+		return
+	}
+	c.AbortWithStatusJSON(status, wbgin.H{"error": err.Error(), "code": apperr.Code(err)})
+}
+
+// responseRecorder keeps a copy of the response body written by the handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
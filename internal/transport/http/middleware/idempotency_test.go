@@ -0,0 +1,212 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/idempotency"
+	"eventbooker/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeStore struct {
+	mu           sync.Mutex
+	records      map[string]*idempotency.Record
+	released     int
+	extended     []time.Duration
+	failComplete bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: map[string]*idempotency.Record{}}
+}
+
+func (s *fakeStore) ClaimIdempotencyKey(_ context.Context, rec *idempotency.Record, _ time.Duration) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.records[rec.UserID+"/"+rec.Key]; ok {
+		cp := *stored
+		return &cp, nil
+	}
+	cp := *rec
+	s.records[rec.UserID+"/"+rec.Key] = &cp
+	return nil, nil
+}
+
+func (s *fakeStore) CompleteIdempotencyKey(_ context.Context, userID, key string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failComplete {
+		return errors.New("connection reset")
+	}
+	rec := s.records[userID+"/"+key]
+	rec.StatusCode, rec.Body = statusCode, body
+	return nil
+}
+
+func (s *fakeStore) ExtendIdempotencyKey(_ context.Context, _, _ string, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extended = append(s.extended, lease)
+	return nil
+}
+
+func (s *fakeStore) ReleaseIdempotencyKey(_ context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, userID+"/"+key)
+	s.released++
+	return nil
+}
+
+// newBookingRouter serves a handler that books once per call and answers with the booking number.
+func newBookingRouter(store *fakeStore, status int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/events/:id/book",
+		func(c *gin.Context) { c.Set("userId", c.GetHeader("X-User")); c.Next() },
+		middleware.Idempotency(store, &config.IdempotencyConfig{}),
+		func(c *gin.Context) {
+			*calls++
+			c.JSON(status, gin.H{"booking": *calls})
+		},
+	)
+	return r
+}
+
+func book(r http.Handler, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/events/1/book", strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	calls := 0
+	r := newBookingRouter(newFakeStore(), http.StatusOK, &calls)
+
+	first := book(r, "u1", "k1", `{"count":1}`)
+	retry := book(r, "u1", "k1", `{"count":1}`)
+	if calls != 1 {
+		t.Fatalf("expected the booking to be made once, got %d", calls)
+	}
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the first response to be replayed, got %d %s", retry.Code, retry.Body)
+	}
+	if retry.Header().Get(idempotency.ReplayedHeader) != "true" || first.Header().Get(idempotency.ReplayedHeader) != "" {
+		t.Error("expected only the replay to be marked")
+	}
+
+	// Keys are scoped to the user.
+	book(r, "u2", "k1", `{"count":1}`)
+	if calls != 2 {
+		t.Errorf("expected another user's key not to be replayed, got %d calls", calls)
+	}
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	calls := 0
+	r := newBookingRouter(newFakeStore(), http.StatusOK, &calls)
+
+	book(r, "u1", "k1", `{"count":1}`)
+	w := book(r, "u1", "k1", `{"count":2}`)
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "idempotency_key_reused") {
+		t.Errorf("expected 422 idempotency_key_reused, got %d %s", w.Code, w.Body)
+	}
+	if calls != 1 {
+		t.Errorf("expected the second request not to be handled, got %d calls", calls)
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	store := newFakeStore()
+	calls := 0
+	r := newBookingRouter(store, http.StatusOK, &calls)
+	rec, _ := idempotency.New("u1", "k1", idempotency.Hash(http.MethodPost, "/events/1/book", []byte(`{}`)), time.Hour)
+	_, _ = store.ClaimIdempotencyKey(context.Background(), rec, time.Minute)
+
+	w := book(r, "u1", "k1", `{}`)
+	if w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("expected 409 without handling, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestIdempotency_FailureReleasesKey(t *testing.T) {
+	store := newFakeStore()
+	calls := 0
+	r := newBookingRouter(store, http.StatusConflict, &calls)
+
+	book(r, "u1", "k1", `{}`)
+	book(r, "u1", "k1", `{}`)
+	if calls != 2 || store.released != 2 {
+		t.Errorf("expected failed requests to be retried, got %d calls and %d releases", calls, store.released)
+	}
+}
+
+func TestIdempotency_CompleteFailureKeepsKey(t *testing.T) {
+	store := newFakeStore()
+	store.failComplete = true
+	calls := 0
+	r := newBookingRouter(store, http.StatusCreated, &calls)
+
+	if w := book(r, "u1", "k1", `{}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be answered, got %d", w.Code)
+	}
+	w := book(r, "u1", "k1", `{}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "idempotency_in_progress") {
+		t.Errorf("expected 409 idempotency_in_progress for the retry, got %d %s", w.Code, w.Body)
+	}
+	if calls != 1 || store.released != 0 {
+		t.Errorf("expected the booking to be made once and the key kept, got %d calls and %d releases", calls, store.released)
+	}
+	if len(store.extended) != 1 || store.extended[0] != 24*time.Hour {
+		t.Errorf("expected the key to be held until it expires, got %v", store.extended)
+	}
+}
+
+func TestIdempotency_RenewsLeaseWhileHandling(t *testing.T) {
+	store := newFakeStore()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/events/:id/book",
+		func(c *gin.Context) { c.Set("userId", "u1"); c.Next() },
+		middleware.Idempotency(store, &config.IdempotencyConfig{Lease: 30 * time.Millisecond}),
+		func(c *gin.Context) {
+			time.Sleep(100 * time.Millisecond)
+			c.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	book(r, "u1", "k1", `{}`)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.extended) == 0 || store.extended[0] != 30*time.Millisecond {
+		t.Errorf("expected the lease to be renewed while the handler runs, got %v", store.extended)
+	}
+}
+
+func TestIdempotency_InvalidAndMissingKey(t *testing.T) {
+	calls := 0
+	r := newBookingRouter(newFakeStore(), http.StatusOK, &calls)
+
+	if w := book(r, "u1", strings.Repeat("k", 300), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a too long key, got %d", w.Code)
+	}
+	book(r, "u1", "", `{}`)
+	book(r, "u1", "", `{}`)
+	if calls != 2 {
+		t.Errorf("expected requests without a key to pass through, got %d calls", calls)
+	}
+}
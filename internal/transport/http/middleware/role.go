@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"slices"
+
+	"eventbooker/internal/domain/user"
+
+	wbgin "github.com/wb-go/wbf/ginext"
+)
+
+// RequireRole returns a middleware that only lets users with one of the given roles
+// through. It must run after Auth, which puts the role into the context.
+func RequireRole(roles ...user.Role) wbgin.HandlerFunc {
+	return func(c *wbgin.Context) {
+		value, _ := c.Get("role")
+		role, _ := value.(user.Role)
+		if !slices.Contains(roles, role) {
+			c.AbortWithStatusJSON(403, wbgin.H{"error": "insufficient role", "code": "forbidden_role"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"eventbooker/internal/domain/user"
+	"eventbooker/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/events",
+		func(c *gin.Context) {
+			if role := c.GetHeader("X-Role"); role != "" {
+				c.Set("role", user.Role(role))
+			}
+			c.Next()
+		},
+		middleware.RequireRole(user.RoleOrganizer, user.RoleAdmin),
+		func(c *gin.Context) { c.Status(http.StatusCreated) },
+	)
+
+	tests := []struct {
+		role string
+		want int
+	}{
+		{"organizer", http.StatusCreated},
+		{"admin", http.StatusCreated},
+		{"attendee", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/events", nil)
+		req.Header.Set("X-Role", tt.role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("role %q: expected %d, got %d", tt.role, tt.want, w.Code)
+		}
+	}
+}
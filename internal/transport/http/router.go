@@ -1,7 +1,11 @@
 package http
 
 import (
+	"expvar"
+
 	_ "eventbooker/docs"
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/user"
 	"eventbooker/internal/transport/http/handler"
 	"eventbooker/internal/transport/http/middleware"
 
@@ -10,7 +14,13 @@ import (
 )
 
 // RegisterRoutes sets up all API routes.
-func RegisterRoutes(engine *wbgin.Engine, userHandler *handler.UserHandler, eventHandler *handler.EventHandler, tokenValidator middleware.TokenValidator) {
+func RegisterRoutes(engine *wbgin.Engine, userHandler *handler.UserHandler, eventHandler *handler.EventHandler, notificationHandler *handler.NotificationHandler, webhookHandler *handler.WebhookHandler, tokenValidator middleware.TokenValidator, idempotencyStore middleware.IdempotencyStore, idempotencyCfg *config.IdempotencyConfig) {
+	// Runtime, outbox relay and notification delivery metrics. They include the
+	// command line and memory stats, so only admins may read them.
+	engine.GET("/debug/vars", middleware.Auth(tokenValidator), middleware.RequireRole(user.RoleAdmin), func(c *wbgin.Context) {
+		expvar.Handler().ServeHTTP(c.Writer, c.Request)
+	})
+
 	api := engine.Group("/api")
 
 	api.GET("/swagger/*any", func(c *wbgin.Context) {
@@ -22,11 +32,55 @@ func RegisterRoutes(engine *wbgin.Engine, userHandler *handler.UserHandler, even
 	authGroup.POST("/register", userHandler.RegisterUser)
 	authGroup.POST("/login", userHandler.LoginUser)
 	authGroup.POST("/refresh", userHandler.RefreshToken)
+	authGroup.POST("/logout", userHandler.Logout)
+	authGroup.POST("/logout-all", middleware.Auth(tokenValidator), func(c *wbgin.Context) { userHandler.LogoutAll(c) })
+	authGroup.POST("/password/forgot", userHandler.ForgotPassword)
+	authGroup.POST("/password/reset", userHandler.ResetPassword)
+	authGroup.GET("/verify", userHandler.VerifyEmail)
+	authGroup.POST("/verify/resend", middleware.Auth(tokenValidator), func(c *wbgin.Context) { userHandler.ResendVerification(c) })
+
+	// Events are published and managed by organizers only
+	organizer := middleware.RequireRole(user.RoleOrganizer, user.RoleAdmin)
 
 	// Protected event routes
 	events := api.Group("/events", middleware.Auth(tokenValidator))
-	events.POST("", func(c *wbgin.Context) { eventHandler.CreateEvent(c) })
+	events.POST("", organizer, func(c *wbgin.Context) { eventHandler.CreateEvent(c) })
+	events.GET("", func(c *wbgin.Context) { eventHandler.ListEvents(c) })
 	events.GET("/:id", func(c *wbgin.Context) { eventHandler.GetEvent(c) })
-	events.POST("/:id/book", func(c *wbgin.Context) { eventHandler.CreateBooking(c) })
+	events.PATCH("/:id", organizer, func(c *wbgin.Context) { eventHandler.UpdateEvent(c) })
+	events.DELETE("/:id", organizer, func(c *wbgin.Context) { eventHandler.CancelEvent(c) })
+	events.POST("/:id/book", middleware.Idempotency(idempotencyStore, idempotencyCfg), func(c *wbgin.Context) { eventHandler.CreateBooking(c) })
 	events.POST("/:id/confirm", func(c *wbgin.Context) { eventHandler.ConfirmBooking(c) })
+	events.POST("/:id/waitlist", func(c *wbgin.Context) { eventHandler.JoinWaitlist(c) })
+	events.DELETE("/:id/waitlist", func(c *wbgin.Context) { eventHandler.LeaveWaitlist(c) })
+
+	// Protected booking routes
+	bookings := api.Group("/bookings", middleware.Auth(tokenValidator))
+	bookings.GET("", func(c *wbgin.Context) { eventHandler.ListBookings(c) })
+	bookings.GET("/:id", func(c *wbgin.Context) { eventHandler.GetBooking(c) })
+	bookings.POST("/:id/cancel", func(c *wbgin.Context) { eventHandler.CancelBooking(c) })
+
+	// Profile of the current user
+	me := api.Group("/me", middleware.Auth(tokenValidator))
+	me.GET("", func(c *wbgin.Context) { userHandler.GetMe(c) })
+	me.PATCH("", func(c *wbgin.Context) { userHandler.UpdateMe(c) })
+	me.DELETE("", func(c *wbgin.Context) { userHandler.DeleteMe(c) })
+	me.POST("/password", func(c *wbgin.Context) { userHandler.ChangePassword(c) })
+
+	// Protected Telegram linking routes
+	telegram := api.Group("/telegram", middleware.Auth(tokenValidator))
+	telegram.POST("/link", func(c *wbgin.Context) { userHandler.CreateTelegramLink(c) })
+
+	// Protected webhook routes
+	webhooks := api.Group("/webhooks", middleware.Auth(tokenValidator), organizer)
+	webhooks.POST("", func(c *wbgin.Context) { webhookHandler.CreateWebhook(c) })
+	webhooks.GET("", func(c *wbgin.Context) { webhookHandler.ListWebhooks(c) })
+	webhooks.DELETE("/:id", func(c *wbgin.Context) { webhookHandler.DeleteWebhook(c) })
+	webhooks.GET("/:id/deliveries", func(c *wbgin.Context) { webhookHandler.ListWebhookDeliveries(c) })
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.Auth(tokenValidator), middleware.RequireRole(user.RoleAdmin))
+	admin.GET("/notifications", func(c *wbgin.Context) { notificationHandler.ListDeliveries(c) })
+	admin.POST("/notifications/:id/resend", func(c *wbgin.Context) { notificationHandler.ResendDelivery(c) })
+	admin.PUT("/users/:id/role", func(c *wbgin.Context) { userHandler.SetUserRole(c) })
 }
@@ -0,0 +1,410 @@
+// Package telegram is the Telegram transport: a bot through which users browse
+// upcoming events and manage their bookings without opening the web page.
+//
+// A chat acts on behalf of the user who registered its chat ID. Only private chats
+// are served, since the ID of a private chat is the ID of the Telegram user.
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/user"
+	"eventbooker/internal/notification"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	// pollTimeout is how long, in seconds, a getUpdates request waits for updates.
+	pollTimeout = 60
+	// eventsLimit is the number of upcoming events listed by /events.
+	eventsLimit = 10
+)
+
+// Reply templates, rendered in the user's locale by notification.Templates.
+const (
+	tmplStart      = "start"
+	tmplHelp       = "bot_help"
+	tmplNotLinked  = "bot_not_linked"
+	tmplLinked     = "bot_linked"
+	tmplBooking    = "bot_booking"
+	tmplNoBookings = "bot_no_bookings"
+	tmplEvents     = "bot_events"
+	tmplConfirmed  = "bot_confirmed"
+	tmplCancelled  = "bot_cancelled"
+	tmplError      = "bot_error"
+)
+
+// API is the part of the Telegram Bot API used by Bot, implemented by *tgbotapi.BotAPI.
+type API interface {
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// UserServicer defines the user service interface used by Bot.
+type UserServicer interface {
+	GetByTelegram(ctx context.Context, chatID string) (*user.User, error)
+	LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error)
+}
+
+// BookingServicer defines the booking service interface used by Bot.
+type BookingServicer interface {
+	List(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error)
+	Confirm(ctx context.Context, id, userID string) error
+	Cancel(ctx context.Context, id, userID string) (*booking.Booking, error)
+}
+
+// EventServicer defines the event service interface used by Bot.
+type EventServicer interface {
+	List(ctx context.Context, f event.Filter) (*event.Page, error)
+}
+
+// Bot answers commands and the inline buttons under booking notifications:
+//
+//	/start               — the chat ID to register in the app
+//	/start <token>       — link the chat to an account, sent by the deep link
+//	/mybookings          — active bookings, each with Confirm/Cancel buttons
+//	/cancel <booking id> — cancel a booking
+//	/events              — upcoming events
+type Bot struct {
+	api       API
+	users     UserServicer
+	bookings  BookingServicer
+	events    EventServicer
+	templates *notification.Templates
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBot creates a new Bot.
+func NewBot(api API, users UserServicer, bookings BookingServicer, events EventServicer, templates *notification.Templates) *Bot {
+	return &Bot{
+		api:       api,
+		users:     users,
+		bookings:  bookings,
+		events:    events,
+		templates: templates,
+	}
+}
+
+// Start polls for updates in the background until ctx is done or Close is called.
+func (b *Bot) Start(ctx context.Context) error {
+	ctx, b.cancel = context.WithCancel(ctx)
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = pollTimeout
+	updates := b.api.GetUpdatesChan(u)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				b.HandleUpdate(ctx, update)
+			}
+		}
+	}()
+
+	wbzlog.Logger.Info().Msg("Telegram bot started")
+	return nil
+}
+
+// Close stops polling and waits for the current update to be handled.
+func (b *Bot) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+		b.api.StopReceivingUpdates()
+	}
+	b.wg.Wait()
+	return nil
+}
+
+// HandleUpdate answers a command or a button press in a private chat. Other
+// updates are ignored.
+func (b *Bot) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	switch {
+	case update.Message != nil:
+		if update.Message.IsCommand() && update.Message.Chat.IsPrivate() {
+			b.handleCommand(ctx, update.Message)
+		}
+	case update.CallbackQuery != nil:
+		// Buttons under inline-mode messages carry no message.
+		if msg := update.CallbackQuery.Message; msg != nil && msg.Chat.IsPrivate() {
+			b.handleCallback(ctx, update.CallbackQuery)
+		}
+	}
+}
+
+// startData is passed to the "start" and "bot_not_linked" templates.
+type startData struct {
+	Username string
+	ChatID   int64
+}
+
+func (b *Bot) handleCommand(ctx context.Context, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	var username, lang string
+	if msg.From != nil {
+		username, lang = msg.From.UserName, msg.From.LanguageCode
+	}
+
+	switch msg.Command() {
+	case "start":
+		if token := strings.TrimSpace(msg.CommandArguments()); token != "" {
+			b.link(ctx, chatID, token, lang)
+			return
+		}
+		wbzlog.Logger.Debug().Msgf("[TG] user %s started bot, chat_id=%d", username, chatID)
+		b.send(chatID, b.render(lang, tmplStart, startData{Username: username, ChatID: chatID}), nil)
+
+	case "events":
+		// The catalogue is public, the account only picks the locale.
+		if u, _ := b.users.GetByTelegram(ctx, strconv.FormatInt(chatID, 10)); u != nil {
+			lang = locale(u, lang)
+		}
+		b.listEvents(ctx, chatID, lang)
+
+	case "mybookings":
+		u, failure := b.linkedUser(ctx, chatID, lang)
+		if u == nil {
+			b.send(chatID, failure, nil)
+			return
+		}
+		b.listBookings(ctx, chatID, u, locale(u, lang))
+
+	case "cancel":
+		u, failure := b.linkedUser(ctx, chatID, lang)
+		if u == nil {
+			b.send(chatID, failure, nil)
+			return
+		}
+		lang = locale(u, lang)
+
+		id := strings.TrimSpace(msg.CommandArguments())
+		if id == "" {
+			b.send(chatID, b.render(lang, tmplHelp, nil), nil)
+			return
+		}
+		if _, err := b.bookings.Cancel(ctx, id, u.ID.String()); err != nil {
+			b.send(chatID, b.errorText(lang, err), nil)
+			return
+		}
+		b.send(chatID, b.render(lang, tmplCancelled, nil), nil)
+
+	default:
+		b.send(chatID, b.render(lang, tmplHelp, nil), nil)
+	}
+}
+
+func (b *Bot) handleCallback(ctx context.Context, q *tgbotapi.CallbackQuery) {
+	chatID := q.Message.Chat.ID
+	var lang string
+	if q.From != nil {
+		lang = q.From.LanguageCode
+	}
+
+	action, bookingID, ok := notification.ParseCallbackData(q.Data)
+	if !ok {
+		b.answer(q.ID, "", false)
+		return
+	}
+
+	u, failure := b.linkedUser(ctx, chatID, lang)
+	if u == nil {
+		b.answer(q.ID, failure, true)
+		return
+	}
+	lang = locale(u, lang)
+
+	var (
+		err   error
+		reply string
+		// The buttons that still make sense once the action is done.
+		keyboard = tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	)
+	switch action {
+	case notification.ActionConfirm:
+		err = b.bookings.Confirm(ctx, bookingID.String(), u.ID.String())
+		reply = tmplConfirmed
+		keyboard = notification.InlineKeyboard(b.templates.Buttons(lang, bookingID, notification.ActionCancel))
+	case notification.ActionCancel:
+		_, err = b.bookings.Cancel(ctx, bookingID.String(), u.ID.String())
+		reply = tmplCancelled
+	}
+	if err != nil {
+		b.answer(q.ID, b.errorText(lang, err), true)
+		return
+	}
+
+	b.answer(q.ID, b.render(lang, reply, nil), false)
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, q.Message.MessageID, keyboard)
+	if _, err = b.api.Request(edit); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to update Telegram message buttons")
+	}
+}
+
+// linkData is passed to the "bot_linked" template.
+type linkData struct {
+	Login string
+}
+
+// link binds the chat to the account that requested the deep link with the token.
+func (b *Bot) link(ctx context.Context, chatID int64, token, lang string) {
+	u, err := b.users.LinkTelegram(ctx, token, strconv.FormatInt(chatID, 10))
+	if err != nil {
+		b.send(chatID, b.errorText(lang, err), nil)
+		return
+	}
+
+	b.send(chatID, b.render(locale(u, lang), tmplLinked, linkData{Login: u.Login}), nil)
+}
+
+// bookingData is passed to the "bot_booking" template.
+type bookingData struct {
+	ID         string
+	EventName  string
+	EventDate  time.Time
+	TicketType string
+	Persons    int
+	// ExpiresAt is the payment deadline of a pending booking, zero once it is confirmed.
+	ExpiresAt time.Time
+}
+
+// listBookings sends every active booking of the user as a message of its own, with
+// the buttons that apply to it.
+func (b *Bot) listBookings(ctx context.Context, chatID int64, u *user.User, lang string) {
+	bookings, err := b.bookings.List(ctx, u.ID.String(), []booking.Status{booking.StatusCreated, booking.StatusConfirmed})
+	if err != nil {
+		b.send(chatID, b.errorText(lang, err), nil)
+		return
+	}
+
+	if len(bookings) == 0 {
+		b.send(chatID, b.render(lang, tmplNoBookings, nil), nil)
+		return
+	}
+
+	for _, bk := range bookings {
+		data := bookingData{
+			ID:         bk.ID.String(),
+			EventName:  bk.EventName,
+			EventDate:  bk.EventDate,
+			TicketType: bk.TicketTypeName,
+			Persons:    bk.Count,
+		}
+		actions := []string{notification.ActionCancel}
+		if bk.Status == booking.StatusCreated {
+			data.ExpiresAt = bk.ExpiredAt
+			actions = []string{notification.ActionConfirm, notification.ActionCancel}
+		}
+		b.send(chatID, b.render(lang, tmplBooking, data), b.templates.Buttons(lang, bk.ID, actions...))
+	}
+}
+
+// eventsData is passed to the "bot_events" template.
+type eventsData struct {
+	Events []*event.Event
+}
+
+func (b *Bot) listEvents(ctx context.Context, chatID int64, lang string) {
+	page, err := b.events.List(ctx, event.Filter{DateFrom: time.Now(), Sort: event.SortDateAsc, Limit: eventsLimit})
+	if err != nil {
+		b.send(chatID, b.errorText(lang, err), nil)
+		return
+	}
+
+	b.send(chatID, b.render(lang, tmplEvents, eventsData{Events: page.Events}), nil)
+}
+
+// linkedUser returns the user who registered the chat, or nil and the text to
+// answer with when there is none.
+func (b *Bot) linkedUser(ctx context.Context, chatID int64, lang string) (*user.User, string) {
+	u, err := b.users.GetByTelegram(ctx, strconv.FormatInt(chatID, 10))
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, b.render(lang, tmplNotLinked, startData{ChatID: chatID})
+	}
+	if err != nil {
+		return nil, b.errorText(lang, err)
+	}
+	return u, ""
+}
+
+// locale prefers the locale of the account to the language of the Telegram client.
+func locale(u *user.User, lang string) string {
+	if u.Locale != "" {
+		return u.Locale
+	}
+	return lang
+}
+
+// errorData is passed to the "bot_error" template.
+type errorData struct {
+	Message string
+}
+
+// errorText describes an application error to the user. Unknown errors are logged
+// and hidden behind a generic text so internals never leak.
+func (b *Bot) errorText(lang string, err error) string {
+	var data errorData
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		data.Message = appErr.Message
+	} else {
+		wbzlog.Logger.Error().Err(err).Msg("Telegram command failed")
+	}
+	return b.render(lang, tmplError, data)
+}
+
+// render returns the text of a reply template, or an empty string when it cannot
+// be rendered.
+func (b *Bot) render(lang, name string, data any) string {
+	c, err := b.templates.Render(lang, name, data)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot render Telegram %s reply", name)
+		return ""
+	}
+	return c.Text
+}
+
+func (b *Bot) send(chatID int64, text string, buttons []notification.Button) {
+	if text == "" {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if len(buttons) > 0 {
+		msg.ReplyMarkup = notification.InlineKeyboard(buttons)
+	}
+	if _, err := b.api.Send(msg); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to send Telegram message")
+	}
+}
+
+// answer stops the progress indicator of a pressed button, showing text as a
+// notification or, when alert is set, in a dialog.
+func (b *Bot) answer(queryID, text string, alert bool) {
+	cb := tgbotapi.NewCallback(queryID, text)
+	cb.ShowAlert = alert
+	if _, err := b.api.Request(cb); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to answer Telegram callback query")
+	}
+}
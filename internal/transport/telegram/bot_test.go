@@ -0,0 +1,260 @@
+package telegram_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/domain/user"
+	"eventbooker/internal/notification"
+	"eventbooker/internal/transport/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+type fakeAPI struct {
+	sent     []tgbotapi.MessageConfig
+	answers  []tgbotapi.CallbackConfig
+	keyboard []tgbotapi.EditMessageReplyMarkupConfig
+}
+
+func (f *fakeAPI) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel { return nil }
+func (f *fakeAPI) StopReceivingUpdates()                                        {}
+func (f *fakeAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.sent = append(f.sent, c.(tgbotapi.MessageConfig))
+	return tgbotapi.Message{}, nil
+}
+func (f *fakeAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	switch c := c.(type) {
+	case tgbotapi.CallbackConfig:
+		f.answers = append(f.answers, c)
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		f.keyboard = append(f.keyboard, c)
+	}
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+type fakeUsers map[string]*user.User
+
+func (f fakeUsers) GetByTelegram(ctx context.Context, chatID string) (*user.User, error) {
+	if u, ok := f[chatID]; ok {
+		return u, nil
+	}
+	return nil, user.ErrNotFound
+}
+
+// LinkTelegram accepts the token "valid".
+func (f fakeUsers) LinkTelegram(ctx context.Context, token, chatID string) (*user.User, error) {
+	if token != "valid" {
+		return nil, user.ErrInvalidLinkToken
+	}
+	u := &user.User{ID: uuid.New(), Login: "alice", Telegram: chatID}
+	f[chatID] = u
+	return u, nil
+}
+
+type fakeBookings struct {
+	bookings  []*booking.Booking
+	confirmed []string
+	cancelled []string
+}
+
+func (f *fakeBookings) List(ctx context.Context, userID string, statuses []booking.Status) ([]*booking.Booking, error) {
+	var out []*booking.Booking
+	for _, b := range f.bookings {
+		if b.UserID.String() == userID {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+func (f *fakeBookings) owned(id, userID string) error {
+	for _, b := range f.bookings {
+		if b.ID.String() == id && b.UserID.String() == userID {
+			return nil
+		}
+	}
+	return booking.ErrNotFound
+}
+func (f *fakeBookings) Confirm(ctx context.Context, id, userID string) error {
+	if err := f.owned(id, userID); err != nil {
+		return err
+	}
+	f.confirmed = append(f.confirmed, id)
+	return nil
+}
+func (f *fakeBookings) Cancel(ctx context.Context, id, userID string) (*booking.Booking, error) {
+	if err := f.owned(id, userID); err != nil {
+		return nil, err
+	}
+	f.cancelled = append(f.cancelled, id)
+	return &booking.Booking{}, nil
+}
+
+type fakeEvents struct{ filter event.Filter }
+
+func (f *fakeEvents) List(ctx context.Context, filter event.Filter) (*event.Page, error) {
+	f.filter = filter
+	return &event.Page{Events: []*event.Event{{ID: uuid.New(), Name: "Gig", Date: time.Now().Add(time.Hour), FreePlaces: 3, Price: 10}}}, nil
+}
+
+const chatID = 42
+
+func newBot(t *testing.T) (*telegram.Bot, *fakeAPI, *fakeBookings, *user.User) {
+	t.Helper()
+	u := &user.User{ID: uuid.New(), Telegram: "42", Locale: "en"}
+	bookings := &fakeBookings{bookings: []*booking.Booking{
+		{ID: uuid.New(), UserID: u.ID, EventName: "Gig", Status: booking.StatusCreated, Count: 2, ExpiredAt: time.Now().Add(time.Hour)},
+		{ID: uuid.New(), UserID: u.ID, EventName: "Play", Status: booking.StatusConfirmed, Count: 1},
+		{ID: uuid.New(), UserID: uuid.New(), EventName: "Other", Status: booking.StatusCreated},
+	}}
+	api := &fakeAPI{}
+	templates := notification.NewTemplates(&config.NotificationConfig{})
+	return telegram.NewBot(api, fakeUsers{"42": u}, bookings, &fakeEvents{}, templates), api, bookings, u
+}
+
+func command(chat int64, text string) tgbotapi.Update {
+	name, _, _ := strings.Cut(text, " ")
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Chat:     &tgbotapi.Chat{ID: chat, Type: "private"},
+		From:     &tgbotapi.User{ID: chat, LanguageCode: "en"},
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(name)}},
+	}}
+}
+
+func press(chat int64, data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "q1",
+		From:    &tgbotapi.User{ID: chat},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chat, Type: "private"}},
+		Data:    data,
+	}}
+}
+
+func TestBot_MyBookings(t *testing.T) {
+	bot, api, bookings, _ := newBot(t)
+
+	bot.HandleUpdate(context.Background(), command(chatID, "/mybookings"))
+
+	if len(api.sent) != 2 {
+		t.Fatalf("expected one message per own booking, got %d", len(api.sent))
+	}
+	pending := api.sent[0].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	if len(pending) != 2 || *pending[0].CallbackData != "confirm:"+bookings.bookings[0].ID.String() {
+		t.Errorf("expected confirm and cancel buttons on a pending booking, got %+v", pending)
+	}
+	confirmed := api.sent[1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	if len(confirmed) != 1 || *confirmed[0].CallbackData != "cancel:"+bookings.bookings[1].ID.String() {
+		t.Errorf("expected only a cancel button on a confirmed booking, got %+v", confirmed)
+	}
+	if !strings.Contains(api.sent[0].Text, bookings.bookings[0].ID.String()) {
+		t.Errorf("expected the booking ID in %q", api.sent[0].Text)
+	}
+}
+
+func TestBot_NotLinked(t *testing.T) {
+	bot, api, _, _ := newBot(t)
+
+	bot.HandleUpdate(context.Background(), command(7, "/mybookings"))
+
+	if len(api.sent) != 1 || !strings.Contains(api.sent[0].Text, "not linked") || !strings.Contains(api.sent[0].Text, "7") {
+		t.Fatalf("expected a not linked reply with the chat ID, got %+v", api.sent)
+	}
+}
+
+func TestBot_CancelCommand(t *testing.T) {
+	bot, api, bookings, _ := newBot(t)
+	own, other := bookings.bookings[1].ID.String(), bookings.bookings[2].ID.String()
+
+	bot.HandleUpdate(context.Background(), command(chatID, "/cancel "+own))
+	bot.HandleUpdate(context.Background(), command(chatID, "/cancel "+other))
+
+	if len(bookings.cancelled) != 1 || bookings.cancelled[0] != own {
+		t.Fatalf("expected only the own booking to be cancelled, got %v", bookings.cancelled)
+	}
+	if !strings.Contains(api.sent[1].Text, "booking not found") {
+		t.Errorf("expected the service error in %q", api.sent[1].Text)
+	}
+}
+
+func TestBot_ConfirmButton(t *testing.T) {
+	bot, api, bookings, _ := newBot(t)
+	id := bookings.bookings[0].ID
+
+	bot.HandleUpdate(context.Background(), press(chatID, notification.CallbackData(notification.ActionConfirm, id)))
+
+	if len(bookings.confirmed) != 1 || bookings.confirmed[0] != id.String() {
+		t.Fatalf("expected the booking to be confirmed, got %v", bookings.confirmed)
+	}
+	if len(api.answers) != 1 || api.answers[0].ShowAlert {
+		t.Errorf("expected the button press to be answered, got %+v", api.answers)
+	}
+	if len(api.keyboard) != 1 {
+		t.Fatalf("expected the buttons to be updated, got %+v", api.keyboard)
+	}
+	if row := api.keyboard[0].ReplyMarkup.InlineKeyboard[0]; len(row) != 1 || *row[0].CallbackData != "cancel:"+id.String() {
+		t.Errorf("expected only the cancel button to remain, got %+v", row)
+	}
+}
+
+func TestBot_ButtonOfAnotherUser(t *testing.T) {
+	bot, api, bookings, _ := newBot(t)
+
+	bot.HandleUpdate(context.Background(), press(chatID, notification.CallbackData(notification.ActionCancel, bookings.bookings[2].ID)))
+
+	if len(bookings.cancelled) != 0 {
+		t.Fatal("expected the booking of another user to stay")
+	}
+	if len(api.answers) != 1 || !api.answers[0].ShowAlert || len(api.keyboard) != 0 {
+		t.Errorf("expected an alert and untouched buttons, got %+v %+v", api.answers, api.keyboard)
+	}
+}
+
+func TestBot_StartLinksChat(t *testing.T) {
+	bot, api, _, _ := newBot(t)
+
+	bot.HandleUpdate(context.Background(), command(7, "/start expired"))
+	bot.HandleUpdate(context.Background(), command(7, "/start valid"))
+	bot.HandleUpdate(context.Background(), command(7, "/mybookings"))
+
+	if len(api.sent) != 3 {
+		t.Fatalf("expected 3 replies, got %+v", api.sent)
+	}
+	if !strings.Contains(api.sent[0].Text, "invalid or expired") {
+		t.Errorf("expected the token error, got %q", api.sent[0].Text)
+	}
+	if !strings.Contains(api.sent[1].Text, "alice") {
+		t.Errorf("expected the linked login, got %q", api.sent[1].Text)
+	}
+	if !strings.Contains(api.sent[2].Text, "no active bookings") {
+		t.Errorf("expected the chat to act as the linked user, got %q", api.sent[2].Text)
+	}
+}
+
+func TestBot_IgnoresGroupChats(t *testing.T) {
+	bot, api, _, _ := newBot(t)
+
+	update := command(chatID, "/mybookings")
+	update.Message.Chat.Type = "group"
+	bot.HandleUpdate(context.Background(), update)
+
+	if len(api.sent) != 0 {
+		t.Errorf("expected no reply in a group chat, got %+v", api.sent)
+	}
+}
+
+func TestBot_Events(t *testing.T) {
+	bot, api, _, _ := newBot(t)
+
+	bot.HandleUpdate(context.Background(), command(7, "/events"))
+
+	if len(api.sent) != 1 || !strings.Contains(api.sent[0].Text, "Gig") || !strings.Contains(api.sent[0].Text, "3 seats left") {
+		t.Fatalf("expected the upcoming events, got %+v", api.sent)
+	}
+}
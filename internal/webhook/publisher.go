@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/notification"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// Store defines the repository operation needed by Publisher.
+type Store interface {
+	// EnqueueWebhook creates a pending delivery of the payload for every subscription
+	// of the organizer of the event that receives messages of type t.
+	EnqueueWebhook(ctx context.Context, eventID uuid.UUID, t Type, messageID uuid.UUID, payload []byte) (int, error)
+}
+
+// Message is the JSON body POSTed to subscribers. ID is the same in every delivery
+// of the message, so receivers can drop duplicates.
+type Message struct {
+	ID         uuid.UUID `json:"id"`
+	Type       Type      `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// BookingData is the data of booking messages.
+type BookingData struct {
+	ID           uuid.UUID  `json:"id"`
+	EventID      uuid.UUID  `json:"event_id"`
+	EventName    string     `json:"event_name"`
+	EventDate    time.Time  `json:"event_date"`
+	TicketTypeID uuid.UUID  `json:"ticket_type_id"`
+	TicketType   string     `json:"ticket_type"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Status       string     `json:"status"`
+	Count        int        `json:"count"`
+	Price        float64    `json:"price"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// EventData is the data of event messages.
+type EventData struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Date           time.Time `json:"date"`
+	Status         string    `json:"status"`
+	MaxCountPeople int       `json:"max_count_people"`
+	FreePlaces     int       `json:"free_places"`
+	Price          float64   `json:"price"`
+}
+
+// Publisher turns booking notifications and event changes into webhook deliveries.
+// It implements the Notifier interfaces of the services and expirers.
+type Publisher struct {
+	store Store
+}
+
+// NewPublisher creates a new Publisher.
+func NewPublisher(store Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+// typeFor maps a booking notification to its webhook message type. Bookings made
+// from the waitlist are reported as created.
+func typeFor(kind notification.Kind) (Type, bool) {
+	switch kind {
+	case notification.KindBookingCreated, notification.KindBookingPromoted:
+		return TypeBookingCreated, true
+	case notification.KindBookingConfirmed:
+		return TypeBookingConfirmed, true
+	case notification.KindBookingCancelled, notification.KindEventCancelled:
+		return TypeBookingCancelled, true
+	case notification.KindBookingExpired:
+		return TypeBookingExpired, true
+	}
+	return "", false
+}
+
+// Notify publishes the change of b described by the notification kind. Reminders
+// and warnings change nothing and are not published.
+func (p *Publisher) Notify(kind notification.Kind, b *booking.Booking) {
+	t, ok := typeFor(kind)
+	if !ok {
+		return
+	}
+
+	data := BookingData{
+		ID:           b.ID,
+		EventID:      b.EventID,
+		EventName:    b.EventName,
+		EventDate:    b.EventDate,
+		TicketTypeID: b.TicketTypeID,
+		TicketType:   b.TicketTypeName,
+		UserID:       b.UserID,
+		Status:       string(b.Status),
+		Count:        b.Count,
+		Price:        b.Price,
+		CreatedAt:    b.CreatedAt,
+	}
+	if b.Status == booking.StatusCreated && !b.ExpiredAt.IsZero() {
+		data.ExpiresAt = &b.ExpiredAt
+	}
+
+	p.publish(b.EventID, t, data)
+}
+
+// EventUpdated publishes a change of e, including its cancellation.
+func (p *Publisher) EventUpdated(e *event.Event) {
+	p.publish(e.ID, TypeEventUpdated, EventData{
+		ID:             e.ID,
+		Name:           e.Name,
+		Description:    e.Description,
+		Date:           e.Date,
+		Status:         string(e.Status),
+		MaxCountPeople: e.MaxCountPeople,
+		FreePlaces:     e.FreePlaces,
+		Price:          e.Price,
+	})
+}
+
+// publish queues the message for the subscribers. Failures are only logged: a
+// webhook must never fail the change it reports.
+func (p *Publisher) publish(eventID uuid.UUID, t Type, data any) {
+	msg := Message{ID: uuid.New(), Type: t, OccurredAt: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot encode %s webhook", t)
+		return
+	}
+
+	if _, err = p.store.EnqueueWebhook(context.Background(), eventID, t, msg.ID, payload); err != nil {
+		wbzlog.Logger.Error().Err(err).Msgf("cannot queue %s webhook for event %s", t, eventID)
+	}
+}
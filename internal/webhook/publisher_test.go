@@ -0,0 +1,84 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"eventbooker/internal/domain/booking"
+	"eventbooker/internal/domain/event"
+	"eventbooker/internal/notification"
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+type queued struct {
+	eventID uuid.UUID
+	typ     webhook.Type
+	payload []byte
+}
+
+type fakeStore struct{ queued []queued }
+
+func (f *fakeStore) EnqueueWebhook(ctx context.Context, eventID uuid.UUID, t webhook.Type, messageID uuid.UUID, payload []byte) (int, error) {
+	f.queued = append(f.queued, queued{eventID: eventID, typ: t, payload: payload})
+	return 1, nil
+}
+
+func TestPublisher_Notify_MapsKinds(t *testing.T) {
+	store := &fakeStore{}
+	p := webhook.NewPublisher(store)
+	b := &booking.Booking{ID: uuid.New(), EventID: uuid.New(), Status: booking.StatusCancelled, EmailRecepient: "a@example.com"}
+
+	for _, kind := range []notification.Kind{
+		notification.KindBookingCreated,
+		notification.KindBookingPromoted,
+		notification.KindBookingConfirmed,
+		notification.KindBookingCancelled,
+		notification.KindEventCancelled,
+		notification.KindBookingExpired,
+		notification.KindExpiryWarning,
+		notification.KindEventReminder,
+	} {
+		p.Notify(kind, b)
+	}
+
+	want := []webhook.Type{
+		webhook.TypeBookingCreated, webhook.TypeBookingCreated, webhook.TypeBookingConfirmed,
+		webhook.TypeBookingCancelled, webhook.TypeBookingCancelled, webhook.TypeBookingExpired,
+	}
+	if len(store.queued) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(store.queued))
+	}
+	for i, q := range store.queued {
+		if q.typ != want[i] || q.eventID != b.EventID {
+			t.Errorf("message %d: expected %s for event %s, got %s for %s", i, want[i], b.EventID, q.typ, q.eventID)
+		}
+	}
+
+	var msg struct {
+		Type webhook.Type           `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(store.queued[0].payload, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != webhook.TypeBookingCreated || msg.Data["id"] != b.ID.String() {
+		t.Errorf("unexpected payload %s", store.queued[0].payload)
+	}
+	if _, leaked := msg.Data["email_recepient"]; leaked {
+		t.Error("recipients must not be sent to subscribers")
+	}
+}
+
+func TestPublisher_EventUpdated(t *testing.T) {
+	store := &fakeStore{}
+	e := &event.Event{ID: uuid.New(), Name: "Gig", Status: event.StatusCancelled}
+
+	webhook.NewPublisher(store).EventUpdated(e)
+
+	if len(store.queued) != 1 || store.queued[0].typ != webhook.TypeEventUpdated || store.queued[0].eventID != e.ID {
+		t.Fatalf("expected one event.updated message, got %+v", store.queued)
+	}
+}
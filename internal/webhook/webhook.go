@@ -0,0 +1,246 @@
+// Package webhook delivers booking and event lifecycle messages to URLs registered
+// by organizers, so external systems such as a CRM can react to them.
+//
+// A Publisher fans every message out into one delivery per matching subscription
+// of the event's organizer; a Worker POSTs pending deliveries, signed with the
+// subscription secret, and retries failed ones with a backoff.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"eventbooker/internal/apperr"
+
+	"github.com/google/uuid"
+)
+
+// Type is the kind of a webhook message.
+type Type string
+
+const (
+	TypeBookingCreated   Type = "booking.created"
+	TypeBookingConfirmed Type = "booking.confirmed"
+	TypeBookingCancelled Type = "booking.cancelled"
+	TypeBookingExpired   Type = "booking.expired"
+	TypeEventUpdated     Type = "event.updated"
+)
+
+// Types lists every message type a subscription can receive.
+var Types = []Type{TypeBookingCreated, TypeBookingConfirmed, TypeBookingCancelled, TypeBookingExpired, TypeEventUpdated}
+
+// Valid reports whether t is a known message type.
+func (t Type) Valid() bool {
+	return slices.Contains(Types, t)
+}
+
+var (
+	ErrNotFound     = apperr.NotFound("webhook_not_found", "webhook subscription not found")
+	ErrNotOwner     = apperr.Forbidden("webhook_forbidden", "only the owner can manage the webhook subscription")
+	ErrInvalidURL   = apperr.Validation("invalid_webhook_url", "url must be an absolute http or https URL")
+	ErrPrivateURL   = apperr.Validation("invalid_webhook_url", "url must point to a public address")
+	ErrNoTypes      = apperr.Validation("invalid_event_types", "at least one event type is required")
+	ErrShortSecret  = apperr.Validation("invalid_webhook_secret", "secret must be at least 16 characters long")
+	ErrBadSignature = errors.New("invalid webhook signature")
+)
+
+// minSecretLength keeps user-chosen secrets from being guessable.
+const minSecretLength = 16
+
+// Subscription is an organizer's request to receive messages of the given types
+// about their events at a URL.
+type Subscription struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	URL       string
+	Secret    string
+	Types     []Type
+	CreatedAt time.Time
+}
+
+// NewSubscription validates and creates a subscription. A random secret is
+// generated when none is given.
+func NewSubscription(userID uuid.UUID, rawURL, secret string, types []Type) (*Subscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+	if !publicHost(u.Hostname()) {
+		return nil, ErrPrivateURL
+	}
+
+	if len(types) == 0 {
+		return nil, ErrNoTypes
+	}
+	unique := make([]Type, 0, len(types))
+	for _, t := range types {
+		if !t.Valid() {
+			return nil, apperr.Validation("invalid_event_types", fmt.Sprintf("unknown event type %q", t))
+		}
+		if !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+
+	if secret == "" {
+		secret = newSecret()
+	} else if len(secret) < minSecretLength {
+		return nil, ErrShortSecret
+	}
+
+	return &Subscription{
+		ID:        uuid.New(),
+		UserID:    userID,
+		URL:       u.String(),
+		Secret:    secret,
+		Types:     unique,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// OwnedBy returns ErrNotOwner unless the subscription belongs to the user.
+func (s *Subscription) OwnedBy(userID uuid.UUID) error {
+	if s.UserID != userID {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// reservedPrefixes are not private by net/netip, but are not reachable on the
+// internet either.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// publicIP reports whether webhooks may be sent to ip. Loopback, private, link-local
+// (such as the cloud metadata address 169.254.169.254), multicast and reserved
+// addresses are refused, so a subscription cannot reach inside our network.
+func publicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicHost rejects hosts that plainly point inside the network. A name may resolve
+// to anything, so the Worker checks the address again when it connects.
+func publicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return publicIP(ip)
+	}
+	return true
+}
+
+func newSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// DeliveryStatus is the state of a delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending means the delivery has not been attempted yet.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered means the endpoint answered with a 2xx status.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed means the last attempt failed and another one is scheduled.
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliveryAbandoned means every attempt failed and no more are scheduled.
+	DeliveryAbandoned DeliveryStatus = "abandoned"
+)
+
+// Delivery is one message sent to one subscription.
+type Delivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	MessageID      uuid.UUID
+	Type           Type
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+
+	// URL and Secret are those of the subscription, loaded when the delivery is claimed.
+	URL    string
+	Secret string
+}
+
+// Signature headers. The signature header holds the Unix time the request was
+// signed at and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the
+// subscription secret: "t=1700000000,v1=5257a8...".
+const (
+	HeaderSignature = "X-EventBooker-Signature"
+	HeaderEvent     = "X-EventBooker-Event"
+	HeaderDelivery  = "X-EventBooker-Delivery"
+)
+
+// Sign returns the signature header value for body signed at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks a signature header produced by Sign. Signatures older than
+// tolerance are rejected to prevent replays; zero tolerance disables the check.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrBadSignature
+	}
+	if tolerance > 0 && now.Sub(time.Unix(unix, 0)) > tolerance {
+		return fmt.Errorf("%w: too old", ErrBadSignature)
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, ts, body)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
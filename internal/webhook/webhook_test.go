@@ -0,0 +1,76 @@
+package webhook_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"eventbooker/internal/apperr"
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+func TestNewSubscription(t *testing.T) {
+	userID := uuid.New()
+
+	s, err := webhook.NewSubscription(userID, "https://crm.example.com/hooks", "", []webhook.Type{webhook.TypeBookingCreated, webhook.TypeBookingCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s.Secret, "whsec_") {
+		t.Errorf("expected a generated secret, got %q", s.Secret)
+	}
+	if len(s.Types) != 1 {
+		t.Errorf("expected duplicate types to be dropped, got %v", s.Types)
+	}
+
+	cases := []struct {
+		name   string
+		url    string
+		secret string
+		types  []webhook.Type
+	}{
+		{"relative url", "/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"ftp url", "ftp://example.com", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"no types", "https://example.com", "", nil},
+		{"unknown type", "https://example.com", "", []webhook.Type{"booking.paid"}},
+		{"short secret", "https://example.com", "secret", []webhook.Type{webhook.TypeEventUpdated}},
+		{"localhost", "http://localhost:8080/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"loopback", "http://127.0.0.1/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"private", "http://10.0.0.5/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"metadata", "http://169.254.169.254/latest/meta-data", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"ipv6 loopback", "http://[::1]/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+		{"mapped ipv4", "http://[::ffff:192.168.0.1]/hooks", "", []webhook.Type{webhook.TypeEventUpdated}},
+	}
+	for _, tc := range cases {
+		if _, err := webhook.NewSubscription(userID, tc.url, tc.secret, tc.types); !errors.Is(err, apperr.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	secret := "0123456789abcdef"
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+
+	header := webhook.Sign(secret, now, body)
+	if err := webhook.Verify(secret, header, body, 5*time.Minute, now); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+
+	if err := webhook.Verify("another-secret-123", header, body, 0, now); !errors.Is(err, webhook.ErrBadSignature) {
+		t.Errorf("expected a wrong secret to fail, got %v", err)
+	}
+	if err := webhook.Verify(secret, header, []byte(`{"id":"2"}`), 0, now); !errors.Is(err, webhook.ErrBadSignature) {
+		t.Errorf("expected a changed body to fail, got %v", err)
+	}
+	if err := webhook.Verify(secret, header, body, time.Minute, now.Add(time.Hour)); !errors.Is(err, webhook.ErrBadSignature) {
+		t.Errorf("expected an old signature to fail, got %v", err)
+	}
+	if err := webhook.Verify(secret, "garbage", body, 0, now); !errors.Is(err, webhook.ErrBadSignature) {
+		t.Errorf("expected a malformed header to fail, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"eventbooker/internal/config"
+
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const (
+	defaultInterval      = 5 * time.Second
+	defaultBatch         = 50
+	defaultTimeout       = 10 * time.Second
+	defaultRetryDelay    = 30 * time.Second
+	defaultMaxRetryDelay = 6 * time.Hour
+	defaultMaxAttempts   = 10
+	defaultRetention     = 7 * 24 * time.Hour
+
+	// pruneInterval is how often finished deliveries past the retention are deleted,
+	// in batches of pruneBatch.
+	pruneInterval = time.Hour
+	pruneBatch    = 1000
+
+	userAgent = "EventBooker-Webhooks/1.0"
+)
+
+// errPrivateAddress is returned for deliveries whose host resolves to an address
+// that is not public. Such deliveries are abandoned at once.
+var errPrivateAddress = errors.New("webhook host is not a public address")
+
+// DeliveryStore defines the repository operations needed by Worker.
+type DeliveryStore interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*Delivery, error)
+	MarkWebhookDelivered(ctx context.Context, id uuid.UUID, statusCode int) error
+	MarkWebhookFailed(ctx context.Context, id uuid.UUID, statusCode int, reason string, retryIn time.Duration) error
+	AbandonWebhook(ctx context.Context, id uuid.UUID, statusCode int, reason string) error
+	PruneWebhookDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error)
+}
+
+// Worker periodically POSTs pending deliveries to the subscribers. A delivery
+// succeeds when the endpoint answers 2xx; otherwise it is retried with a doubling
+// delay until it runs out of attempts. Delivery is at least once. Redirects are not
+// followed, and only public addresses are dialled unless webhook.allow_private is set.
+// Delivered and abandoned deliveries are deleted once they are older than the retention.
+type Worker struct {
+	store         DeliveryStore
+	client        *http.Client
+	interval      time.Duration
+	batch         int
+	lease         time.Duration
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	maxAttempts   int
+	retention     time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker creates a new Worker.
+func NewWorker(store DeliveryStore, cfg *config.WebhookConfig) *Worker {
+	w := &Worker{
+		store:         store,
+		interval:      cfg.Interval,
+		batch:         cfg.Batch,
+		retryDelay:    cfg.RetryDelay,
+		maxRetryDelay: cfg.MaxRetryDelay,
+		maxAttempts:   cfg.MaxAttempts,
+		retention:     cfg.Retention,
+	}
+	if w.interval <= 0 {
+		w.interval = defaultInterval
+	}
+	if w.batch <= 0 {
+		w.batch = defaultBatch
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if w.retryDelay <= 0 {
+		w.retryDelay = defaultRetryDelay
+	}
+	if w.maxRetryDelay <= 0 {
+		w.maxRetryDelay = defaultMaxRetryDelay
+	}
+	if w.maxAttempts <= 0 {
+		w.maxAttempts = defaultMaxAttempts
+	}
+	if w.retention <= 0 {
+		w.retention = defaultRetention
+	}
+
+	w.client = newClient(timeout, cfg.AllowPrivate)
+	// A claimed delivery stays hidden from other workers while its batch is sent,
+	// and every delivery of the batch may take the whole timeout.
+	w.lease = max(time.Minute, time.Duration(w.batch)*timeout)
+	return w
+}
+
+// newClient returns the HTTP client for deliveries. It does not follow redirects,
+// which could lead elsewhere, and unless allowPrivate is set it refuses to connect
+// to addresses that are not public. The check runs on the resolved address, so
+// it also holds for names that resolve inside the network.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would dial the subscriber itself, bypassing the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivate is a net.Dialer Control function that fails connections to
+// addresses that are not public.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicIP(addr.Addr()) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, addr.Addr())
+	}
+	return nil
+}
+
+// Start runs the delivery loop in the background until ctx is done or Close is called.
+func (w *Worker) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		var pruned time.Time
+		for {
+			// A full batch means more deliveries are likely waiting.
+			for ctx.Err() == nil {
+				if w.DeliverOnce(ctx) < w.batch {
+					break
+				}
+			}
+
+			if time.Since(pruned) >= pruneInterval {
+				for ctx.Err() == nil {
+					if w.PruneOnce(ctx) < pruneBatch {
+						break
+					}
+				}
+				pruned = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// DeliverOnce sends one batch of due deliveries and returns how many were claimed.
+func (w *Worker) DeliverOnce(ctx context.Context) int {
+	deliveries, err := w.store.ClaimWebhookDeliveries(ctx, w.batch, w.lease)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to claim webhook deliveries")
+		return 0
+	}
+
+	for _, d := range deliveries {
+		code, err := w.post(ctx, d)
+		if err == nil {
+			if err = w.store.MarkWebhookDelivered(ctx, d.ID, code); err != nil {
+				wbzlog.Logger.Error().Err(err).Msgf("failed to mark webhook delivery %s as delivered", d.ID)
+			}
+			continue
+		}
+
+		wbzlog.Logger.Warn().Err(err).Msgf("webhook delivery %s (%s) to %s failed, attempt %d", d.ID, d.Type, d.URL, d.Attempts)
+		if d.Attempts >= w.maxAttempts || errors.Is(err, errPrivateAddress) {
+			err = w.store.AbandonWebhook(ctx, d.ID, code, err.Error())
+		} else {
+			err = w.store.MarkWebhookFailed(ctx, d.ID, code, err.Error(), w.backoff(d.Attempts))
+		}
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msgf("failed to record webhook delivery failure %s", d.ID)
+		}
+	}
+
+	return len(deliveries)
+}
+
+// PruneOnce deletes one batch of deliveries finished longer than the retention ago
+// and returns how many were deleted.
+func (w *Worker) PruneOnce(ctx context.Context) int {
+	n, err := w.store.PruneWebhookDeliveries(ctx, w.retention, pruneBatch)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("failed to prune webhook deliveries")
+		return 0
+	}
+	return n
+}
+
+// post sends a delivery and returns the response status code, zero when there was
+// no response.
+func (w *Worker) post(ctx context.Context, d *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, string(d.Type))
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderSignature, Sign(d.Secret, time.Now(), d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the retry delay with every attempt up to maxRetryDelay.
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.retryDelay
+	for i := 1; i < attempts && d < w.maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, w.maxRetryDelay)
+}
+
+// Close stops the delivery loop and waits for the current batch to finish.
+func (w *Worker) Close() error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	return nil
+}
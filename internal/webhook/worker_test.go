@@ -0,0 +1,180 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"eventbooker/internal/config"
+	"eventbooker/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+type result struct {
+	code    int
+	reason  string
+	retryIn time.Duration
+}
+
+type fakeDeliveryStore struct {
+	due       []*webhook.Delivery
+	delivered map[uuid.UUID]int
+	failed    map[uuid.UUID]result
+	abandoned map[uuid.UUID]result
+	pruneAge  time.Duration
+}
+
+func newFakeDeliveryStore(due ...*webhook.Delivery) *fakeDeliveryStore {
+	return &fakeDeliveryStore{
+		due:       due,
+		delivered: make(map[uuid.UUID]int),
+		failed:    make(map[uuid.UUID]result),
+		abandoned: make(map[uuid.UUID]result),
+	}
+}
+
+func (f *fakeDeliveryStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*webhook.Delivery, error) {
+	due := f.due
+	f.due = nil
+	for _, d := range due {
+		d.Attempts++
+	}
+	return due, nil
+}
+
+func (f *fakeDeliveryStore) MarkWebhookDelivered(ctx context.Context, id uuid.UUID, statusCode int) error {
+	f.delivered[id] = statusCode
+	return nil
+}
+
+func (f *fakeDeliveryStore) MarkWebhookFailed(ctx context.Context, id uuid.UUID, statusCode int, reason string, retryIn time.Duration) error {
+	f.failed[id] = result{code: statusCode, reason: reason, retryIn: retryIn}
+	return nil
+}
+
+func (f *fakeDeliveryStore) AbandonWebhook(ctx context.Context, id uuid.UUID, statusCode int, reason string) error {
+	f.abandoned[id] = result{code: statusCode, reason: reason}
+	return nil
+}
+
+func (f *fakeDeliveryStore) PruneWebhookDeliveries(ctx context.Context, olderThan time.Duration, limit int) (int, error) {
+	f.pruneAge = olderThan
+	return 0, nil
+}
+
+func TestWorker_DeliverOnce_SignsRequests(t *testing.T) {
+	const secret = "0123456789abcdef"
+	payload := []byte(`{"id":"m1","type":"booking.created"}`)
+
+	var (
+		gotEvent, gotDelivery string
+		verifyErr             error
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotEvent = r.Header.Get(webhook.HeaderEvent)
+		gotDelivery = r.Header.Get(webhook.HeaderDelivery)
+		verifyErr = webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), body, time.Minute, time.Now())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := &webhook.Delivery{ID: uuid.New(), Type: webhook.TypeBookingCreated, Payload: payload, URL: srv.URL, Secret: secret}
+	store := newFakeDeliveryStore(d)
+
+	// The test server listens on loopback.
+	if n := webhook.NewWorker(store, &config.WebhookConfig{AllowPrivate: true}).DeliverOnce(context.Background()); n != 1 {
+		t.Fatalf("expected 1 claimed delivery, got %d", n)
+	}
+
+	if verifyErr != nil {
+		t.Errorf("expected a valid signature, got %v", verifyErr)
+	}
+	if gotEvent != string(webhook.TypeBookingCreated) || gotDelivery != d.ID.String() {
+		t.Errorf("unexpected headers: event %q, delivery %q", gotEvent, gotDelivery)
+	}
+	if code, ok := store.delivered[d.ID]; !ok || code != http.StatusNoContent {
+		t.Errorf("expected the delivery to be marked delivered with 204, got %d", code)
+	}
+}
+
+func TestWorker_DeliverOnce_RetriesFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	retry := &webhook.Delivery{ID: uuid.New(), URL: srv.URL, Secret: "s", Attempts: 2}
+	last := &webhook.Delivery{ID: uuid.New(), URL: srv.URL, Secret: "s", Attempts: 4}
+	store := newFakeDeliveryStore(retry, last)
+	cfg := &config.WebhookConfig{RetryDelay: time.Second, MaxRetryDelay: time.Minute, MaxAttempts: 5, AllowPrivate: true}
+
+	webhook.NewWorker(store, cfg).DeliverOnce(context.Background())
+
+	// The third attempt failed: the delay doubled twice.
+	if r, ok := store.failed[retry.ID]; !ok || r.code != http.StatusServiceUnavailable || r.retryIn != 4*time.Second {
+		t.Errorf("expected a retry in 4s after a 503, got %+v", r)
+	}
+	if _, ok := store.abandoned[last.ID]; !ok {
+		t.Error("expected the delivery to be abandoned after its last attempt")
+	}
+}
+
+func TestWorker_DeliverOnce_RefusesPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := &webhook.Delivery{ID: uuid.New(), URL: srv.URL, Secret: "s"}
+	store := newFakeDeliveryStore(d)
+
+	webhook.NewWorker(store, &config.WebhookConfig{}).DeliverOnce(context.Background())
+
+	if called {
+		t.Error("expected the loopback address not to be dialled")
+	}
+	if _, ok := store.abandoned[d.ID]; !ok {
+		t.Errorf("expected the delivery to be abandoned at once, got %+v", store.failed[d.ID])
+	}
+}
+
+func TestWorker_DeliverOnce_DoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	d := &webhook.Delivery{ID: uuid.New(), URL: srv.URL + "/hooks", Secret: "s"}
+	store := newFakeDeliveryStore(d)
+
+	webhook.NewWorker(store, &config.WebhookConfig{AllowPrivate: true}).DeliverOnce(context.Background())
+
+	if redirected {
+		t.Error("expected the redirect not to be followed")
+	}
+	if r, ok := store.failed[d.ID]; !ok || r.code != http.StatusTemporaryRedirect {
+		t.Errorf("expected a failure with 307, got %+v", r)
+	}
+}
+
+func TestWorker_PruneOnce_DefaultsToAWeek(t *testing.T) {
+	store := newFakeDeliveryStore()
+	w := webhook.NewWorker(store, &config.WebhookConfig{})
+
+	w.PruneOnce(context.Background())
+	if store.pruneAge != 7*24*time.Hour {
+		t.Errorf("expected a retention of one week, got %s", store.pruneAge)
+	}
+}
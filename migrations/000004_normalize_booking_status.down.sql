@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS bookings_user_id_created_at_idx;
+UPDATE bookings SET status = 'cancelled' WHERE status = 'canceled';
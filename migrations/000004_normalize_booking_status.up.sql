@@ -0,0 +1,3 @@
+UPDATE bookings SET status = 'canceled' WHERE status = 'cancelled';
+
+CREATE INDEX IF NOT EXISTS bookings_user_id_created_at_idx ON bookings (user_id, created_at DESC);
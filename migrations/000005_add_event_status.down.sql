@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS events_status_date_idx;
+
+ALTER TABLE events DROP COLUMN IF EXISTS status;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS waitlist (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    count INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    telegram_notification BOOLEAN NOT NULL,
+    email_notification BOOLEAN NOT NULL,
+    booking_id UUID REFERENCES bookings(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS waitlist_waiting_user_idx ON waitlist (event_id, user_id) WHERE status = 'waiting';
+CREATE INDEX IF NOT EXISTS waitlist_waiting_queue_idx ON waitlist (event_id, created_at, id) WHERE status = 'waiting';
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    price NUMERIC(10, 2) NOT NULL,
+    total_seats INTEGER NOT NULL,
+    available_seats INTEGER NOT NULL,
+    booking_ttl INTEGER NOT NULL,
+    UNIQUE (event_id, name)
+);
+
+INSERT INTO ticket_types (id, event_id, name, price, total_seats, available_seats, booking_ttl)
+SELECT gen_random_uuid(), id, 'standard', price, total_seats, available_seats, booking_ttl FROM events;
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS ticket_type_id UUID REFERENCES ticket_types(id) ON DELETE CASCADE;
+UPDATE bookings b SET ticket_type_id = t.id FROM ticket_types t WHERE t.event_id = b.event_id AND b.ticket_type_id IS NULL;
+ALTER TABLE bookings ALTER COLUMN ticket_type_id SET NOT NULL;
+
+ALTER TABLE waitlist ADD COLUMN IF NOT EXISTS ticket_type_id UUID REFERENCES ticket_types(id) ON DELETE CASCADE;
+UPDATE waitlist w SET ticket_type_id = t.id FROM ticket_types t WHERE t.event_id = w.event_id AND w.ticket_type_id IS NULL;
+ALTER TABLE waitlist ALTER COLUMN ticket_type_id SET NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS bookings_expiry_warning_idx;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE bookings DROP COLUMN IF EXISTS expiry_warned_at;
@@ -0,0 +1,4 @@
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS expiry_warned_at TIMESTAMP;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS bookings_expiry_warning_idx ON bookings (expired_at) WHERE status = 'created' AND expiry_warned_at IS NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID PRIMARY KEY,
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    content JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliveries_retry_idx ON notification_deliveries (next_attempt_at) WHERE status = 'failed';
+CREATE INDEX IF NOT EXISTS notification_deliveries_status_idx ON notification_deliveries (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS notification_deliveries_booking_idx ON notification_deliveries (booking_id);
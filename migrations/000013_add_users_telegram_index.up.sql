@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS users_telegram_idx ON users (telegram) WHERE telegram <> '';
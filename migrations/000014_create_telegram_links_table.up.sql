@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS telegram_links (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS telegram_links_user_idx ON telegram_links (user_id);
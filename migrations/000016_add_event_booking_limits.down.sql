@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS bookings_event_user_idx;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS max_seats_per_booking,
+    DROP COLUMN IF EXISTS max_bookings_per_user,
+    DROP COLUMN IF EXISTS max_seats_per_user;
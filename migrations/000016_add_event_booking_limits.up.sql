@@ -0,0 +1,6 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS max_seats_per_booking INTEGER NOT NULL DEFAULT 0 CHECK (max_seats_per_booking >= 0),
+    ADD COLUMN IF NOT EXISTS max_bookings_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_bookings_per_user >= 0),
+    ADD COLUMN IF NOT EXISTS max_seats_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_seats_per_user >= 0);
+
+CREATE INDEX IF NOT EXISTS bookings_event_user_idx ON bookings (event_id, user_id) WHERE status IN ('created', 'confirmed');
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'attendee';
+
+-- Users who already published events keep being able to do so.
+UPDATE users SET role = 'organizer' WHERE role = 'attendee' AND id IN (SELECT creator_id FROM events);
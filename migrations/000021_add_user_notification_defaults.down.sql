@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS telegram_notification;
+ALTER TABLE users DROP COLUMN IF EXISTS email_notification;
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_notification BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS telegram_notification BOOLEAN NOT NULL DEFAULT false;
+
+-- Users with a linked chat keep getting Telegram notifications by default.
+UPDATE users SET telegram_notification = true WHERE telegram <> '';
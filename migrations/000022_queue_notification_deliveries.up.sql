@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS notification_deliveries_retry_idx;
+CREATE INDEX IF NOT EXISTS notification_deliveries_retry_idx ON notification_deliveries (next_attempt_at) WHERE status IN ('pending', 'failed');
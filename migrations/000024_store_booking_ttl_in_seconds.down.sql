@@ -0,0 +1,5 @@
+UPDATE ticket_types SET booking_ttl_seconds = (booking_ttl_seconds + 59) / 60;
+ALTER TABLE ticket_types RENAME COLUMN booking_ttl_seconds TO booking_ttl;
+
+UPDATE events SET booking_ttl_seconds = (booking_ttl_seconds + 59) / 60;
+ALTER TABLE events RENAME COLUMN booking_ttl_seconds TO booking_ttl;
@@ -0,0 +1,5 @@
+ALTER TABLE events RENAME COLUMN booking_ttl TO booking_ttl_seconds;
+UPDATE events SET booking_ttl_seconds = booking_ttl_seconds * 60;
+
+ALTER TABLE ticket_types RENAME COLUMN booking_ttl TO booking_ttl_seconds;
+UPDATE ticket_types SET booking_ttl_seconds = booking_ttl_seconds * 60;
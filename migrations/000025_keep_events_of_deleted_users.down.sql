@@ -0,0 +1,4 @@
+DELETE FROM events WHERE creator_id IS NULL;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_creator_id_fkey;
+ALTER TABLE events ADD CONSTRAINT events_creator_id_fkey FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE events ALTER COLUMN creator_id SET NOT NULL;
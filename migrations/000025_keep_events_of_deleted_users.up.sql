@@ -0,0 +1,3 @@
+ALTER TABLE events ALTER COLUMN creator_id DROP NOT NULL;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_creator_id_fkey;
+ALTER TABLE events ADD CONSTRAINT events_creator_id_fkey FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE SET NULL;
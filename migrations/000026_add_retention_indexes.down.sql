@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS webhook_deliveries_finished_idx;
+DROP INDEX IF EXISTS notification_deliveries_finished_idx;
+DROP INDEX IF EXISTS outbox_sent_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS outbox_sent_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS notification_deliveries_finished_idx ON notification_deliveries (updated_at) WHERE status IN ('sent', 'abandoned');
+CREATE INDEX IF NOT EXISTS webhook_deliveries_finished_idx ON webhook_deliveries (created_at) WHERE status IN ('delivered', 'abandoned');